@@ -221,7 +221,7 @@ func streamLogs(w http.ResponseWriter, r *http.Request) {
 	}
 	defer logMessageReceiver.SetEnabled(false)
 
-	var filters diagnostic.Filters
+	var options diagnostic.StreamOptions
 
 	if r.Body != http.NoBody {
 		body, err := ioutil.ReadAll(r.Body)
@@ -230,19 +230,24 @@ func streamLogs(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if err := json.Unmarshal(body, &filters); err != nil {
+		if err := json.Unmarshal(body, &options); err != nil {
 			http.Error(w, log.Errorf("Error while unmarshaling JSON from request body: %s", err).Error(), 500)
 			return
 		}
 	}
 
+	if err := options.Validate(); err != nil {
+		http.Error(w, log.Errorf("Invalid stream logs filters: %s", err).Error(), 400)
+		return
+	}
+
 	// Reset the `server_timeout` deadline for this connection as streaming holds the connection open.
 	conn := GetConnection(r)
 	_ = conn.SetDeadline(time.Time{})
 
 	done := make(chan struct{})
 	defer close(done)
-	logChan := logMessageReceiver.Filter(&filters, done)
+	logChan := logMessageReceiver.Stream(&options, done)
 	flushTimer := time.NewTicker(time.Second)
 	for {
 		// Handlers for detecting a closed connection (from either the server or client)
@@ -251,7 +256,12 @@ func streamLogs(w http.ResponseWriter, r *http.Request) {
 			return
 		case <-r.Context().Done():
 			return
-		case line := <-logChan:
+		case line, ok := <-logChan:
+			if !ok {
+				// The count or the duration limit is reached
+				flusher.Flush()
+				return
+			}
 			fmt.Fprint(w, line)
 		case <-flushTimer.C:
 			// The buffer will flush on its own most of the time, but when we run out of logs flush so the client is up to date.
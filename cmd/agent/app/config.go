@@ -71,6 +71,9 @@ func initRuntimeSettings() error {
 	if err := commonsettings.RegisterRuntimeSetting(commonsettings.LogPayloadsRuntimeSetting{}); err != nil {
 		return err
 	}
+	if err := commonsettings.RegisterRuntimeSetting(commonsettings.PayloadTeeSampleRateRuntimeSetting("forwarder_payload_tee_sample_rate")); err != nil {
+		return err
+	}
 	if err := commonsettings.RegisterRuntimeSetting(commonsettings.ProfilingGoroutines("internal_profiling_goroutines")); err != nil {
 		return err
 	}
@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+)
+
+var (
+	forwarderQueueCmd = &cobra.Command{
+		Use:   "forwarder-queue",
+		Short: "Inspect and replay the transactions stored on disk by the forwarder",
+		Long: `Inspect and replay the transactions the forwarder stored on disk because they could not be sent.
+
+The agent must be stopped before purging or replaying the files, as the forwarder
+reads and removes them while running.`,
+	}
+
+	forwarderQueueListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the retry files and their transactions",
+		Long:  ``,
+		RunE:  forwarderQueueList,
+	}
+
+	forwarderQueuePurgeCmd = &cobra.Command{
+		Use:   "purge",
+		Short: "Remove transactions from the retry files",
+		Long:  `Remove the transactions older than --older-than and/or sent to the --endpoint endpoint.`,
+		RunE:  forwarderQueuePurge,
+	}
+
+	forwarderQueueReplayCmd = &cobra.Command{
+		Use:   "replay",
+		Short: "Send the transactions of a retry file to a domain",
+		Long:  `Send the transactions of a retry file to a domain, removing them from the file once sent unless --keep is set.`,
+		RunE:  forwarderQueueReplay,
+	}
+
+	forwarderQueueArgs = struct {
+		olderThan time.Duration
+		endpoint  string
+		file      string
+		domain    string
+		keep      bool
+	}{}
+)
+
+func init() {
+	AgentCmd.AddCommand(forwarderQueueCmd)
+	forwarderQueueCmd.AddCommand(forwarderQueueListCmd)
+	forwarderQueueCmd.AddCommand(forwarderQueuePurgeCmd)
+	forwarderQueueCmd.AddCommand(forwarderQueueReplayCmd)
+
+	forwarderQueuePurgeCmd.Flags().DurationVar(&forwarderQueueArgs.olderThan, "older-than", 0, "remove the transactions created before this duration, e.g. 2h")
+	forwarderQueuePurgeCmd.Flags().StringVar(&forwarderQueueArgs.endpoint, "endpoint", "", "remove the transactions of this endpoint name, e.g. series_v1")
+
+	forwarderQueueReplayCmd.Flags().StringVar(&forwarderQueueArgs.file, "file", "", "path of the retry file to replay, as printed by the list command")
+	forwarderQueueReplayCmd.Flags().StringVar(&forwarderQueueArgs.domain, "domain", "", "domain to send the transactions to, e.g. https://app.datadoghq.com")
+	forwarderQueueReplayCmd.Flags().BoolVar(&forwarderQueueArgs.keep, "keep", false, "keep the transactions in the file once sent")
+	forwarderQueueReplayCmd.MarkFlagRequired("file")   //nolint:errcheck
+	forwarderQueueReplayCmd.MarkFlagRequired("domain") //nolint:errcheck
+}
+
+// readForwarderQueueFiles sets up the configuration and reads the retry files
+// of the configured domains.
+func readForwarderQueueFiles() ([]forwarder.RetryQueueFile, error) {
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	err := common.SetupConfig(confFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set up global agent configuration: %v", err)
+	}
+
+	err = config.SetupLogger(loggerName, config.GetEnvDefault("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+	if err != nil {
+		fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+		return nil, err
+	}
+
+	keysPerDomain, err := config.GetMultipleEndpoints()
+	if err != nil {
+		return nil, fmt.Errorf("misconfiguration of agent endpoints: %v", err)
+	}
+
+	return forwarder.ReadRetryQueueFiles(forwarder.RetryQueueStoragePath(), keysPerDomain)
+}
+
+func forwarderQueueList(cmd *cobra.Command, args []string) error {
+	files, err := readForwarderQueueFiles()
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		fmt.Fprintln(color.Output, "No transaction stored on disk")
+		return nil
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		fmt.Fprintf(color.Output, "%s\n", color.BlueString(file.Path))
+		fmt.Fprintf(color.Output, "  Domain: %s\n", file.Domain)
+		fmt.Fprintf(color.Output, "  Size: %d bytes, modified %s\n", file.Size, file.ModTime.Format(time.RFC3339))
+		fmt.Fprintf(color.Output, "  Transactions: %d\n", len(file.Transactions))
+		if file.DeserializeErrors > 0 {
+			fmt.Fprintf(color.Output, "  %s\n", color.RedString("Transactions that cannot be decoded: %d", file.DeserializeErrors))
+		}
+		for _, t := range file.Transactions {
+			fmt.Fprintf(color.Output, "    - endpoint: %s, size: %d bytes, priority: %s, age: %s, errors: %d\n",
+				t.GetEndpointName(), t.GetPayloadSize(), priorityString(t.Priority), now.Sub(t.CreatedAt).Round(time.Second), t.ErrorCount)
+		}
+		fmt.Fprintln(color.Output)
+	}
+	return nil
+}
+
+func forwarderQueuePurge(cmd *cobra.Command, args []string) error {
+	olderThan := forwarderQueueArgs.olderThan
+	endpoint := forwarderQueueArgs.endpoint
+	if olderThan <= 0 && endpoint == "" {
+		return fmt.Errorf("at least one of --older-than or --endpoint must be set")
+	}
+
+	files, err := readForwarderQueueFiles()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	shouldRemove := func(t *transaction.HTTPTransaction) bool {
+		if olderThan > 0 && now.Sub(t.CreatedAt) < olderThan {
+			return false
+		}
+		if endpoint != "" && t.GetEndpointName() != endpoint {
+			return false
+		}
+		return true
+	}
+
+	total := 0
+	for _, file := range files {
+		removed, err := forwarder.PurgeRetryQueueFile(file, shouldRemove)
+		if err != nil {
+			return fmt.Errorf("cannot purge %s: %v", file.Path, err)
+		}
+		if removed > 0 {
+			fmt.Fprintf(color.Output, "%s: %d transaction(s) removed\n", file.Path, removed)
+		}
+		total += removed
+	}
+	fmt.Fprintf(color.Output, "%d transaction(s) removed\n", total)
+	return nil
+}
+
+func forwarderQueueReplay(cmd *cobra.Command, args []string) error {
+	domain, err := url.Parse(forwarderQueueArgs.domain)
+	if err != nil || (domain.Scheme != "http" && domain.Scheme != "https") || domain.Host == "" {
+		return fmt.Errorf("invalid domain %q, it must be an http or https URL", forwarderQueueArgs.domain)
+	}
+
+	files, err := readForwarderQueueFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.Path != forwarderQueueArgs.file {
+			continue
+		}
+		sent, err := forwarder.ReplayRetryQueueFile(context.Background(), file, forwarderQueueArgs.domain, forwarderQueueArgs.keep)
+		fmt.Fprintf(color.Output, "%d/%d transaction(s) sent to %s\n", sent, len(file.Transactions), forwarderQueueArgs.domain)
+		return err
+	}
+	return fmt.Errorf("%s is not a retry file of a configured domain, run `forwarder-queue list` to list them", forwarderQueueArgs.file)
+}
+
+func priorityString(priority transaction.Priority) string {
+	if priority == transaction.TransactionPriorityHigh {
+		return "high"
+	}
+	return "normal"
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/journald"
+)
+
+var (
+	journaldCursorCmd = &cobra.Command{
+		Use:   "journald-cursor",
+		Short: "Print or reset the journal cursors stored by the logs agent",
+		Long: `Print the cursors of the journals tailed by the logs agent, as stored in its registry.
+
+With --reset, the cursors are removed from the registry and the journals are
+tailed from their end on the next start. Both apply to all the journals unless
+--path selects one of them. The agent must be stopped before resetting the
+cursors, as it overwrites the registry while running.`,
+		RunE: journaldCursor,
+	}
+
+	journaldCursorArgs = struct {
+		path  string
+		reset bool
+	}{}
+)
+
+func init() {
+	AgentCmd.AddCommand(journaldCursorCmd)
+	journaldCursorCmd.Flags().StringVar(&journaldCursorArgs.path, "path", "", "path of the journal as configured in the journald integration, empty for the default journal")
+	journaldCursorCmd.Flags().BoolVar(&journaldCursorArgs.reset, "reset", false, "remove the cursor from the registry")
+}
+
+func journaldCursor(cmd *cobra.Command, args []string) error {
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	err := common.SetupConfigWithoutSecrets(confFilePath, "")
+	if err != nil {
+		return fmt.Errorf("unable to set up global agent configuration: %v", err)
+	}
+
+	err = config.SetupLogger(loggerName, config.GetEnvDefault("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+	if err != nil {
+		fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+		return err
+	}
+
+	registryPath := filepath.Join(config.Datadog.GetString("logs_config.run_path"), auditor.DefaultRegistryFilename)
+	registry, err := auditor.ReadRegistry(registryPath)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(color.Output, "No registry found at %s\n", registryPath)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read the registry %s: %v", registryPath, err)
+	}
+
+	// all the journals are selected unless --path is set, an empty path selecting the default journal
+	var identifiers []string
+	for identifier := range registry {
+		if !strings.HasPrefix(identifier, journald.IdentifierPrefix) {
+			continue
+		}
+		if cmd.Flags().Changed("path") && identifier != journald.Identifier(journaldCursorArgs.path) {
+			continue
+		}
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	if len(identifiers) == 0 {
+		fmt.Fprintln(color.Output, "No journal cursor stored in the registry")
+		return nil
+	}
+
+	if !journaldCursorArgs.reset {
+		for _, identifier := range identifiers {
+			entry := registry[identifier]
+			fmt.Fprintf(color.Output, "%s\n", color.BlueString(strings.TrimPrefix(identifier, journald.IdentifierPrefix)))
+			fmt.Fprintf(color.Output, "  Cursor: %s\n", entry.Offset)
+			fmt.Fprintf(color.Output, "  Last updated: %s\n", entry.LastUpdated.Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	for _, identifier := range identifiers {
+		delete(registry, identifier)
+	}
+	if err := auditor.WriteRegistry(registryPath, registry); err != nil {
+		return fmt.Errorf("cannot write the registry %s: %v", registryPath, err)
+	}
+	for _, identifier := range identifiers {
+		fmt.Fprintf(color.Output, "Cursor of %s reset\n", strings.TrimPrefix(identifier, journald.IdentifierPrefix))
+	}
+	return nil
+}
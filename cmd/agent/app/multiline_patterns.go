@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
+)
+
+var (
+	multilinePatternsCmd = &cobra.Command{
+		Use:   "multiline-patterns",
+		Short: "Inspect and override the multiline patterns learned by the logs agent",
+		Long: `Inspect and override the multiline patterns learned per source by the automatic
+multiline detection of the logs agent.
+
+The changes apply to the tailers started afterwards, e.g. after a restart of the agent.`,
+	}
+
+	multilinePatternsListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the multiline patterns learned per source",
+		Long:  ``,
+		RunE:  multilinePatternsList,
+	}
+
+	multilinePatternsSetCmd = &cobra.Command{
+		Use:   "set",
+		Short: "Set the multiline pattern of a source",
+		Long:  `Set the regular expression matching the beginning of the logs of a source, or use the single line handler for the source when --pattern is empty.`,
+		RunE:  multilinePatternsSet,
+	}
+
+	multilinePatternsResetCmd = &cobra.Command{
+		Use:   "reset",
+		Short: "Remove the multiline patterns so they are learned again",
+		Long:  `Remove the multiline pattern of the --source source, or all the patterns when --source is not set.`,
+		RunE:  multilinePatternsReset,
+	}
+
+	multilinePatternsArgs = struct {
+		source  string
+		pattern string
+	}{}
+)
+
+func init() {
+	AgentCmd.AddCommand(multilinePatternsCmd)
+	multilinePatternsCmd.AddCommand(multilinePatternsListCmd)
+	multilinePatternsCmd.AddCommand(multilinePatternsSetCmd)
+	multilinePatternsCmd.AddCommand(multilinePatternsResetCmd)
+
+	multilinePatternsSetCmd.Flags().StringVar(&multilinePatternsArgs.source, "source", "", "name of the source, as printed by the list command")
+	multilinePatternsSetCmd.Flags().StringVar(&multilinePatternsArgs.pattern, "pattern", "", "regular expression matching the beginning of the logs, e.g. \\d{4}-\\d{2}-\\d{2}")
+	multilinePatternsSetCmd.MarkFlagRequired("source") //nolint:errcheck
+
+	multilinePatternsResetCmd.Flags().StringVar(&multilinePatternsArgs.source, "source", "", "name of the source, all the sources when empty")
+}
+
+// setupMultilinePatterns sets up the configuration locating the learned patterns
+func setupMultilinePatterns() error {
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	err := common.SetupConfigWithoutSecrets(confFilePath, "")
+	if err != nil {
+		return fmt.Errorf("unable to set up global agent configuration: %v", err)
+	}
+
+	err = config.SetupLogger(loggerName, config.GetEnvDefault("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+	if err != nil {
+		fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+func multilinePatternsList(cmd *cobra.Command, args []string) error {
+	if err := setupMultilinePatterns(); err != nil {
+		return err
+	}
+
+	patterns, err := decoder.ReadLearnedPatterns()
+	if err != nil {
+		return fmt.Errorf("cannot read the multiline patterns: %v", err)
+	}
+	if len(patterns) == 0 {
+		fmt.Fprintln(color.Output, "No multiline pattern learned")
+		return nil
+	}
+
+	sources := make([]string, 0, len(patterns))
+	for source := range patterns {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		pattern := patterns[source]
+		fmt.Fprintf(color.Output, "%s\n", color.BlueString(source))
+		if pattern.Pattern == "" {
+			fmt.Fprintln(color.Output, "  Pattern: none, single line logs")
+		} else {
+			fmt.Fprintf(color.Output, "  Pattern: %s\n", pattern.Pattern)
+		}
+		if pattern.Override {
+			fmt.Fprintln(color.Output, "  Set manually")
+		} else {
+			fmt.Fprintf(color.Output, "  Confidence: %.2f over %d lines\n", pattern.Confidence, pattern.LinesTested)
+		}
+		fmt.Fprintf(color.Output, "  Last updated: %s\n", pattern.LastUpdated.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func multilinePatternsSet(cmd *cobra.Command, args []string) error {
+	if err := setupMultilinePatterns(); err != nil {
+		return err
+	}
+
+	// the patterns only match the beginning of the logs, as the extra patterns of the detection
+	pattern := multilinePatternsArgs.pattern
+	if pattern != "" && !strings.HasPrefix(pattern, "^") {
+		pattern = "^" + pattern
+	}
+	err := decoder.WriteLearnedPattern(multilinePatternsArgs.source, decoder.LearnedPattern{
+		Pattern:     pattern,
+		LastUpdated: time.Now().UTC(),
+		Override:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot set the multiline pattern of %s: %v", multilinePatternsArgs.source, err)
+	}
+	fmt.Fprintf(color.Output, "Multiline pattern of %s set\n", multilinePatternsArgs.source)
+	return nil
+}
+
+func multilinePatternsReset(cmd *cobra.Command, args []string) error {
+	if err := setupMultilinePatterns(); err != nil {
+		return err
+	}
+
+	var sources []string
+	if multilinePatternsArgs.source != "" {
+		sources = append(sources, multilinePatternsArgs.source)
+	}
+	if err := decoder.RemoveLearnedPatterns(sources...); err != nil {
+		return fmt.Errorf("cannot reset the multiline patterns: %v", err)
+	}
+	fmt.Fprintln(color.Output, "Multiline patterns reset")
+	return nil
+}
@@ -21,20 +21,32 @@ import (
 )
 
 var (
-	filters diagnostic.Filters
+	streamOptions diagnostic.StreamOptions
+	streamStages  []string
 )
 
 func init() {
 	AgentCmd.AddCommand(troubleshootLogsCmd)
-	troubleshootLogsCmd.Flags().StringVar(&filters.Name, "name", "", "Filter by name")
-	troubleshootLogsCmd.Flags().StringVar(&filters.Type, "type", "", "Filter by type")
-	troubleshootLogsCmd.Flags().StringVar(&filters.Source, "source", "", "Filter by source")
+	troubleshootLogsCmd.Flags().StringVar(&streamOptions.Name, "name", "", "Filter by name")
+	troubleshootLogsCmd.Flags().StringVar(&streamOptions.Type, "type", "", "Filter by type")
+	troubleshootLogsCmd.Flags().StringVar(&streamOptions.Source, "source", "", "Filter by source")
+	troubleshootLogsCmd.Flags().StringVar(&streamOptions.Service, "service", "", "Filter by service")
+	troubleshootLogsCmd.Flags().StringVar(&streamOptions.Status, "status", "", "Filter by status, e.g. error")
+	troubleshootLogsCmd.Flags().StringVar(&streamOptions.Content, "content", "", "Filter by a regular expression matching the content")
+	troubleshootLogsCmd.Flags().StringSliceVar(&streamStages, "stages", nil, "Stages of the processing to stream the logs at: received (before the processing rules), processed, dropped (by a processing rule); processed by default")
+	troubleshootLogsCmd.Flags().BoolVar(&streamOptions.JSON, "json", false, "Print the logs as JSON objects")
+	troubleshootLogsCmd.Flags().IntVar(&streamOptions.Count, "count", 0, "Stop after streaming this number of logs, no limit when 0")
+	troubleshootLogsCmd.Flags().DurationVar(&streamOptions.Duration, "duration", 0, "Stop after streaming the logs for this duration, e.g. 30s, no limit when 0")
 }
 
 var troubleshootLogsCmd = &cobra.Command{
 	Use:   "stream-logs",
 	Short: "Stream the logs being processed by a running agent",
-	Long:  ``,
+	Long: `Stream the logs being processed by a running agent, matching the filters.
+
+The logs can be streamed before the processing rules are applied, after they are
+applied, or when a processing rule drops them, to debug why a log is dropped or
+masked. The logs streamed before the processing rules are not redacted.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		if flagNoColor {
@@ -62,7 +74,14 @@ func connectAndStream() error {
 		return err
 	}
 
-	body, err := json.Marshal(&filters)
+	for _, stage := range streamStages {
+		streamOptions.Stages = append(streamOptions.Stages, diagnostic.Stage(stage))
+	}
+	if err := streamOptions.Validate(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(&streamOptions)
 
 	if err != nil {
 		return err
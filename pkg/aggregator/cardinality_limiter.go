@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// cardinalityLimitModeDrop drops the samples of the contexts above the limit
+	cardinalityLimitModeDrop = "drop"
+	// cardinalityLimitModeCollapse aggregates the samples of the contexts above
+	// the limit into a single overflow context per metric name
+	cardinalityLimitModeCollapse = "collapse"
+
+	// cardinalityLimitedTag is the only tag of the overflow contexts
+	cardinalityLimitedTag = "cardinality_limited:true"
+)
+
+// cardinalityLimiter caps the number of distinct contexts a metric name can
+// have during a flush window.
+type cardinalityLimiter struct {
+	limit    int
+	collapse bool
+
+	// contextsByName holds the contexts accepted during the current window
+	contextsByName map[string]map[ckey.ContextKey]struct{}
+	// limitedByName counts the samples refused during the current window
+	limitedByName map[string]uint64
+}
+
+// newCardinalityLimiter returns a cardinalityLimiter, or nil if limit is not
+// strictly positive.
+func newCardinalityLimiter(limit int, mode string) *cardinalityLimiter {
+	if limit <= 0 {
+		return nil
+	}
+
+	collapse := false
+	switch mode {
+	case cardinalityLimitModeDrop:
+	case cardinalityLimitModeCollapse:
+		collapse = true
+	default:
+		log.Warnf("Unknown dogstatsd_cardinality_limit_mode %q, falling back to %q", mode, cardinalityLimitModeDrop)
+	}
+
+	return &cardinalityLimiter{
+		limit:          limit,
+		collapse:       collapse,
+		contextsByName: make(map[string]map[ckey.ContextKey]struct{}),
+		limitedByName:  make(map[string]uint64),
+	}
+}
+
+func newCardinalityLimiterFromConfig() *cardinalityLimiter {
+	return newCardinalityLimiter(
+		config.Datadog.GetInt("dogstatsd_cardinality_limit"),
+		config.Datadog.GetString("dogstatsd_cardinality_limit_mode"),
+	)
+}
+
+// allow returns whether the context can be tracked for the metric name during
+// the current window. Refused samples are counted per metric name.
+func (l *cardinalityLimiter) allow(name string, key ckey.ContextKey) bool {
+	contexts, ok := l.contextsByName[name]
+	if !ok {
+		contexts = make(map[ckey.ContextKey]struct{})
+		l.contextsByName[name] = contexts
+	}
+
+	if _, ok := contexts[key]; ok {
+		return true
+	}
+	if len(contexts) < l.limit {
+		contexts[key] = struct{}{}
+		return true
+	}
+
+	l.limitedByName[name]++
+	return false
+}
+
+// reset starts a new window and returns the number of samples refused per
+// metric name during the previous one.
+func (l *cardinalityLimiter) reset() map[string]uint64 {
+	limited := l.limitedByName
+	l.contextsByName = make(map[string]map[ckey.ContextKey]struct{})
+	l.limitedByName = make(map[string]uint64)
+	return limited
+}
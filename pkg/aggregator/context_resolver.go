@@ -44,9 +44,27 @@ func newContextResolver() *contextResolver {
 
 // trackContext returns the contextKey associated with the context of the metricSample and tracks that context
 func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
+	contextKey, _ := cr.trackContextWithLimiter(metricSampleContext, nil)
+	return contextKey
+}
+
+// trackContextWithLimiter behaves like trackContext, but new contexts have
+// to be accepted by the limiter first. When the limiter refuses a context,
+// the sample is either dropped, in which case false is returned and nothing
+// is tracked, or attributed to the overflow context of its metric name.
+func (cr *contextResolver) trackContextWithLimiter(metricSampleContext metrics.MetricSampleContext, limiter *cardinalityLimiter) (ckey.ContextKey, bool) {
 	metricSampleContext.GetTags(cr.tagsBuffer)               // tags here are not sorted and can contain duplicates
 	contextKey := cr.generateContextKey(metricSampleContext) // the generator will remove duplicates from cr.tagsBuffer (and doesn't mind the order)
 
+	if limiter != nil && !limiter.allow(metricSampleContext.GetName(), contextKey) {
+		cr.tagsBuffer.Reset()
+		if !limiter.collapse {
+			return contextKey, false
+		}
+		cr.tagsBuffer.Append(cardinalityLimitedTag)
+		contextKey = cr.generateContextKey(metricSampleContext)
+	}
+
 	if _, ok := cr.contextsByKey[contextKey]; !ok {
 		// making a copy of tags for the context since tagsBuffer
 		// will be reused later. This allow us to allocate one slice
@@ -59,7 +77,7 @@ func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSample
 	}
 
 	cr.tagsBuffer.Reset()
-	return contextKey
+	return contextKey, true
 }
 
 func (cr *contextResolver) get(key ckey.ContextKey) (*Context, bool) {
@@ -108,6 +126,16 @@ func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.Met
 	return contextKey
 }
 
+// trackContextWithLimiter returns the contextKey associated with the context of the metricSample and tracks that context
+// if the limiter accepts it. It returns false if the sample must be dropped.
+func (cr *timestampContextResolver) trackContextWithLimiter(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64, limiter *cardinalityLimiter) (ckey.ContextKey, bool) {
+	contextKey, ok := cr.resolver.trackContextWithLimiter(metricSampleContext, limiter)
+	if ok {
+		cr.lastSeenByKey[contextKey] = currentTimestamp
+	}
+	return contextKey, ok
+}
+
 func (cr *timestampContextResolver) length() int {
 	return cr.resolver.length()
 }
@@ -2,6 +2,7 @@ package aggregator
 
 import (
 	"fmt"
+	"sync"
 	"sync/atomic"
 
 	"github.com/DataDog/datadog-agent/pkg/metrics"
@@ -32,6 +33,16 @@ type tagsetTelemetry struct {
 	// tlmHugeSketches is an array containing counters with the same values as
 	// hugeSketchesCount.
 	tlmHugeSketches []telemetry.Counter
+
+	// cardinalityLimitedCount contains the total count of samples refused by
+	// the cardinality limiter, by metric name. Access must hold
+	// cardinalityLimitedMu.
+	cardinalityLimitedCount map[string]uint64
+	cardinalityLimitedMu    sync.Mutex
+
+	// tlmCardinalityLimited is a counter with the same values as
+	// cardinalityLimitedCount.
+	tlmCardinalityLimited telemetry.Counter
 }
 
 func newTagsetTelemetry(thresholds []uint64) *tagsetTelemetry {
@@ -43,6 +54,10 @@ func newTagsetTelemetry(thresholds []uint64) *tagsetTelemetry {
 		tlmHugeSeries:     make([]telemetry.Counter, size, size),
 		hugeSketchesCount: make([]uint64, size, size),
 		tlmHugeSketches:   make([]telemetry.Counter, size, size),
+
+		cardinalityLimitedCount: make(map[string]uint64),
+		tlmCardinalityLimited: telemetry.NewCounter("aggregator", "cardinality_limited_samples",
+			[]string{"metric_name"}, "Count of dogstatsd samples above the per-metric context limit, by metric name"),
 	}
 
 	for i, thresh := range t.sizeThresholds {
@@ -95,10 +110,26 @@ func (t *tagsetTelemetry) updateHugeSeriesTelemetry(series *metrics.Series) {
 	t.updateTelemetry(tagsetSizes, t.hugeSeriesCount, t.tlmHugeSeries)
 }
 
+// updateCardinalityLimitedTelemetry counts the samples refused by the
+// cardinality limiter, by metric name
+func (t *tagsetTelemetry) updateCardinalityLimitedTelemetry(limitedByName map[string]uint64) {
+	if len(limitedByName) == 0 {
+		return
+	}
+
+	t.cardinalityLimitedMu.Lock()
+	defer t.cardinalityLimitedMu.Unlock()
+	for name, count := range limitedByName {
+		t.cardinalityLimitedCount[name] += count
+		t.tlmCardinalityLimited.Add(float64(count), name)
+	}
+}
+
 func (t *tagsetTelemetry) exp() interface{} {
 	rv := map[string]map[string]uint64{
-		"Series":   {},
-		"Sketches": {},
+		"Series":             {},
+		"Sketches":           {},
+		"CardinalityLimited": {},
 	}
 
 	for i, thresh := range t.sizeThresholds {
@@ -108,5 +139,11 @@ func (t *tagsetTelemetry) exp() interface{} {
 		rv["Sketches"][fmt.Sprintf("Above%d", thresh)] = distributionCount
 	}
 
+	t.cardinalityLimitedMu.Lock()
+	for name, count := range t.cardinalityLimitedCount {
+		rv["CardinalityLimited"][name] = count
+	}
+	t.cardinalityLimitedMu.Unlock()
+
 	return rv
 }
@@ -10,4 +10,8 @@ func (t *tagsetTelemetry) reset() {
 		atomic.StoreUint64(&t.hugeSeriesCount[i], uint64(0))
 		atomic.StoreUint64(&t.hugeSketchesCount[i], uint64(0))
 	}
+
+	t.cardinalityLimitedMu.Lock()
+	t.cardinalityLimitedCount = make(map[string]uint64)
+	t.cardinalityLimitedMu.Unlock()
 }
@@ -28,6 +28,8 @@ type TimeSampler struct {
 	counterLastSampledByContext map[ckey.ContextKey]float64
 	lastCutOffTime              int64
 	sketchMap                   sketchMap
+	// cardinalityLimiter is nil when the number of contexts per metric name is not limited
+	cardinalityLimiter *cardinalityLimiter
 }
 
 // NewTimeSampler returns a newly initialized TimeSampler
@@ -41,6 +43,7 @@ func NewTimeSampler(interval int64) *TimeSampler {
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
+		cardinalityLimiter:          newCardinalityLimiterFromConfig(),
 	}
 }
 
@@ -55,7 +58,10 @@ func (s *TimeSampler) isBucketStillOpen(bucketStartTimestamp, timestamp int64) b
 // Add the metricSample to the correct bucket
 func (s *TimeSampler) addSample(metricSample *metrics.MetricSample, timestamp float64) {
 	// Keep track of the context
-	contextKey := s.contextResolver.trackContext(metricSample, timestamp)
+	contextKey, ok := s.contextResolver.trackContextWithLimiter(metricSample, timestamp, s.cardinalityLimiter)
+	if !ok {
+		return
+	}
 	bucketStart := s.calculateBucketStart(timestamp)
 
 	switch metricSample.Mtype {
@@ -182,6 +188,11 @@ func (s *TimeSampler) flush(timestamp float64) (metrics.Series, metrics.SketchSe
 	series := s.flushSeries(cutoffTime)
 	sketches := s.flushSketches(cutoffTime)
 
+	// a new flush window starts for the cardinality limiter
+	if s.cardinalityLimiter != nil {
+		tagsetTlm.updateCardinalityLimitedTelemetry(s.cardinalityLimiter.reset())
+	}
+
 	// expiring contexts
 	s.contextResolver.expireContexts(timestamp - config.Datadog.GetFloat64("dogstatsd_context_expiry_seconds"))
 	s.lastCutOffTime = cutoffTime
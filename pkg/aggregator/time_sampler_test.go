@@ -479,6 +479,101 @@ func TestBucketSamplingWithSketchAndSeries(t *testing.T) {
 	}, sketches[0])
 }
 
+func TestCardinalityLimitDrop(t *testing.T) {
+	sampler := NewTimeSampler(10)
+	sampler.cardinalityLimiter = newCardinalityLimiter(2, cardinalityLimitModeDrop)
+	tagsetTlm.reset()
+
+	for _, tag := range []string{"request:1", "request:2", "request:3", "request:4", "request:1"} {
+		sampler.addSample(&metrics.MetricSample{
+			Name:       "my.metric.name",
+			Value:      1,
+			Mtype:      metrics.CounterType,
+			Tags:       []string{tag},
+			SampleRate: 1,
+		}, 12345.0)
+	}
+	// other metric names have their own limit
+	sampler.addSample(&metrics.MetricSample{
+		Name:       "my.other.metric.name",
+		Value:      1,
+		Mtype:      metrics.GaugeType,
+		Tags:       []string{"request:3"},
+		SampleRate: 1,
+	}, 12345.0)
+
+	series, _ := sampler.flush(12360.0)
+
+	require.Len(t, series, 3)
+	values := map[string]float64{}
+	for _, serie := range series {
+		values[serie.Name+"|"+serie.Tags[0]] = serie.Points[0].Value
+	}
+	assert.Equal(t, map[string]float64{
+		"my.metric.name|request:1":       0.2,
+		"my.metric.name|request:2":       0.1,
+		"my.other.metric.name|request:3": 1,
+	}, values)
+	assert.Equal(t, 3, sampler.contextResolver.length())
+	assert.Equal(t, uint64(2), tagsetTlm.exp().(map[string]map[string]uint64)["CardinalityLimited"]["my.metric.name"])
+
+	// a new flush window accepts new contexts
+	sampler.addSample(&metrics.MetricSample{
+		Name:       "my.metric.name",
+		Value:      1,
+		Mtype:      metrics.GaugeType,
+		Tags:       []string{"request:3"},
+		SampleRate: 1,
+	}, 12365.0)
+	series, _ = sampler.flush(12380.0)
+	found := false
+	for _, serie := range series {
+		if serie.Name == "my.metric.name" && serie.Tags[0] == "request:3" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestCardinalityLimitCollapse(t *testing.T) {
+	sampler := NewTimeSampler(10)
+	sampler.cardinalityLimiter = newCardinalityLimiter(1, cardinalityLimitModeCollapse)
+
+	for _, tag := range []string{"request:1", "request:2", "request:3"} {
+		sampler.addSample(&metrics.MetricSample{
+			Name:       "my.metric.name",
+			Value:      1,
+			Mtype:      metrics.CounterType,
+			Tags:       []string{tag},
+			Host:       "my-host",
+			SampleRate: 1,
+		}, 12345.0)
+	}
+
+	series, _ := sampler.flush(12360.0)
+
+	expectedSerie1 := &metrics.Serie{
+		Name:     "my.metric.name",
+		Points:   []metrics.Point{{Ts: 12340.0, Value: 0.1}},
+		Tags:     []string{"request:1"},
+		Host:     "my-host",
+		MType:    metrics.APIRateType,
+		Interval: 10,
+	}
+	expectedSerie1.ContextKey = generateSerieContextKey(expectedSerie1)
+	expectedSerie2 := &metrics.Serie{
+		Name:     "my.metric.name",
+		Points:   []metrics.Point{{Ts: 12340.0, Value: 0.2}},
+		Tags:     []string{cardinalityLimitedTag},
+		Host:     "my-host",
+		MType:    metrics.APIRateType,
+		Interval: 10,
+	}
+	expectedSerie2.ContextKey = generateSerieContextKey(expectedSerie2)
+
+	metrics.AssertSeriesEqual(t, metrics.Series{expectedSerie1, expectedSerie2}, series)
+}
+
 func BenchmarkTimeSampler(b *testing.B) {
 	sampler := NewTimeSampler(10)
 	sample := metrics.MetricSample{
@@ -12,12 +12,22 @@ import (
 )
 
 func setupAPM(config Config) {
+	config.SetKnown("apm_config.obfuscation.sql.obfuscation_mode")
+	config.SetKnown("apm_config.obfuscation.sql.keep_comments")
+	config.SetKnown("apm_config.obfuscation.sql.collect_operations")
+	config.SetKnown("apm_config.obfuscation.sql.collect_procedures")
 	config.SetKnown("apm_config.obfuscation.elasticsearch.enabled")
 	config.SetKnown("apm_config.obfuscation.elasticsearch.keep_values")
 	config.SetKnown("apm_config.obfuscation.elasticsearch.obfuscate_sql_values")
 	config.SetKnown("apm_config.obfuscation.mongodb.enabled")
 	config.SetKnown("apm_config.obfuscation.mongodb.keep_values")
 	config.SetKnown("apm_config.obfuscation.mongodb.obfuscate_sql_values")
+	config.SetKnown("apm_config.obfuscation.opensearch.enabled")
+	config.SetKnown("apm_config.obfuscation.opensearch.keep_values")
+	config.SetKnown("apm_config.obfuscation.opensearch.obfuscate_sql_values")
+	config.SetKnown("apm_config.obfuscation.dynamodb.enabled")
+	config.SetKnown("apm_config.obfuscation.dynamodb.keep_values")
+	config.SetKnown("apm_config.obfuscation.dynamodb.obfuscate_sql_values")
 	config.SetKnown("apm_config.obfuscation.sql_exec_plan.enabled")
 	config.SetKnown("apm_config.obfuscation.sql_exec_plan.keep_values")
 	config.SetKnown("apm_config.obfuscation.sql_exec_plan.obfuscate_sql_values")
@@ -29,6 +39,7 @@ func setupAPM(config Config) {
 	config.SetKnown("apm_config.obfuscation.remove_stack_traces")
 	config.SetKnown("apm_config.obfuscation.redis.enabled")
 	config.SetKnown("apm_config.obfuscation.memcached.enabled")
+	config.SetKnown("apm_config.obfuscation.graphql.enabled")
 	config.SetKnown("apm_config.filter_tags.require")
 	config.SetKnown("apm_config.filter_tags.reject")
 	config.SetKnown("apm_config.extra_sample_rate")
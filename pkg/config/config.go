@@ -82,6 +82,15 @@ const (
 	// DefaultLogsSenderBackoffRecoveryInterval is the default logs sender backoff recovery interval
 	DefaultLogsSenderBackoffRecoveryInterval = 2
 
+	// DefaultLogsDiskQueueMaxDiskRatio is the default maximum ratio of the disk the logs disk queues can fill
+	DefaultLogsDiskQueueMaxDiskRatio = 0.80
+
+	// DefaultLogsLocalDestinationMaxSize is the default size in bytes at which the files of the local logs destinations are rotated
+	DefaultLogsLocalDestinationMaxSize = 100 * 1024 * 1024
+
+	// DefaultLogsLocalDestinationMaxFiles is the default number of rotated files kept by the local logs destinations
+	DefaultLogsLocalDestinationMaxFiles = 5
+
 	// DefaultInventoriesMinInterval is the default value for inventories_min_interval, in seconds
 	DefaultInventoriesMinInterval = 5 * 60
 
@@ -162,6 +171,17 @@ type MetricMapping struct {
 	Tags      map[string]string `mapstructure:"tags" json:"tags"`
 }
 
+// MetricRewriteRule represent one DogStatsD metric rewriting rule
+type MetricRewriteRule struct {
+	Match          string            `mapstructure:"match" json:"match"`
+	MatchType      string            `mapstructure:"match_type" json:"match_type"`
+	MatchTags      map[string]string `mapstructure:"match_tags" json:"match_tags"`
+	Drop           bool              `mapstructure:"drop" json:"drop"`
+	AllowTags      []string          `mapstructure:"allow_tags" json:"allow_tags"`
+	RenameTags     map[string]string `mapstructure:"rename_tags" json:"rename_tags"`
+	StripTagValues []string          `mapstructure:"strip_tag_values" json:"strip_tag_values"`
+}
+
 // Warnings represent the warnings in the config
 type Warnings struct {
 	TraceMallocEnabledWithPy2 bool
@@ -370,6 +390,7 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("proc_root", "/proc")
 	config.BindEnvAndSetDefault("histogram_aggregates", []string{"max", "median", "avg", "count"})
 	config.BindEnvAndSetDefault("histogram_percentiles", []string{"0.95"})
+	config.BindEnvAndSetDefault("histogram_use_sketch", false)
 	config.BindEnvAndSetDefault("aggregator_stop_timeout", 2)
 	config.BindEnvAndSetDefault("aggregator_buffer_size", 100)
 	config.BindEnvAndSetDefault("basic_telemetry_add_container_tags", false) // configure adding the agent container tags to the basic agent telemetry metrics (e.g. `datadog.agent.running`)
@@ -383,6 +404,10 @@ func InitConfig(config Config) {
 	// Warning: do not change the two following values. Your payloads will get dropped by Datadog's intake.
 	config.BindEnvAndSetDefault("serializer_max_payload_size", 2*megaByte+megaByte/2)
 	config.BindEnvAndSetDefault("serializer_max_uncompressed_payload_size", 4*megaByte)
+	// Compression of the JSON stream payloads: "zlib", "zstd" or "none", optionally per endpoint name (e.g. "series_v1")
+	config.BindEnvAndSetDefault("serializer_compressor_kind", "zlib")
+	config.BindEnvAndSetDefault("serializer_zstd_compressor_level", 1)
+	config.BindEnvAndSetDefault("serializer_compressor_kind_per_endpoint", map[string]string{})
 
 	config.BindEnvAndSetDefault("use_v2_api.series", false)
 	// Serializer: allow user to blacklist any kind of payload to be sent
@@ -407,6 +432,11 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_backoff_max", 64)
 	config.BindEnvAndSetDefault("forwarder_recovery_interval", DefaultForwarderRecoveryInterval)
 	config.BindEnvAndSetDefault("forwarder_recovery_reset", false)
+	// Forwarder circuit breaker: number of consecutive errors of each kind opening the circuit of an endpoint
+	config.BindEnvAndSetDefault("forwarder_circuit_breaker_network_error_threshold", 1)
+	config.BindEnvAndSetDefault("forwarder_circuit_breaker_4xx_threshold", 1)
+	config.BindEnvAndSetDefault("forwarder_circuit_breaker_5xx_threshold", 1)
+	config.BindEnvAndSetDefault("forwarder_circuit_breaker_half_open_probes", 1)
 
 	// Forwarder storage on disk
 	config.BindEnvAndSetDefault("forwarder_storage_path", "")
@@ -415,6 +445,19 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_storage_max_size_in_bytes", 0) // 0 means disabled. This is a BETA feature.
 	config.BindEnvAndSetDefault("forwarder_storage_max_disk_ratio", 0.80) // Do not store transactions on disk when the disk usage exceeds 80% of the disk capacity. Use 80% as some applications do not behave well when the disk space is very small.
 
+	// Forwarder payload tee: fraction of the payloads written decoded to local files, for debugging
+	config.BindEnvAndSetDefault("forwarder_payload_tee_sample_rate", 0.0) // 0 means disabled
+	config.BindEnvAndSetDefault("forwarder_payload_tee_path", "")
+	config.BindEnvAndSetDefault("forwarder_payload_tee_max_file_size", 10*1024*1024)
+	config.BindEnvAndSetDefault("forwarder_payload_tee_max_files", 5)
+
+	// Prometheus remote-write: additional destination of the series and sketches
+	config.BindEnvAndSetDefault("prometheus_remote_write.url", "") // empty means disabled
+	config.BindEnvAndSetDefault("prometheus_remote_write.headers", map[string]string{})
+	config.BindEnvAndSetDefault("prometheus_remote_write.max_samples_per_payload", 2000)
+	config.BindEnvAndSetDefault("prometheus_remote_write.priority", "normal")
+	config.BindEnvAndSetDefault("prometheus_remote_write.retry_queue_payloads_max_size", 15*1024*1024)
+
 	// Forwarder channels buffer size
 	config.BindEnvAndSetDefault("forwarder_high_prio_buffer_size", 100)
 	config.BindEnvAndSetDefault("forwarder_low_prio_buffer_size", 100)
@@ -439,7 +482,15 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_queue_size", 1024)
 
 	config.BindEnvAndSetDefault("dogstatsd_non_local_traffic", false)
-	config.BindEnvAndSetDefault("dogstatsd_socket", "") // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_socket", "")  // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_tcp_port", 0) // Notice: 0 means TCP listener disabled
+	// Options are: newline, length_prefixed
+	config.BindEnvAndSetDefault("dogstatsd_tcp_framing", "newline")
+	config.BindEnvAndSetDefault("dogstatsd_tcp_max_connections", 1024) // 0 means unlimited
+	config.BindEnvAndSetDefault("dogstatsd_tcp_idle_timeout", 5*time.Minute)
+	config.BindEnvAndSetDefault("dogstatsd_tcp_tls_cert_file", "")
+	config.BindEnvAndSetDefault("dogstatsd_tcp_tls_key_file", "")
+	config.BindEnvAndSetDefault("dogstatsd_tcp_tls_client_ca_file", "")
 	config.BindEnvAndSetDefault("dogstatsd_stats_port", 5000)
 	config.BindEnvAndSetDefault("dogstatsd_stats_enable", false)
 	config.BindEnvAndSetDefault("dogstatsd_stats_buffer", 10)
@@ -450,6 +501,10 @@ func InitConfig(config Config) {
 	// is 10s), otherwise we won't be able to sample unseen counter as
 	// contexts will be deleted (see 'dogstatsd_expiry_seconds').
 	config.BindEnvAndSetDefault("dogstatsd_context_expiry_seconds", 300)
+	// Maximum number of distinct contexts a metric name can have per flush,
+	// 0 means no limit. Options for the mode are: drop, collapse
+	config.BindEnvAndSetDefault("dogstatsd_cardinality_limit", 0)
+	config.BindEnvAndSetDefault("dogstatsd_cardinality_limit_mode", "drop")
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection", false) // Only supported for socket traffic
 	config.BindEnvAndSetDefault("dogstatsd_so_rcvbuf", 0)
 	config.BindEnvAndSetDefault("dogstatsd_metrics_stats_enable", false)
@@ -475,6 +530,15 @@ func InitConfig(config Config) {
 		return mappings
 	})
 
+	config.BindEnv("dogstatsd_mapper_rules")
+	config.SetEnvKeyTransformer("dogstatsd_mapper_rules", func(in string) interface{} {
+		var rules []MetricRewriteRule
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"dogstatsd_mapper_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
+
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
 	config.BindEnvAndSetDefault("statsd_metric_namespace", "")
@@ -760,6 +824,8 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("logs_config.auto_multi_line_default_sample_size", 500)
 	config.BindEnvAndSetDefault("logs_config.auto_multi_line_default_match_timeout", 30) // Seconds
 	config.BindEnvAndSetDefault("logs_config.auto_multi_line_default_match_threshold", 0.48)
+	// Store the outcome of the detection per source in the run path so it is reused after a restart
+	config.BindEnvAndSetDefault("logs_config.auto_multi_line_persist_learned_patterns", true)
 
 	// If true, the agent looks for container logs in the location used by podman, rather
 	// than docker.  This is a temporary configuration parameter to support podman logs until
@@ -775,6 +841,18 @@ func InitConfig(config Config) {
 	// Time in seconds
 	config.BindEnvAndSetDefault("logs_config.file_scan_period", 10.0)
 
+	// Logs storage on disk, used by the senders to spill the payloads they cannot send during an outage
+	config.BindEnvAndSetDefault("logs_config.disk_queue_path", "")                                         // defaults to `<logs_config.run_path>/disk_queue`
+	config.BindEnvAndSetDefault("logs_config.disk_queue_max_size_in_bytes", 0)                             // 0 means disabled
+	config.BindEnvAndSetDefault("logs_config.disk_queue_max_disk_ratio", DefaultLogsDiskQueueMaxDiskRatio) // same semantics as `forwarder_storage_max_disk_ratio`
+	config.BindEnvAndSetDefault("logs_config.disk_queue_max_age", 24*time.Hour)                            // duration-formatted string (parsed by `time.ParseDuration`), 0 means no limit
+
+	// Local destination, writing the logs to a file or to stdout instead of sending them to Datadog
+	config.BindEnvAndSetDefault("logs_config.local_destination", "")                                            // "file" or "stdout", empty means disabled
+	config.BindEnvAndSetDefault("logs_config.local_destination_path", "")                                       // defaults to `<logs_config.run_path>/logs.json`
+	config.BindEnvAndSetDefault("logs_config.local_destination_max_size", DefaultLogsLocalDestinationMaxSize)   // in bytes, 0 means no rotation
+	config.BindEnvAndSetDefault("logs_config.local_destination_max_files", DefaultLogsLocalDestinationMaxFiles) // number of rotated files kept
+
 	// The cardinality of tags to send for checks and dogstatsd respectively.
 	// Choices are: low, orchestrator, high.
 	// WARNING: sending orchestrator, or high tags for dogstatsd metrics may create more metrics
@@ -1491,6 +1569,22 @@ func getDogstatsdMappingProfilesConfig(config Config) ([]MappingProfile, error)
 	return mappings, nil
 }
 
+// GetDogstatsdMapperRules returns the metric rewriting rules used in DogStatsD mapper
+func GetDogstatsdMapperRules() ([]MetricRewriteRule, error) {
+	return getDogstatsdMapperRulesConfig(Datadog)
+}
+
+func getDogstatsdMapperRulesConfig(config Config) ([]MetricRewriteRule, error) {
+	var rules []MetricRewriteRule
+	if config.IsSet("dogstatsd_mapper_rules") {
+		err := config.UnmarshalKey("dogstatsd_mapper_rules", &rules)
+		if err != nil {
+			return []MetricRewriteRule{}, log.Errorf("Could not parse dogstatsd_mapper_rules: %v", err)
+		}
+	}
+	return rules, nil
+}
+
 // IsCLCRunner returns whether the Agent is in cluster check runner mode
 func IsCLCRunner() bool {
 	if !Datadog.GetBool("clc_runner_enabled") {
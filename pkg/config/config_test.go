@@ -991,6 +991,56 @@ func TestDogstatsdMappingProfilesEnv(t *testing.T) {
 	assert.Equal(t, mappings, expected)
 }
 
+func TestDogstatsdMapperRulesOk(t *testing.T) {
+	datadogYaml := `
+dogstatsd_mapper_rules:
+  - match: "app.requests.*"
+    match_tags:
+      env: "staging|dev"
+    drop: true
+  - match: 'app\.latency\..*'
+    match_type: "regex"
+    allow_tags: ["env", "service", "endpoint"]
+    rename_tags:
+      endpoint: "resource"
+    strip_tag_values: ["service"]
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	rules, err := getDogstatsdMapperRulesConfig(testConfig)
+
+	expectedRules := []MetricRewriteRule{
+		{
+			Match:     "app.requests.*",
+			MatchTags: map[string]string{"env": "staging|dev"},
+			Drop:      true,
+		},
+		{
+			Match:          "app\\.latency\\..*",
+			MatchType:      "regex",
+			AllowTags:      []string{"env", "service", "endpoint"},
+			RenameTags:     map[string]string{"endpoint": "resource"},
+			StripTagValues: []string{"service"},
+		},
+	}
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedRules, rules)
+}
+
+func TestDogstatsdMapperRulesEnv(t *testing.T) {
+	env := "DD_DOGSTATSD_MAPPER_RULES"
+	err := os.Setenv(env, `[{"match":"app.*","match_tags":{"env":"dev"},"drop":true},{"rename_tags":{"a":"b"}}]`)
+	assert.Nil(t, err)
+	defer os.Unsetenv(env)
+	expected := []MetricRewriteRule{
+		{Match: "app.*", MatchTags: map[string]string{"env": "dev"}, Drop: true},
+		{RenameTags: map[string]string{"a": "b"}},
+	}
+	rules, _ := GetDogstatsdMapperRules()
+	assert.Equal(t, expected, rules)
+}
+
 func TestPrometheusScrapeChecksEnv(t *testing.T) {
 	env := "DD_PROMETHEUS_SCRAPE_CHECKS"
 	err := os.Setenv(env, `[{"configurations":[{"timeout":5,"send_distribution_buckets":true}],"autodiscovery":{"kubernetes_container_names":["my-app"],"kubernetes_annotations":{"include":{"custom_label":"true"}}}}]`)
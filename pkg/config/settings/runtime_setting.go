@@ -115,3 +115,24 @@ func GetInt(v interface{}) (int, error) {
 		return 0, fmt.Errorf("GetInt: bad parameter value provided: %v", v)
 	}
 }
+
+// GetFloat returns the float value contained in value.
+// If value is a float or an integer, returns its value
+// If value is a string, it parses the string into a float.
+// Else, returns an error.
+func GetFloat(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("GetFloat: %s", err)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("GetFloat: bad parameter value provided: %v", v)
+	}
+}
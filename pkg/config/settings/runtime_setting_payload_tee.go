@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package settings
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// PayloadTeeSampleRateRuntimeSetting wraps operations to change the fraction
+// of the forwarder payloads written to local files at runtime.
+type PayloadTeeSampleRateRuntimeSetting (string)
+
+// Description returns the runtime setting's description
+func (p PayloadTeeSampleRateRuntimeSetting) Description() string {
+	return "Set the fraction (between 0 and 1) of the forwarder payloads written to local files, 0 disables it."
+}
+
+// Hidden returns whether or not this setting is hidden from the list of runtime settings
+func (p PayloadTeeSampleRateRuntimeSetting) Hidden() bool {
+	return false
+}
+
+// Name returns the name of the runtime setting
+func (p PayloadTeeSampleRateRuntimeSetting) Name() string {
+	return string(p)
+}
+
+// Get returns the current value of the runtime setting
+func (p PayloadTeeSampleRateRuntimeSetting) Get() (interface{}, error) {
+	return config.Datadog.GetFloat64("forwarder_payload_tee_sample_rate"), nil
+}
+
+// Set changes the value of the runtime setting
+func (p PayloadTeeSampleRateRuntimeSetting) Set(v interface{}) error {
+	rate, err := GetFloat(v)
+	if err != nil {
+		return fmt.Errorf("PayloadTeeSampleRateRuntimeSetting: %v", err)
+	}
+	if rate < 0 || rate > 1 {
+		return fmt.Errorf("PayloadTeeSampleRateRuntimeSetting: the sample rate must be between 0 and 1, got %v", rate)
+	}
+
+	config.Datadog.Set("forwarder_payload_tee_sample_rate", rate)
+	return nil
+}
@@ -151,3 +151,53 @@ func TestGetInt(t *testing.T) {
 		}
 	}
 }
+
+func TestGetFloat(t *testing.T) {
+	cases := []struct {
+		v   interface{}
+		exp float64
+		err bool
+	}{
+		{0, 0, false},
+		{1, 1, false},
+		{0.25, 0.25, false},
+		{"0.5", 0.5, false},
+		{"1", 1, false},
+		{"aaa", 0, true},
+		{true, 0, true},
+	}
+
+	for _, c := range cases {
+		v, err := GetFloat(c.v)
+		if c.err {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+			assert.Equal(t, c.exp, v)
+		}
+	}
+}
+
+func TestPayloadTeeSampleRate(t *testing.T) {
+	cleanRuntimeSetting()
+	setupConf()
+
+	s := PayloadTeeSampleRateRuntimeSetting("forwarder_payload_tee_sample_rate")
+	assert.Equal(t, "forwarder_payload_tee_sample_rate", s.Name())
+
+	v, err := s.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, v)
+
+	err = s.Set("0.1")
+	assert.Nil(t, err)
+	v, err = s.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, 0.1, v)
+
+	assert.NotNil(t, s.Set("2"))
+	assert.NotNil(t, s.Set("-0.5"))
+	v, err = s.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, 0.1, v)
+}
@@ -42,7 +42,7 @@ func runParseMetricBenchmark(b *testing.B, multipleValues bool) {
 					continue
 				}
 
-				benchSamples = enrichMetricSample(samples, parsed, "", namespaceBlacklist, metricBlocklist, "default-hostname", "", true, false)
+				benchSamples = enrichMetricSample(samples, parsed, "", namespaceBlacklist, metricBlocklist, nil, "default-hostname", "", true, false)
 			}
 		})
 	}
@@ -3,6 +3,7 @@ package dogstatsd
 import (
 	"strings"
 
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/mapper"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 )
@@ -94,7 +95,7 @@ func isMetricBlocklisted(metricName string, metricBlocklist []string) bool {
 }
 
 func enrichMetricSample(metricSamples []metrics.MetricSample, ddSample dogstatsdMetricSample, namespace string, excludedNamespaces []string,
-	metricBlocklist []string, defaultHostname string, origin string, entityIDPrecedenceEnabled bool, serverlessMode bool) []metrics.MetricSample {
+	metricBlocklist []string, rewriter *mapper.MetricRewriter, defaultHostname string, origin string, entityIDPrecedenceEnabled bool, serverlessMode bool) []metrics.MetricSample {
 	metricName := ddSample.name
 	tags, hostnameFromTags, originID, k8sOriginID, cardinality := extractTagsMetadata(ddSample.tags, defaultHostname, origin, entityIDPrecedenceEnabled)
 
@@ -106,6 +107,13 @@ func enrichMetricSample(metricSamples []metrics.MetricSample, ddSample dogstatsd
 		return []metrics.MetricSample{}
 	}
 
+	if rewriter != nil {
+		var keep bool
+		if tags, keep = rewriter.Rewrite(metricName, tags); !keep {
+			return metricSamples
+		}
+	}
+
 	if serverlessMode { // we don't want to set the host while running in serverless mode
 		hostnameFromTags = ""
 	}
@@ -7,6 +7,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/mapper"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 )
@@ -30,7 +32,7 @@ func parseAndEnrichSingleMetricMessage(message []byte, namespace string, namespa
 	}
 
 	samples := []metrics.MetricSample{}
-	samples = enrichMetricSample(samples, parsed, namespace, namespaceBlacklist, metricBlocklist, defaultHostname, "", true, false)
+	samples = enrichMetricSample(samples, parsed, namespace, namespaceBlacklist, metricBlocklist, nil, defaultHostname, "", true, false)
 	if len(samples) != 1 {
 		return metrics.MetricSample{}, fmt.Errorf("wrong number of metrics parsed")
 	}
@@ -45,7 +47,7 @@ func parseAndEnrichMultipleMetricMessage(message []byte, namespace string, names
 	}
 
 	samples := []metrics.MetricSample{}
-	return enrichMetricSample(samples, parsed, namespace, namespaceBlacklist, metricBlocklist, defaultHostname, "", true, false), nil
+	return enrichMetricSample(samples, parsed, namespace, namespaceBlacklist, metricBlocklist, nil, defaultHostname, "", true, false), nil
 }
 
 func parseAndEnrichServiceCheckMessage(message []byte, defaultHostname string) (*metrics.ServiceCheck, error) {
@@ -814,7 +816,7 @@ func TestMetricBlocklistShouldBlock(t *testing.T) {
 	parsed, err := parser.parseMetricSample(message)
 	assert.NoError(t, err)
 	samples := []metrics.MetricSample{}
-	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, "default", "", true, false)
+	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, nil, "default", "", true, false)
 
 	assert.Equal(t, 0, len(samples))
 }
@@ -826,7 +828,7 @@ func TestServerlessModeShouldSetEmptyHostname(t *testing.T) {
 	parsed, err := parser.parseMetricSample(message)
 	assert.NoError(t, err)
 	samples := []metrics.MetricSample{}
-	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, "default", "", true, true)
+	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, nil, "default", "", true, true)
 
 	assert.Equal(t, 1, len(samples))
 	assert.Equal(t, "", samples[0].Host)
@@ -842,11 +844,32 @@ func TestMetricBlocklistShouldNotBlock(t *testing.T) {
 	parsed, err := parser.parseMetricSample(message)
 	assert.NoError(t, err)
 	samples := []metrics.MetricSample{}
-	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, "default", "", true, false)
+	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, nil, "default", "", true, false)
 
 	assert.Equal(t, 1, len(samples))
 }
 
+func TestMetricRewriterRules(t *testing.T) {
+	rewriter, err := mapper.NewMetricRewriter([]config.MetricRewriteRule{
+		{Match: "custom.metric.*", MatchTags: map[string]string{"env": "dev"}, Drop: true},
+		{Match: "custom.metric.*", RenameTags: map[string]string{"endpoint": "resource"}},
+	}, 10)
+	require.NoError(t, err)
+	parser := newParser(newFloat64ListPool())
+
+	parsed, err := parser.parseMetricSample([]byte("custom.metric.a:21|ms|#env:dev,host:my-host"))
+	assert.NoError(t, err)
+	samples := enrichMetricSample([]metrics.MetricSample{}, parsed, "", nil, nil, rewriter, "default", "", true, false)
+	assert.Equal(t, 0, len(samples))
+
+	parsed, err = parser.parseMetricSample([]byte("custom.metric.a:21|ms|#env:prod,endpoint:/users,host:my-host"))
+	assert.NoError(t, err)
+	samples = enrichMetricSample([]metrics.MetricSample{}, parsed, "", nil, nil, rewriter, "default", "", true, false)
+	require.Equal(t, 1, len(samples))
+	assert.Equal(t, []string{"env:prod", "resource:/users"}, samples[0].Tags)
+	assert.Equal(t, "my-host", samples[0].Host)
+}
+
 func TestConvertEntityOriginDetectionNoTags(t *testing.T) {
 	parsed, err := parseAndEnrichSingleMetricMessage([]byte("daemon:666|g|#sometag1:somevalue1,host:my-hostname,dd.internal.entity_id:foo,sometag2:somevalue2"), "", nil, nil, "default-hostname")
 	assert.NoError(t, err)
@@ -0,0 +1,422 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"expvar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// TCPFramingNewline splits the TCP stream on '\n', like the other listeners.
+	TCPFramingNewline = "newline"
+	// TCPFramingLengthPrefixed expects every frame to be prefixed by its
+	// length as a 4 bytes little-endian unsigned integer.
+	TCPFramingLengthPrefixed = "length_prefixed"
+
+	tcpLengthPrefixSize = 4
+)
+
+var (
+	tcpExpvars             = expvar.NewMap("dogstatsd-tcp")
+	tcpPacketReadingErrors = expvar.Int{}
+	tcpPackets             = expvar.Int{}
+	tcpBytes               = expvar.Int{}
+	tcpConnections         = expvar.Int{}
+	tcpRejectedConnections = expvar.Int{}
+	tcpActiveConnections   = expvar.Int{}
+)
+
+func init() {
+	tcpExpvars.Set("PacketReadingErrors", &tcpPacketReadingErrors)
+	tcpExpvars.Set("Packets", &tcpPackets)
+	tcpExpvars.Set("Bytes", &tcpBytes)
+	tcpExpvars.Set("Connections", &tcpConnections)
+	tcpExpvars.Set("RejectedConnections", &tcpRejectedConnections)
+	tcpExpvars.Set("ActiveConnections", &tcpActiveConnections)
+}
+
+// TCPListener implements the StatsdListener interface for TCP protocol.
+// It listens to a given TCP address, optionally secured with TLS, and sends
+// back packets ready to be processed.
+// Origin detection is not implemented for TCP.
+type TCPListener struct {
+	listener        net.Listener
+	packetsBuffer   *packets.Buffer
+	packetAssembler *packets.Assembler
+	bufferSize      int
+	framing         string
+	maxConnections  int
+	idleTimeout     time.Duration
+	trafficCapture  *replay.TrafficCapture // Currently ignored
+
+	connsMutex sync.Mutex
+	conns      map[net.Conn]struct{}
+	stopping   bool
+	connsWg    sync.WaitGroup
+}
+
+// NewTCPListener returns an idle TCP Statsd listener
+func NewTCPListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager, capture *replay.TrafficCapture) (*TCPListener, error) {
+	var url string
+
+	if config.Datadog.GetBool("dogstatsd_non_local_traffic") == true {
+		// Listen to all network interfaces
+		url = fmt.Sprintf(":%d", config.Datadog.GetInt("dogstatsd_tcp_port"))
+	} else {
+		url = net.JoinHostPort(config.GetBindHost(), config.Datadog.GetString("dogstatsd_tcp_port"))
+	}
+
+	framing := config.Datadog.GetString("dogstatsd_tcp_framing")
+	if framing != TCPFramingNewline && framing != TCPFramingLengthPrefixed {
+		return nil, fmt.Errorf("dogstatsd-tcp: unknown framing %q, expected %q or %q", framing, TCPFramingNewline, TCPFramingLengthPrefixed)
+	}
+
+	tlsConfig, err := buildTCPTLSConfig(
+		config.Datadog.GetString("dogstatsd_tcp_tls_cert_file"),
+		config.Datadog.GetString("dogstatsd_tcp_tls_key_file"),
+		config.Datadog.GetString("dogstatsd_tcp_tls_client_ca_file"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("dogstatsd-tcp: %s", err)
+	}
+
+	var listener net.Listener
+	if tlsConfig != nil {
+		listener, err = tls.Listen("tcp", url, tlsConfig)
+	} else {
+		listener, err = net.Listen("tcp", url)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+
+	bufferSize := config.Datadog.GetInt("dogstatsd_buffer_size")
+	packetsBufferSize := config.Datadog.GetInt("dogstatsd_packet_buffer_size")
+	flushTimeout := config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout")
+
+	packetsBuffer := packets.NewBuffer(uint(packetsBufferSize), flushTimeout, packetOut)
+	packetAssembler := packets.NewAssembler(flushTimeout, packetsBuffer, sharedPacketPoolManager, packets.TCP)
+
+	l := &TCPListener{
+		listener:        listener,
+		packetsBuffer:   packetsBuffer,
+		packetAssembler: packetAssembler,
+		bufferSize:      bufferSize,
+		framing:         framing,
+		maxConnections:  config.Datadog.GetInt("dogstatsd_tcp_max_connections"),
+		idleTimeout:     config.Datadog.GetDuration("dogstatsd_tcp_idle_timeout"),
+		trafficCapture:  capture,
+		conns:           make(map[net.Conn]struct{}),
+	}
+	log.Debugf("dogstatsd-tcp: %s successfully initialized (framing: %s, tls: %t)", listener.Addr(), framing, tlsConfig != nil)
+	return l, nil
+}
+
+// buildTCPTLSConfig returns the TLS configuration of the listener, or nil
+// when no certificate is configured. When a client CA is given, clients are
+// required to present a certificate signed by it.
+func buildTCPTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, fmt.Errorf("a client CA is configured but no server certificate and key")
+		}
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both a TLS certificate and a key are required")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load TLS key pair: %s", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		pem, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read client CA file: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificate found in client CA file %s", clientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *TCPListener) Listen() {
+	log.Infof("dogstatsd-tcp: starting to listen on %s", l.listener.Addr())
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			// listener has been closed
+			if strings.HasSuffix(err.Error(), " use of closed network connection") {
+				return
+			}
+			log.Errorf("dogstatsd-tcp: error accepting connection: %v", err)
+			tlmTCPConnections.Inc("error")
+			continue
+		}
+
+		if !l.trackConnection(conn) {
+			conn.Close()
+			continue
+		}
+		go l.handleConnection(conn)
+	}
+}
+
+// trackConnection registers a new connection, it returns false if the
+// connection must be refused.
+func (l *TCPListener) trackConnection(conn net.Conn) bool {
+	l.connsMutex.Lock()
+	defer l.connsMutex.Unlock()
+
+	if l.stopping {
+		return false
+	}
+	if l.maxConnections > 0 && len(l.conns) >= l.maxConnections {
+		log.Debugf("dogstatsd-tcp: refusing connection from %s: limit of %d connections reached", conn.RemoteAddr(), l.maxConnections)
+		tcpRejectedConnections.Add(1)
+		tlmTCPConnections.Inc("rejected")
+		return false
+	}
+
+	l.conns[conn] = struct{}{}
+	l.connsWg.Add(1)
+	tcpConnections.Add(1)
+	tcpActiveConnections.Add(1)
+	tlmTCPConnections.Inc("accepted")
+	tlmTCPActiveConnections.Inc()
+	return true
+}
+
+func (l *TCPListener) untrackConnection(conn net.Conn) {
+	l.connsMutex.Lock()
+	delete(l.conns, conn)
+	l.connsMutex.Unlock()
+
+	conn.Close()
+	tcpActiveConnections.Add(-1)
+	tlmTCPActiveConnections.Dec()
+	l.connsWg.Done()
+}
+
+func (l *TCPListener) handleConnection(conn net.Conn) {
+	defer l.untrackConnection(conn)
+
+	log.Debugf("dogstatsd-tcp: new connection from %s", conn.RemoteAddr())
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if l.idleTimeout > 0 {
+			tlsConn.SetDeadline(time.Now().Add(l.idleTimeout)) //nolint:errcheck
+		}
+		if err := tlsConn.Handshake(); err != nil {
+			log.Debugf("dogstatsd-tcp: TLS handshake with %s failed: %v", conn.RemoteAddr(), err)
+			tlmTCPConnections.Inc("tls_error")
+			return
+		}
+		tlsConn.SetDeadline(time.Time{}) //nolint:errcheck
+	}
+
+	var read int
+	var err error
+	if l.framing == TCPFramingLengthPrefixed {
+		read, err = l.readLengthPrefixed(conn)
+	} else {
+		read, err = l.readNewlines(conn)
+	}
+	tlmTCPConnectionBytes.Observe(float64(read))
+
+	switch {
+	case err == nil, err == io.EOF:
+		log.Debugf("dogstatsd-tcp: client %s disconnected", conn.RemoteAddr())
+	case strings.HasSuffix(err.Error(), " use of closed network connection"):
+		// Stop() closed the connection
+	case isTimeout(err):
+		log.Debugf("dogstatsd-tcp: closing idle connection from %s", conn.RemoteAddr())
+		tlmTCPConnections.Inc("idle_timeout")
+	default:
+		log.Errorf("dogstatsd-tcp: error reading from %s: %v", conn.RemoteAddr(), err)
+		tcpPacketReadingErrors.Add(1)
+		tlmTCPPackets.Inc("error")
+	}
+}
+
+// readNewlines reads '\n' separated messages from the connection until an
+// error occurs. It returns the number of bytes read.
+func (l *TCPListener) readNewlines(conn net.Conn) (int, error) {
+	buffer := make([]byte, l.bufferSize)
+	startWriteIndex := 0
+	total := 0
+	// set when a message bigger than the buffer is being skipped
+	discarding := false
+	var t1, t2 time.Time
+	for {
+		l.refreshDeadline(conn)
+		bytesRead, err := conn.Read(buffer[startWriteIndex:])
+		t1 = time.Now()
+		total += bytesRead
+
+		if bytesRead > 0 {
+			endIndex := startWriteIndex + bytesRead
+
+			if discarding {
+				// skip the end of the oversized message, up to its '\n'
+				idx := bytes.IndexByte(buffer[:endIndex], '\n')
+				if idx < 0 {
+					endIndex = 0
+				} else {
+					discarding = false
+					endIndex = copy(buffer, buffer[idx+1:endIndex])
+				}
+			}
+
+			// When there is no '\n', the message is partial. LastIndexByte returns -1 and messageSize is 0.
+			// If there is a '\n', at least one message is completed and '\n' is part of this message.
+			messageSize := bytes.LastIndexByte(buffer[:endIndex], '\n') + 1
+			if messageSize > 1 {
+				l.onMessage(buffer[:messageSize-1], messageSize)
+			}
+
+			startWriteIndex = endIndex - messageSize
+
+			// If the message is bigger than the buffer size, drop it and continue reading next messages.
+			if startWriteIndex >= len(buffer) {
+				log.Debugf("dogstatsd-tcp: dropping message from %s bigger than %d bytes", conn.RemoteAddr(), len(buffer))
+				tcpPacketReadingErrors.Add(1)
+				tlmTCPPackets.Inc("too_big")
+				startWriteIndex = 0
+				discarding = true
+			} else {
+				copy(buffer, buffer[messageSize:endIndex])
+			}
+		}
+
+		if err != nil {
+			return total, err
+		}
+
+		t2 = time.Now()
+		tlmListener.Observe(float64(t2.Sub(t1).Nanoseconds()), "tcp")
+	}
+}
+
+// readLengthPrefixed reads length-prefixed frames from the connection until
+// an error occurs. It returns the number of bytes read.
+func (l *TCPListener) readLengthPrefixed(conn net.Conn) (int, error) {
+	reader := bufio.NewReaderSize(conn, l.bufferSize+tcpLengthPrefixSize)
+	buffer := make([]byte, l.bufferSize)
+	header := make([]byte, tcpLengthPrefixSize)
+	total := 0
+	var t1, t2 time.Time
+	for {
+		l.refreshDeadline(conn)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return total, err
+		}
+		t1 = time.Now()
+		total += tcpLengthPrefixSize
+
+		frameSize := int(binary.LittleEndian.Uint32(header))
+		if frameSize > len(buffer) {
+			log.Debugf("dogstatsd-tcp: dropping frame from %s of %d bytes, bigger than %d bytes", conn.RemoteAddr(), frameSize, len(buffer))
+			tcpPacketReadingErrors.Add(1)
+			tlmTCPPackets.Inc("too_big")
+			n, err := io.CopyN(ioutil.Discard, reader, int64(frameSize))
+			total += int(n)
+			if err != nil {
+				return total, err
+			}
+			continue
+		}
+
+		n, err := io.ReadFull(reader, buffer[:frameSize])
+		total += n
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return total, err
+		}
+		if frameSize > 0 {
+			l.onMessage(buffer[:frameSize], frameSize+tcpLengthPrefixSize)
+		}
+
+		t2 = time.Now()
+		tlmListener.Observe(float64(t2.Sub(t1).Nanoseconds()), "tcp")
+	}
+}
+
+func (l *TCPListener) onMessage(message []byte, bytesRead int) {
+	tcpPackets.Add(1)
+	tcpBytes.Add(int64(bytesRead))
+	tlmTCPPackets.Inc("ok")
+	tlmTCPPacketsBytes.Add(float64(bytesRead))
+
+	// packetAssembler merges multiple packets together and sends them when its buffer is full
+	l.packetAssembler.AddMessage(message)
+}
+
+func (l *TCPListener) refreshDeadline(conn net.Conn) {
+	if l.idleTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(l.idleTimeout)) //nolint:errcheck
+	}
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// getActiveConnectionsCount returns the number of active connections.
+func (l *TCPListener) getActiveConnectionsCount() int {
+	l.connsMutex.Lock()
+	defer l.connsMutex.Unlock()
+	return len(l.conns)
+}
+
+// Stop closes the TCP listener, all the active connections and stops listening
+func (l *TCPListener) Stop() {
+	l.listener.Close()
+
+	l.connsMutex.Lock()
+	l.stopping = true
+	for conn := range l.conns {
+		conn.Close()
+	}
+	l.connsMutex.Unlock()
+
+	// Wait until all connections are closed
+	l.connsWg.Wait()
+
+	l.packetAssembler.Close()
+	l.packetsBuffer.Close()
+}
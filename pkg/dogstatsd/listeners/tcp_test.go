@@ -0,0 +1,277 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+)
+
+var (
+	packetPoolTCP        = packets.NewPool(config.Datadog.GetInt("dogstatsd_buffer_size"))
+	packetPoolManagerTCP = packets.NewPoolManager(packetPoolTCP)
+)
+
+func setupTCPConfig(t *testing.T, framing string) int {
+	port, err := getAvailableTCPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_tcp_port", port)
+	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
+	config.Datadog.SetDefault("dogstatsd_tcp_framing", framing)
+	config.Datadog.SetDefault("dogstatsd_tcp_max_connections", 1024)
+	config.Datadog.SetDefault("dogstatsd_tcp_tls_cert_file", "")
+	config.Datadog.SetDefault("dogstatsd_tcp_tls_key_file", "")
+	config.Datadog.SetDefault("dogstatsd_tcp_tls_client_ca_file", "")
+	return port
+}
+
+func TestStartStopTCPListener(t *testing.T) {
+	port := setupTCPConfig(t, TCPFramingNewline)
+	s, err := NewTCPListener(nil, packetPoolManagerTCP, nil)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	go s.Listen()
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Eventually(t, func() bool { return s.getActiveConnectionsCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	s.Stop()
+	assert.Equal(t, 0, s.getActiveConnectionsCount())
+
+	// the port can be bound again
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err, "port is not available, it should be")
+	l.Close()
+}
+
+func TestNewTCPListenerUnknownFraming(t *testing.T) {
+	setupTCPConfig(t, "unknown")
+	s, err := NewTCPListener(nil, packetPoolManagerTCP, nil)
+	assert.Nil(t, s)
+	assert.Error(t, err)
+}
+
+func TestTCPReceiveNewline(t *testing.T) {
+	port := setupTCPConfig(t, TCPFramingNewline)
+	packetChannel := make(chan packets.Packets)
+	s, err := NewTCPListener(packetChannel, packetPoolManagerTCP, nil)
+	require.NoError(t, err)
+
+	go s.Listen()
+	defer s.Stop()
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the second message is split across two writes and must be reassembled
+	conn.Write([]byte("daemon:666|g|#sometag1:somevalue1\nother:1|c"))
+	conn.Write([]byte("|#sometag2:somevalue2\npartial:1|c"))
+
+	select {
+	case pkts := <-packetChannel:
+		require.Len(t, pkts, 1)
+		assert.Equal(t, "daemon:666|g|#sometag1:somevalue1\nother:1|c|#sometag2:somevalue2", string(pkts[0].Contents))
+		assert.Equal(t, packets.TCP, pkts[0].Source)
+		assert.Equal(t, "", pkts[0].Origin)
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+}
+
+func TestTCPReceiveNewlineTooBig(t *testing.T) {
+	port := setupTCPConfig(t, TCPFramingNewline)
+	packetChannel := make(chan packets.Packets)
+	s, err := NewTCPListener(packetChannel, packetPoolManagerTCP, nil)
+	require.NoError(t, err)
+
+	go s.Listen()
+	defer s.Stop()
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	big := make([]byte, 2*config.Datadog.GetInt("dogstatsd_buffer_size"))
+	for i := range big {
+		big[i] = 'a'
+	}
+	conn.Write(big)
+	conn.Write([]byte("aaa\ndaemon:666|g\n"))
+
+	select {
+	case pkts := <-packetChannel:
+		require.Len(t, pkts, 1)
+		assert.Equal(t, "daemon:666|g", string(pkts[0].Contents))
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+}
+
+func TestTCPReceiveLengthPrefixed(t *testing.T) {
+	port := setupTCPConfig(t, TCPFramingLengthPrefixed)
+	packetChannel := make(chan packets.Packets)
+	s, err := NewTCPListener(packetChannel, packetPoolManagerTCP, nil)
+	require.NoError(t, err)
+
+	go s.Listen()
+	defer s.Stop()
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.Write(lengthPrefixed("daemon:666|g|#sometag1:somevalue1"))
+	conn.Write(lengthPrefixed("other:1|c"))
+
+	select {
+	case pkts := <-packetChannel:
+		require.Len(t, pkts, 1)
+		assert.Equal(t, "daemon:666|g|#sometag1:somevalue1\nother:1|c", string(pkts[0].Contents))
+		assert.Equal(t, packets.TCP, pkts[0].Source)
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+}
+
+func TestTCPMaxConnections(t *testing.T) {
+	port := setupTCPConfig(t, TCPFramingNewline)
+	config.Datadog.SetDefault("dogstatsd_tcp_max_connections", 1)
+	s, err := NewTCPListener(nil, packetPoolManagerTCP, nil)
+	require.NoError(t, err)
+
+	go s.Listen()
+	defer s.Stop()
+
+	first, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer first.Close()
+	assert.Eventually(t, func() bool { return s.getActiveConnectionsCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	second, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer second.Close()
+
+	// the second connection is closed by the listener
+	second.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = second.Read(make([]byte, 1))
+	assert.Error(t, err)
+	assert.Equal(t, 1, s.getActiveConnectionsCount())
+}
+
+func TestTCPReceiveTLSClientCert(t *testing.T) {
+	port := setupTCPConfig(t, TCPFramingNewline)
+	dir := t.TempDir()
+	serverCert, serverKey := writeTestCertificate(t, dir, "server")
+	clientCert, clientKey := writeTestCertificate(t, dir, "client")
+	config.Datadog.SetDefault("dogstatsd_tcp_tls_cert_file", serverCert)
+	config.Datadog.SetDefault("dogstatsd_tcp_tls_key_file", serverKey)
+	config.Datadog.SetDefault("dogstatsd_tcp_tls_client_ca_file", clientCert)
+
+	packetChannel := make(chan packets.Packets)
+	s, err := NewTCPListener(packetChannel, packetPoolManagerTCP, nil)
+	require.NoError(t, err)
+
+	go s.Listen()
+	defer s.Stop()
+
+	// a client without certificate is refused
+	conn, err := tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port), &tls.Config{InsecureSkipVerify: true})
+	if err == nil {
+		conn.Write([]byte("daemon:1|g\n"))
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	assert.Error(t, err)
+
+	cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+	conn, err = tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port), &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		InsecureSkipVerify: true,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.Write([]byte("daemon:666|g\n"))
+
+	select {
+	case pkts := <-packetChannel:
+		require.Len(t, pkts, 1)
+		assert.Equal(t, "daemon:666|g", string(pkts[0].Contents))
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+}
+
+func lengthPrefixed(message string) []byte {
+	frame := make([]byte, tcpLengthPrefixSize+len(message))
+	binary.LittleEndian.PutUint32(frame, uint32(len(message)))
+	copy(frame[tcpLengthPrefixSize:], message)
+	return frame
+}
+
+// writeTestCertificate writes a self-signed certificate and its key in dir
+// and returns their paths.
+func writeTestCertificate(t *testing.T, dir string, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certPath, keyPath
+}
+
+// getAvailableTCPPort requests a random port number and makes sure it is available
+func getAvailableTCPPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return -1, fmt.Errorf("can't find an available tcp port: %s", err)
+	}
+	defer l.Close()
+
+	_, portString, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		return -1, fmt.Errorf("can't find an available tcp port: %s", err)
+	}
+	return strconv.Atoi(portString)
+}
@@ -36,3 +36,18 @@ func (m *mapperCache) get(metricName string) (*MapResult, bool) {
 func (m *mapperCache) add(metricName string, mapResult *MapResult) {
 	m.cache.Add(metricName, mapResult)
 }
+
+// getRules returns:
+// - the rewriting rules matching the metric name if found, otherwise nil
+// - a boolean indicating if the metric name has been found
+func (m *mapperCache) getRules(metricName string) ([]*rewriteRule, bool) {
+	if rules, ok := m.cache.Get(metricName); ok {
+		return rules.([]*rewriteRule), true
+	}
+	return nil, false
+}
+
+// addRules adds the rewriting rules matching a metric name to cache with metric name as key
+func (m *mapperCache) addRules(metricName string, rules []*rewriteRule) {
+	m.cache.Add(metricName, rules)
+}
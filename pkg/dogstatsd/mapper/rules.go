@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mapper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// MetricRewriter applies rewriting rules to the name and tags of metrics
+type MetricRewriter struct {
+	rules []*rewriteRule
+	cache *mapperCache
+}
+
+// rewriteRule represent one rewriting rule. A rule applies to a metric when
+// its name matches nameRegex (if any) and when, for every entry of tagRegexes,
+// the metric has a tag with this key and a value matching the regex.
+type rewriteRule struct {
+	nameRegex      *regexp.Regexp
+	tagRegexes     map[string]*regexp.Regexp
+	drop           bool
+	allowTags      map[string]struct{}
+	renameTags     map[string]string
+	stripTagValues map[string]struct{}
+}
+
+// NewMetricRewriter creates, validates, prepares a new MetricRewriter
+func NewMetricRewriter(configRules []config.MetricRewriteRule, cacheSize int) (*MetricRewriter, error) {
+	var rules []*rewriteRule
+	for i, configRule := range configRules {
+		rule := &rewriteRule{drop: configRule.Drop}
+
+		if configRule.Match != "" {
+			matchType := configRule.MatchType
+			if matchType == "" {
+				matchType = matchTypeWildcard
+			}
+			if matchType != matchTypeWildcard && matchType != matchTypeRegex {
+				return nil, fmt.Errorf("rule num %d: invalid match type, must be `wildcard` or `regex`", i)
+			}
+			regex, err := buildRegex(configRule.Match, matchType)
+			if err != nil {
+				return nil, fmt.Errorf("rule num %d: %v", i, err)
+			}
+			rule.nameRegex = regex
+		}
+
+		if len(configRule.MatchTags) > 0 {
+			rule.tagRegexes = make(map[string]*regexp.Regexp, len(configRule.MatchTags))
+			for tagKey, valueMatch := range configRule.MatchTags {
+				regex, err := regexp.Compile("^(?:" + valueMatch + ")$")
+				if err != nil {
+					return nil, fmt.Errorf("rule num %d: invalid match on tag `%s`. cannot compile regex: %v", i, tagKey, err)
+				}
+				rule.tagRegexes[tagKey] = regex
+			}
+		}
+
+		if len(configRule.AllowTags) > 0 {
+			rule.allowTags = toSet(configRule.AllowTags)
+		}
+		if len(configRule.RenameTags) > 0 {
+			rule.renameTags = configRule.RenameTags
+		}
+		if len(configRule.StripTagValues) > 0 {
+			rule.stripTagValues = toSet(configRule.StripTagValues)
+		}
+
+		if !rule.drop && rule.allowTags == nil && rule.renameTags == nil && rule.stripTagValues == nil {
+			return nil, fmt.Errorf("rule num %d: no action, one of `drop`, `allow_tags`, `rename_tags` or `strip_tag_values` is required", i)
+		}
+		rules = append(rules, rule)
+	}
+	cache, err := newMapperCache(cacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &MetricRewriter{rules: rules, cache: cache}, nil
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return set
+}
+
+// Rewrite applies the rules matching the metric to its tags, in the order
+// they are defined. Within a rule, tags are first filtered with the allow
+// list, then renamed, then stripped of their value. The tags slice is
+// modified in place. It returns false if the metric must be dropped.
+func (r *MetricRewriter) Rewrite(metricName string, tags []string) ([]string, bool) {
+	for _, rule := range r.rulesForName(metricName) {
+		if !rule.matchTags(tags) {
+			continue
+		}
+		if rule.drop {
+			return tags, false
+		}
+		tags = rule.apply(tags)
+	}
+	return tags, true
+}
+
+// rulesForName returns the rules whose name pattern matches the metric name.
+// The result only depends on the metric name, so it is cached.
+func (r *MetricRewriter) rulesForName(metricName string) []*rewriteRule {
+	if rules, cached := r.cache.getRules(metricName); cached {
+		return rules
+	}
+
+	var rules []*rewriteRule
+	for _, rule := range r.rules {
+		if rule.nameRegex == nil || rule.nameRegex.MatchString(metricName) {
+			rules = append(rules, rule)
+		}
+	}
+	r.cache.addRules(metricName, rules)
+	return rules
+}
+
+func (rule *rewriteRule) matchTags(tags []string) bool {
+	for tagKey, regex := range rule.tagRegexes {
+		found := false
+		for _, tag := range tags {
+			key, value := splitTag(tag)
+			if key == tagKey && regex.MatchString(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (rule *rewriteRule) apply(tags []string) []string {
+	n := 0
+	for _, tag := range tags {
+		key, value := splitTag(tag)
+		if rule.allowTags != nil {
+			if _, ok := rule.allowTags[key]; !ok {
+				continue
+			}
+		}
+		if newKey, ok := rule.renameTags[key]; ok {
+			key = newKey
+			if value != "" {
+				tag = key + ":" + value
+			} else {
+				tag = key
+			}
+		}
+		if _, ok := rule.stripTagValues[key]; ok {
+			tag = key
+		}
+		tags[n] = tag
+		n++
+	}
+	return tags[:n]
+}
+
+// splitTag returns the key and the value of a `key:value` tag. Tags without
+// a colon only have a key.
+func splitTag(tag string) (string, string) {
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mapper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestRewrite(t *testing.T) {
+	type result struct {
+		tags []string
+		keep bool
+	}
+	scenarios := []struct {
+		name            string
+		config          string
+		metrics         map[string][]string
+		expectedResults map[string]result
+	}{
+		{
+			name: "Drop by name",
+			config: `
+dogstatsd_mapper_rules:
+  - match: "test.debug.*"
+    drop: true
+`,
+			metrics: map[string][]string{
+				"test.debug.count": {"env:prod"},
+				"test.other.count": {"env:prod"},
+				"test.debug":       {"env:prod"},
+			},
+			expectedResults: map[string]result{
+				"test.debug.count": {tags: []string{"env:prod"}, keep: false},
+				"test.other.count": {tags: []string{"env:prod"}, keep: true},
+				"test.debug":       {tags: []string{"env:prod"}, keep: true},
+			},
+		},
+		{
+			name: "Drop by name and tag",
+			config: `
+dogstatsd_mapper_rules:
+  - match: 'test\..*'
+    match_type: regex
+    match_tags:
+      env: "dev|staging"
+    drop: true
+`,
+			metrics: map[string][]string{
+				"test.a.b": {"env:staging", "service:foo"},
+				"test.c":   {"env:prod"},
+				"test.d":   {"service:foo"},
+				"other":    {"env:dev"},
+			},
+			expectedResults: map[string]result{
+				"test.a.b": {tags: []string{"env:staging", "service:foo"}, keep: false},
+				"test.c":   {tags: []string{"env:prod"}, keep: true},
+				"test.d":   {tags: []string{"service:foo"}, keep: true},
+				"other":    {tags: []string{"env:dev"}, keep: true},
+			},
+		},
+		{
+			name: "Allow, rename and strip tags",
+			config: `
+dogstatsd_mapper_rules:
+  - match: "test.latency"
+    allow_tags: ["env", "endpoint", "user"]
+    rename_tags:
+      endpoint: resource
+    strip_tag_values: ["user"]
+`,
+			metrics: map[string][]string{
+				"test.latency": {"env:prod", "endpoint:/users", "request_id:1234", "user:bob", "flag"},
+				"test.count":   {"env:prod", "request_id:1234"},
+			},
+			expectedResults: map[string]result{
+				"test.latency": {tags: []string{"env:prod", "resource:/users", "user"}, keep: true},
+				"test.count":   {tags: []string{"env:prod", "request_id:1234"}, keep: true},
+			},
+		},
+		{
+			name: "Rules without name match apply to all metrics, in order",
+			config: `
+dogstatsd_mapper_rules:
+  - match_tags:
+      team: "payments"
+    rename_tags:
+      request_id: request
+  - strip_tag_values: ["request"]
+`,
+			metrics: map[string][]string{
+				"test.a": {"team:payments", "request_id:1234"},
+				"test.b": {"team:search", "request_id:1234", "request:5678"},
+			},
+			expectedResults: map[string]result{
+				"test.a": {tags: []string{"team:payments", "request"}, keep: true},
+				"test.b": {tags: []string{"team:search", "request_id:1234", "request"}, keep: true},
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			rewriter, err := getRewriter(scenario.config)
+			require.NoError(t, err)
+
+			// run twice to go through the cache
+			for i := 0; i < 2; i++ {
+				for name, tags := range scenario.metrics {
+					newTags, keep := rewriter.Rewrite(name, append([]string{}, tags...))
+					expected := scenario.expectedResults[name]
+					assert.Equal(t, expected.keep, keep, "metric %s", name)
+					assert.Equal(t, expected.tags, newTags, "metric %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestRewriteErrors(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name: "Missing action",
+			config: `
+dogstatsd_mapper_rules:
+  - match: "test.*"
+`,
+			expectedError: "rule num 0: no action",
+		},
+		{
+			name: "Invalid match type",
+			config: `
+dogstatsd_mapper_rules:
+  - match: "test.*"
+    match_type: glob
+    drop: true
+`,
+			expectedError: "rule num 0: invalid match type",
+		},
+		{
+			name: "Invalid wildcard",
+			config: `
+dogstatsd_mapper_rules:
+  - drop: true
+  - match: "test.**"
+    drop: true
+`,
+			expectedError: "rule num 1: invalid wildcard match pattern",
+		},
+		{
+			name: "Invalid tag regex",
+			config: `
+dogstatsd_mapper_rules:
+  - match_tags:
+      env: "(prod"
+    drop: true
+`,
+			expectedError: "invalid match on tag `env`",
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			_, err := getRewriter(scenario.config)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), scenario.expectedError)
+		})
+	}
+}
+
+func getRewriter(configString string) (*MetricRewriter, error) {
+	var rules []config.MetricRewriteRule
+	config.Datadog.SetConfigType("yaml")
+	err := config.Datadog.ReadConfig(strings.NewReader(configString))
+	if err != nil {
+		return nil, err
+	}
+	err = config.Datadog.UnmarshalKey("dogstatsd_mapper_rules", &rules)
+	if err != nil {
+		return nil, err
+	}
+	return NewMetricRewriter(rules, 1000)
+}
@@ -15,6 +15,8 @@ const (
 	UDS
 	// NamedPipe Windows named pipe listner
 	NamedPipe
+	// TCP listener
+	TCP
 )
 
 // Packet represents a statsd packet ready to process,
@@ -145,6 +145,7 @@ type Server struct {
 	debugTagsAccumulator      *tagset.HashingTagsAccumulator
 	TCapture                  *replay.TrafficCapture
 	mapper                    *mapper.MetricMapper
+	rewriter                  *mapper.MetricRewriter
 	eolTerminationUDP         bool
 	eolTerminationUDS         bool
 	eolTerminationNamedPipe   bool
@@ -251,6 +252,14 @@ func NewServer(aggregator *aggregator.BufferedAggregator, extraTags []string) (*
 			tmpListeners = append(tmpListeners, udpListener)
 		}
 	}
+	if config.Datadog.GetInt("dogstatsd_tcp_port") > 0 {
+		tcpListener, err := listeners.NewTCPListener(packetsChannel, sharedPacketPoolManager, capture)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, tcpListener)
+		}
+	}
 
 	pipeName := config.Datadog.GetString("dogstatsd_pipe_name")
 	if len(pipeName) > 0 {
@@ -263,7 +272,7 @@ func NewServer(aggregator *aggregator.BufferedAggregator, extraTags []string) (*
 	}
 
 	if len(tmpListeners) == 0 {
-		return nil, fmt.Errorf("listening on neither udp nor socket, please check your configuration")
+		return nil, fmt.Errorf("listening on neither udp, tcp nor socket, please check your configuration")
 	}
 
 	// check configuration for custom namespace
@@ -388,6 +397,18 @@ func NewServer(aggregator *aggregator.BufferedAggregator, extraTags []string) (*
 			s.mapper = mapperInstance
 		}
 	}
+
+	rules, err := config.GetDogstatsdMapperRules()
+	if err != nil {
+		log.Warnf("Could not parse mapper rules: %v", err)
+	} else if len(rules) != 0 {
+		rewriterInstance, err := mapper.NewMetricRewriter(rules, cacheSize)
+		if err != nil {
+			log.Warnf("Could not create metric rewriter: %v", err)
+		} else {
+			s.rewriter = rewriterInstance
+		}
+	}
 	return s, nil
 }
 
@@ -637,7 +658,7 @@ func (s *Server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 			sample.tags = append(sample.tags, mapResult.Tags...)
 		}
 	}
-	metricSamples = enrichMetricSample(metricSamples, sample, s.metricPrefix, s.metricPrefixBlacklist, s.metricBlocklist, s.defaultHostname, origin, s.entityIDPrecedenceEnabled, s.ServerlessMode)
+	metricSamples = enrichMetricSample(metricSamples, sample, s.metricPrefix, s.metricPrefixBlacklist, s.metricBlocklist, s.rewriter, s.defaultHostname, origin, s.entityIDPrecedenceEnabled, s.ServerlessMode)
 
 	if len(sample.values) > 0 {
 		s.sharedFloat64List.put(sample.values)
@@ -6,22 +6,96 @@
 package forwarder
 
 import (
+	"errors"
 	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
 	"github.com/DataDog/datadog-agent/pkg/util/backoff"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+// circuitState is the state of the circuit breaker of an endpoint.
+type circuitState int
+
+const (
+	// circuitClosed lets the transactions through
+	circuitClosed circuitState = iota
+	// circuitOpen holds the transactions back until the backoff duration elapses
+	circuitOpen
+	// circuitHalfOpen lets a limited number of probe transactions through to
+	// check whether the endpoint recovered
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Kinds of errors having their own threshold before opening the circuit
+const (
+	networkErrorKind = "network"
+	clientErrorKind  = "4xx"
+	serverErrorKind  = "5xx"
+)
+
+// errorKind returns the kind of an error returned by Transaction.Process
+func errorKind(err error) string {
+	var httpErr *transaction.HTTPError
+	if !errors.As(err, &httpErr) {
+		return networkErrorKind
+	}
+	if httpErr.StatusCode >= 500 {
+		return serverErrorKind
+	}
+	return clientErrorKind
+}
+
 type block struct {
 	nbError int
 	until   time.Time
+	// opened is true from the opening of the circuit to the next success
+	opened bool
+	// consecutiveErrors counts the errors of each kind while the circuit is closed
+	consecutiveErrors map[string]int
+	// probes is the number of probe transactions in flight while the circuit is half-open
+	probes      int
+	lastError   string
+	lastErrorAt time.Time
 }
 
+func (b *block) state(now time.Time) circuitState {
+	if now.Before(b.until) {
+		return circuitOpen
+	}
+	if b.opened {
+		return circuitHalfOpen
+	}
+	return circuitClosed
+}
+
+// blockedEndpoints is a circuit breaker holding back the transactions of the
+// endpoints returning errors.
+//
+// The circuit of an endpoint opens once the number of consecutive errors of a
+// kind (network, 4xx or 5xx) reaches the threshold of this kind, blocking the
+// endpoint for a backoff duration increasing with the number of failures.
+// Once this duration elapses the circuit is half-open: a limited number of
+// probe transactions are sent, closing the circuit if they succeed and opening
+// it again otherwise.
 type blockedEndpoints struct {
 	errorPerEndpoint map[string]*block
 	backoffPolicy    backoff.Policy
+	errorThresholds  map[string]int
+	maxProbes        int
 	m                sync.RWMutex
 }
 
@@ -52,56 +126,177 @@ func newBlockedEndpoints() *blockedEndpoints {
 
 	recoveryReset := config.Datadog.GetBool("forwarder_recovery_reset")
 
+	errorThresholds := map[string]int{
+		networkErrorKind: config.Datadog.GetInt("forwarder_circuit_breaker_network_error_threshold"),
+		clientErrorKind:  config.Datadog.GetInt("forwarder_circuit_breaker_4xx_threshold"),
+		serverErrorKind:  config.Datadog.GetInt("forwarder_circuit_breaker_5xx_threshold"),
+	}
+	for kind, threshold := range errorThresholds {
+		if threshold <= 0 {
+			log.Warnf("Configured circuit breaker threshold for %s errors (%v) is not positive; 1 will be used", kind, threshold)
+			errorThresholds[kind] = 1
+		}
+	}
+
+	maxProbes := config.Datadog.GetInt("forwarder_circuit_breaker_half_open_probes")
+	if maxProbes <= 0 {
+		log.Warnf("Configured forwarder_circuit_breaker_half_open_probes (%v) is not positive; 1 will be used", maxProbes)
+		maxProbes = 1
+	}
+
 	return &blockedEndpoints{
 		errorPerEndpoint: make(map[string]*block),
 		backoffPolicy:    backoff.NewPolicy(backoffFactor, backoffBase, backoffMax, recInterval, recoveryReset),
+		errorThresholds:  errorThresholds,
+		maxProbes:        maxProbes,
 	}
 }
 
+func (e *blockedEndpoints) getBlock(endpoint string) *block {
+	b, ok := e.errorPerEndpoint[endpoint]
+	if !ok {
+		b = &block{}
+		e.errorPerEndpoint[endpoint] = b
+	}
+	return b
+}
+
+// close opens the circuit of the endpoint, blocking it for a backoff duration.
 func (e *blockedEndpoints) close(endpoint string) {
 	e.m.Lock()
 	defer e.m.Unlock()
 
-	var b *block
-	if knownBlock, ok := e.errorPerEndpoint[endpoint]; ok {
-		b = knownBlock
-	} else {
-		b = &block{}
-	}
+	e.open(e.getBlock(endpoint))
+}
 
+func (e *blockedEndpoints) open(b *block) {
 	b.nbError = e.backoffPolicy.IncError(b.nbError)
 	b.until = time.Now().Add(e.getBackoffDuration(b.nbError))
+	b.opened = true
+	b.consecutiveErrors = nil
+	b.probes = 0
+}
 
-	e.errorPerEndpoint[endpoint] = b
+// fail records a failed transaction. The circuit opens if the error threshold
+// of its kind is reached or if the transaction was a probe.
+func (e *blockedEndpoints) fail(endpoint string, err error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	b := e.getBlock(endpoint)
+	kind := errorKind(err)
+	b.lastError = kind + ": " + err.Error()
+	b.lastErrorAt = time.Now()
+
+	switch b.state(time.Now()) {
+	case circuitHalfOpen:
+		log.Warnf("Probe transaction to '%s' failed, opening the circuit again", endpoint)
+		e.open(b)
+	case circuitClosed:
+		if b.consecutiveErrors == nil {
+			b.consecutiveErrors = make(map[string]int)
+		}
+		b.consecutiveErrors[kind]++
+		if b.consecutiveErrors[kind] >= e.errorThresholds[kind] {
+			log.Warnf("Opening the circuit of '%s' after %d consecutive %s errors", endpoint, b.consecutiveErrors[kind], kind)
+			e.open(b)
+		}
+	}
 }
 
+// recover records a successful transaction, closing the circuit of the endpoint.
 func (e *blockedEndpoints) recover(endpoint string) {
 	e.m.Lock()
 	defer e.m.Unlock()
 
-	var b *block
-	if knownBlock, ok := e.errorPerEndpoint[endpoint]; ok {
-		b = knownBlock
-	} else {
-		b = &block{}
+	b := e.getBlock(endpoint)
+	if b.state(time.Now()) == circuitHalfOpen {
+		log.Infof("Probe transaction to '%s' succeeded, closing the circuit", endpoint)
 	}
 
+	// The number of errors decreases slowly so that the backoff duration of
+	// a flapping endpoint keeps increasing.
 	b.nbError = e.backoffPolicy.DecError(b.nbError)
-	b.until = time.Now().Add(e.getBackoffDuration(b.nbError))
-
-	e.errorPerEndpoint[endpoint] = b
+	b.until = time.Time{}
+	b.opened = false
+	b.consecutiveErrors = nil
+	b.probes = 0
 }
 
+// isBlock returns true if the transactions to the endpoint must be held back.
 func (e *blockedEndpoints) isBlock(endpoint string) bool {
 	e.m.RLock()
 	defer e.m.RUnlock()
 
-	if b, ok := e.errorPerEndpoint[endpoint]; ok && time.Now().Before(b.until) {
+	b, ok := e.errorPerEndpoint[endpoint]
+	if !ok {
+		return false
+	}
+	switch b.state(time.Now()) {
+	case circuitOpen:
 		return true
+	case circuitHalfOpen:
+		return b.probes >= e.maxProbes
 	}
 	return false
 }
 
+// allow returns true if a transaction can be sent to the endpoint. While the
+// circuit is half-open, the transaction is counted as a probe.
+func (e *blockedEndpoints) allow(endpoint string) bool {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	b, ok := e.errorPerEndpoint[endpoint]
+	if !ok {
+		return true
+	}
+	switch b.state(time.Now()) {
+	case circuitOpen:
+		return false
+	case circuitHalfOpen:
+		if b.probes >= e.maxProbes {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
 func (e *blockedEndpoints) getBackoffDuration(numErrors int) time.Duration {
 	return e.backoffPolicy.GetBackoffDuration(numErrors)
 }
+
+// endpointHealth is the state of the circuit breaker of an endpoint, as
+// exposed in the status.
+type endpointHealth struct {
+	State        string
+	ErrorCount   int
+	BlockedUntil string `json:",omitempty"`
+	LastError    string `json:",omitempty"`
+	LastErrorAt  string `json:",omitempty"`
+}
+
+// health returns the state of the circuit breaker of each endpoint.
+func (e *blockedEndpoints) health() map[string]endpointHealth {
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	now := time.Now()
+	endpoints := make(map[string]endpointHealth, len(e.errorPerEndpoint))
+	for endpoint, b := range e.errorPerEndpoint {
+		h := endpointHealth{
+			State:      b.state(now).String(),
+			ErrorCount: b.nbError,
+			LastError:  b.lastError,
+		}
+		if now.Before(b.until) {
+			h.BlockedUntil = b.until.Format(time.RFC3339)
+		}
+		if !b.lastErrorAt.IsZero() {
+			h.LastErrorAt = b.lastErrorAt.Format(time.RFC3339)
+		}
+		endpoints[endpoint] = h
+	}
+	return endpoints
+}
@@ -6,6 +6,8 @@
 package forwarder
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 	"testing"
@@ -15,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
 )
 
 func init() {
@@ -262,3 +265,97 @@ func TestIsblockUnknown(t *testing.T) {
 
 	assert.False(t, e.isBlock("test"))
 }
+
+func TestErrorKind(t *testing.T) {
+	assert.Equal(t, networkErrorKind, errorKind(errors.New("connection refused")))
+	assert.Equal(t, clientErrorKind, errorKind(&transaction.HTTPError{StatusCode: 429}))
+	assert.Equal(t, serverErrorKind, errorKind(&transaction.HTTPError{StatusCode: 503}))
+	assert.Equal(t, serverErrorKind, errorKind(fmt.Errorf("wrapped: %w", &transaction.HTTPError{StatusCode: 500})))
+}
+
+func TestFailThresholds(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("forwarder_circuit_breaker_5xx_threshold", 3)
+	defer mockConfig.Set("forwarder_circuit_breaker_5xx_threshold", 1)
+	e := newBlockedEndpoints()
+	serverErr := &transaction.HTTPError{StatusCode: 503}
+
+	e.fail("test", serverErr)
+	e.fail("test", serverErr)
+	assert.False(t, e.isBlock("test"))
+
+	// a success resets the count of consecutive errors
+	e.recover("test")
+	e.fail("test", serverErr)
+	e.fail("test", serverErr)
+	assert.False(t, e.isBlock("test"))
+
+	// errors of other kinds have their own threshold
+	e.fail("test", errors.New("connection refused"))
+	assert.True(t, e.isBlock("test"))
+	e.recover("test")
+
+	e.fail("test", serverErr)
+	e.fail("test", serverErr)
+	e.fail("test", serverErr)
+	assert.True(t, e.isBlock("test"))
+}
+
+func TestHalfOpenProbes(t *testing.T) {
+	e := newBlockedEndpoints()
+
+	e.close("test")
+	assert.False(t, e.allow("test"))
+	assert.Equal(t, circuitOpen, e.errorPerEndpoint["test"].state(time.Now()))
+
+	// the backoff duration elapsed: a single probe is allowed
+	e.errorPerEndpoint["test"].until = time.Now().Add(-1 * time.Second)
+	assert.Equal(t, circuitHalfOpen, e.errorPerEndpoint["test"].state(time.Now()))
+	assert.False(t, e.isBlock("test"))
+	assert.True(t, e.allow("test"))
+	assert.True(t, e.isBlock("test"))
+	assert.False(t, e.allow("test"))
+
+	// the probe failed: the circuit opens again with a longer backoff
+	nbError := e.errorPerEndpoint["test"].nbError
+	e.fail("test", errors.New("connection refused"))
+	assert.Equal(t, circuitOpen, e.errorPerEndpoint["test"].state(time.Now()))
+	assert.Greater(t, e.errorPerEndpoint["test"].nbError, nbError)
+
+	// the probe succeeded: the circuit closes
+	e.errorPerEndpoint["test"].until = time.Now().Add(-1 * time.Second)
+	assert.True(t, e.allow("test"))
+	e.recover("test")
+	assert.Equal(t, circuitClosed, e.errorPerEndpoint["test"].state(time.Now()))
+	assert.True(t, e.allow("test"))
+	assert.True(t, e.allow("test"))
+}
+
+func TestHalfOpenMaxProbes(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("forwarder_circuit_breaker_half_open_probes", 2)
+	defer mockConfig.Set("forwarder_circuit_breaker_half_open_probes", 1)
+	e := newBlockedEndpoints()
+
+	e.close("test")
+	e.errorPerEndpoint["test"].until = time.Now().Add(-1 * time.Second)
+	assert.True(t, e.allow("test"))
+	assert.True(t, e.allow("test"))
+	assert.False(t, e.allow("test"))
+}
+
+func TestHealth(t *testing.T) {
+	e := newBlockedEndpoints()
+
+	e.recover("healthy")
+	e.fail("unhealthy", &transaction.HTTPError{StatusCode: 503, Message: "error 503"})
+
+	health := e.health()
+	require.Len(t, health, 2)
+	assert.Equal(t, endpointHealth{State: "closed"}, health["healthy"])
+	assert.Equal(t, "open", health["unhealthy"].State)
+	assert.Equal(t, 1, health["unhealthy"].ErrorCount)
+	assert.Equal(t, "5xx: error 503", health["unhealthy"].LastError)
+	assert.NotEmpty(t, health["unhealthy"].BlockedUntil)
+	assert.NotEmpty(t, health["unhealthy"].LastErrorAt)
+}
@@ -204,6 +204,7 @@ func (f *domainForwarder) Start() error {
 	if f.connectionResetInterval != 0 {
 		go f.scheduleConnectionResets()
 	}
+	registerEndpointsHealth(f.blockedList)
 
 	f.internalState = Started
 	return nil
@@ -228,6 +229,7 @@ func (f *domainForwarder) Stop(purgeHighPrio bool) {
 		w.Stop(purgeHighPrio)
 	}
 	f.workers = []*Worker{}
+	unregisterEndpointsHealth(f.blockedList)
 	close(f.highPrio)
 	close(f.lowPrio)
 	close(f.requeuedTransaction)
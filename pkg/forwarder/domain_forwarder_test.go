@@ -73,6 +73,19 @@ func TestDomainForwarderStop(t *testing.T) {
 	assert.Equal(t, Stopped, forwarder.State())
 }
 
+func TestDomainForwarderEndpointsHealth(t *testing.T) {
+	forwarder := newDomainForwarderForTest(0)
+	forwarder.blockedList.close("blocked")
+
+	forwarder.Start()
+	health := endpointsHealth().(map[string]endpointHealth)
+	assert.Equal(t, "open", health["blocked"].State)
+
+	forwarder.Stop(false)
+	health = endpointsHealth().(map[string]endpointHealth)
+	assert.NotContains(t, health, "blocked")
+}
+
 func TestDomainForwarderStop_WithConnectionReset(t *testing.T) {
 	forwarder := newDomainForwarderForTest(120 * time.Second)
 	forwarder.Stop(false) // this should be a noop
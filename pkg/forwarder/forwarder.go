@@ -63,6 +63,7 @@ type Forwarder interface {
 	SubmitV1CheckRuns(payload Payloads, extra http.Header) error
 	SubmitSeries(payload Payloads, extra http.Header) error
 	SubmitSketchSeries(payload Payloads, extra http.Header) error
+	SubmitPrometheusRemoteWrite(payload Payloads, extra http.Header) error
 	SubmitHostMetadata(payload Payloads, extra http.Header) error
 	SubmitAgentChecksMetadata(payload Payloads, extra http.Header) error
 	SubmitMetadata(payload Payloads, extra http.Header) error
@@ -196,10 +197,14 @@ type DefaultForwarder struct {
 	domainForwarders map[string]*domainForwarder
 	domainResolvers  map[string]resolver.DomainResolver
 	healthChecker    *forwarderHealth
+	payloadTee       *payloadTee
 	internalState    uint32
 	m                sync.Mutex // To control Start/Stop races
 
 	completionHandler transaction.HTTPCompletionHandler
+
+	// prometheusRemoteWrite is nil when no Prometheus remote-write endpoint is configured
+	prometheusRemoteWrite *prometheusRemoteWrite
 }
 
 // NewDefaultForwarder returns a new DefaultForwarder.
@@ -209,6 +214,7 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 		domainForwarders: map[string]*domainForwarder{},
 		domainResolvers:  map[string]resolver.DomainResolver{},
 		internalState:    Stopped,
+		payloadTee:       newPayloadTee(),
 		healthChecker: &forwarderHealth{
 			domainResolvers:       options.DomainResolvers,
 			disableAPIKeyChecking: options.DisableAPIKeyChecking,
@@ -286,6 +292,33 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 		}
 	}
 
+	// The Prometheus remote-write endpoint is a core-only feature.
+	if HasFeature(options.EnabledFeatures, CoreFeatures) {
+		remoteWrite, err := newPrometheusRemoteWrite()
+		if err != nil {
+			log.Errorf("Prometheus remote-write disabled: %v", err)
+		} else if remoteWrite != nil {
+			if _, found := f.domainForwarders[remoteWrite.domain]; found {
+				log.Errorf("Prometheus remote-write disabled: the domain %q is already used to send data to Datadog", remoteWrite.domain)
+			} else {
+				transactionContainer := retry.BuildTransactionRetryQueue(
+					config.Datadog.GetInt("prometheus_remote_write.retry_queue_payloads_max_size"),
+					flushToDiskMemRatio,
+					"", // The transactions are never stored on disk
+					0,
+					transactionContainerSort,
+					resolver.NewSingleDomainResolver(remoteWrite.domain, nil))
+				f.domainForwarders[remoteWrite.domain] = newDomainForwarder(
+					remoteWrite.domain,
+					transactionContainer,
+					options.NumberOfWorkers,
+					options.ConnectionResetInterval,
+					domainForwarderSort)
+				f.prometheusRemoteWrite = remoteWrite
+			}
+		}
+	}
+
 	if optionalRemovalPolicy != nil {
 		filesRemoved, err := optionalRemovalPolicy.RemoveUnknownDomains()
 		if err != nil {
@@ -376,6 +409,7 @@ func (f *DefaultForwarder) Stop() {
 	}
 
 	f.healthChecker.Stop()
+	f.payloadTee.stop()
 
 	f.healthChecker = nil
 	f.domainForwarders = map[string]*domainForwarder{}
@@ -449,10 +483,21 @@ func (f *DefaultForwarder) sendHTTPTransactions(transactions []*transaction.HTTP
 
 // SubmitSketchSeries will send payloads to Datadog backend - PROTOTYPE FOR PERCENTILE
 func (f *DefaultForwarder) SubmitSketchSeries(payload Payloads, extra http.Header) error {
+	f.payloadTee.tee(endpoints.SketchSeriesEndpoint, payload, extra)
 	transactions := f.createHTTPTransactions(endpoints.SketchSeriesEndpoint, payload, false, extra)
 	return f.sendHTTPTransactions(transactions)
 }
 
+// SubmitPrometheusRemoteWrite will send Prometheus remote-write payloads to the
+// endpoint configured with `prometheus_remote_write.url`
+func (f *DefaultForwarder) SubmitPrometheusRemoteWrite(payload Payloads, extra http.Header) error {
+	if f.prometheusRemoteWrite == nil {
+		return fmt.Errorf("no Prometheus remote-write endpoint is configured")
+	}
+	transactions := f.prometheusRemoteWrite.createHTTPTransactions(payload, extra)
+	return f.sendHTTPTransactions(transactions)
+}
+
 // SubmitHostMetadata will send a host_metadata tag type payload to Datadog backend.
 func (f *DefaultForwarder) SubmitHostMetadata(payload Payloads, extra http.Header) error {
 	return f.submitV1IntakeWithTransactionsFactory(payload, extra,
@@ -482,12 +527,14 @@ func (f *DefaultForwarder) SubmitMetadata(payload Payloads, extra http.Header) e
 // SubmitV1Series will send timeserie to v1 endpoint (this will be remove once
 // the backend handles v2 endpoints).
 func (f *DefaultForwarder) SubmitV1Series(payload Payloads, extra http.Header) error {
+	f.payloadTee.tee(endpoints.V1SeriesEndpoint, payload, extra)
 	transactions := f.createHTTPTransactions(endpoints.V1SeriesEndpoint, payload, true, extra)
 	return f.sendHTTPTransactions(transactions)
 }
 
 // SubmitSeries will send timeseries to the v2 endpoint
 func (f *DefaultForwarder) SubmitSeries(payload Payloads, extra http.Header) error {
+	f.payloadTee.tee(endpoints.SeriesEndpoint, payload, extra)
 	transactions := f.createHTTPTransactions(endpoints.SeriesEndpoint, payload, false, extra)
 	return f.sendHTTPTransactions(transactions)
 }
@@ -495,12 +542,14 @@ func (f *DefaultForwarder) SubmitSeries(payload Payloads, extra http.Header) err
 // SubmitV1CheckRuns will send service checks to v1 endpoint (this will be removed once
 // the backend handles v2 endpoints).
 func (f *DefaultForwarder) SubmitV1CheckRuns(payload Payloads, extra http.Header) error {
+	f.payloadTee.tee(endpoints.V1CheckRunsEndpoint, payload, extra)
 	transactions := f.createHTTPTransactions(endpoints.V1CheckRunsEndpoint, payload, true, extra)
 	return f.sendHTTPTransactions(transactions)
 }
 
 // SubmitV1Intake will send payloads to the universal `/intake/` endpoint used by Agent v.5
 func (f *DefaultForwarder) SubmitV1Intake(payload Payloads, extra http.Header) error {
+	f.payloadTee.tee(endpoints.V1IntakeEndpoint, payload, extra)
 	return f.submitV1IntakeWithTransactionsFactory(payload, extra, f.createHTTPTransactions)
 }
 
@@ -556,6 +605,7 @@ func (f *DefaultForwarder) SubmitOrchestratorChecks(payload Payloads, extra http
 }
 
 func (f *DefaultForwarder) submitProcessLikePayload(ep transaction.Endpoint, payload Payloads, extra http.Header, retryable bool) (chan Response, error) {
+	f.payloadTee.tee(ep, payload, extra)
 	transactions := f.createHTTPTransactions(ep, payload, false, extra)
 	results := make(chan Response, len(transactions))
 	internalResults := make(chan Response, len(transactions))
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config/resolver"
@@ -34,6 +35,10 @@ var (
 	validateAPIKeyTimeout = 10 * time.Second
 
 	apiKeyStatus = expvar.Map{}
+
+	// circuit breakers of the started domain forwarders, exposed in the status
+	endpointsHealthLists   = make(map[*blockedEndpoints]struct{})
+	endpointsHealthListsMu sync.Mutex
 )
 
 func init() {
@@ -46,6 +51,37 @@ func init() {
 func initForwarderHealthExpvars() {
 	apiKeyStatus.Init()
 	transaction.ForwarderExpvars.Set("APIKeyStatus", &apiKeyStatus)
+	transaction.ForwarderExpvars.Set("EndpointsHealth", expvar.Func(endpointsHealth))
+}
+
+// registerEndpointsHealth exposes the state of the circuit breaker of each
+// endpoint of blockedList in the status.
+func registerEndpointsHealth(blockedList *blockedEndpoints) {
+	endpointsHealthListsMu.Lock()
+	defer endpointsHealthListsMu.Unlock()
+	endpointsHealthLists[blockedList] = struct{}{}
+}
+
+func unregisterEndpointsHealth(blockedList *blockedEndpoints) {
+	endpointsHealthListsMu.Lock()
+	defer endpointsHealthListsMu.Unlock()
+	delete(endpointsHealthLists, blockedList)
+}
+
+// endpointsHealth returns the state of the circuit breaker of every endpoint
+// the forwarder sent transactions to, so that the status shows why
+// transactions are held back.
+func endpointsHealth() interface{} {
+	endpointsHealthListsMu.Lock()
+	defer endpointsHealthListsMu.Unlock()
+
+	endpoints := make(map[string]endpointHealth)
+	for blockedList := range endpointsHealthLists {
+		for endpoint, health := range blockedList.health() {
+			endpoints[endpoint] = health
+		}
+	}
+	return endpoints
 }
 
 // forwarderHealth report the health status of the Forwarder. A Forwarder is
@@ -6,9 +6,6 @@
 package retry
 
 import (
-	"crypto/md5"
-	"fmt"
-	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -109,14 +106,7 @@ func (p *FileRemovalPolicy) forEachDomainPath(callback func(folderPath string) (
 }
 
 func (p *FileRemovalPolicy) getFolderPathForDomain(domainName string) (string, error) {
-	// Use md5 for the folder name as the domainName is an url which can contain invalid charaters for a file path.
-	h := md5.New()
-	if _, err := io.WriteString(h, domainName); err != nil {
-		return "", err
-	}
-	folder := fmt.Sprintf("%x", h.Sum(nil))
-
-	return path.Join(p.rootPath, folder), nil
+	return DomainFolderPath(p.rootPath, domainName)
 }
 
 func (p *FileRemovalPolicy) removeUnknownDomain(folderPath string) ([]string, error) {
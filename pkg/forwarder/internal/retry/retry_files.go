@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package retry
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config/resolver"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+)
+
+// RetryFile is a file of the on-disk retry queue of a domain.
+type RetryFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// DomainFolderPath returns the folder of rootPath storing the retry files of a domain.
+func DomainFolderPath(rootPath string, domainName string) (string, error) {
+	// Use md5 for the folder name as the domainName is an url which can contain invalid charaters for a file path.
+	h := md5.New()
+	if _, err := io.WriteString(h, domainName); err != nil {
+		return "", err
+	}
+	folder := fmt.Sprintf("%x", h.Sum(nil))
+
+	return path.Join(rootPath, folder), nil
+}
+
+// ListRetryFiles returns the retry files of a domain folder, in the order
+// they are replayed by the forwarder: the most recent first.
+func ListRetryFiles(domainFolderPath string) ([]RetryFile, error) {
+	entries, err := ioutil.ReadDir(domainFolderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []RetryFile
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && filepath.Ext(entry.Name()) == retryTransactionsExtension {
+			files = append(files, RetryFile{
+				Path:    path.Join(domainFolderPath, entry.Name()),
+				Size:    entry.Size(),
+				ModTime: entry.ModTime(),
+			})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	return files, nil
+}
+
+// ReadRetryFile returns the transactions of a retry file and the number of
+// transactions that could not be deserialized. The resolver must be the one
+// of the domain of the file to restore the API keys.
+func ReadRetryFile(filePath string, resolver resolver.DomainResolver) ([]*transaction.HTTPTransaction, int, error) {
+	bytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	transactions, errorCount, err := NewHTTPTransactionsSerializer(resolver).Deserialize(bytes)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	httpTransactions := make([]*transaction.HTTPTransaction, 0, len(transactions))
+	for _, t := range transactions {
+		httpTransactions = append(httpTransactions, t.(*transaction.HTTPTransaction))
+	}
+	return httpTransactions, errorCount, nil
+}
+
+// WriteRetryFile replaces the transactions of a retry file. The modification
+// time of the file is kept so that its position in the retry queue does not
+// change.
+func WriteRetryFile(filePath string, transactions []*transaction.HTTPTransaction, resolver resolver.DomainResolver) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	serializer := NewHTTPTransactionsSerializer(resolver)
+	for _, t := range transactions {
+		if err := serializer.Add(t); err != nil {
+			return err
+		}
+	}
+	bytes, err := serializer.GetBytesAndReset()
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first to not lose the transactions in case of error.
+	tmpFile, err := ioutil.TempFile(filepath.Dir(filePath), filepath.Base(filePath)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmpFile.Write(bytes); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	if err = os.Chtimes(tmpFile.Name(), info.ModTime(), info.ModTime()); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), filePath)
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package retry
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config/resolver"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryFiles(t *testing.T) {
+	a := assert.New(t)
+	root, clean := createTmpFolder(a)
+	defer clean()
+	domainResolver := resolver.NewSingleDomainResolver(domainName, nil)
+
+	folder, err := DomainFolderPath(root, domainName)
+	a.NoError(err)
+	a.NoError(os.MkdirAll(folder, 0755))
+
+	older := writeTestRetryFile(a, folder, "older.retry", domainResolver, "endpoint1", "endpoint2")
+	newer := writeTestRetryFile(a, folder, "newer.retry", domainResolver, "endpoint3")
+	a.NoError(ioutil.WriteFile(path.Join(folder, "notRetryFile"), nil, 0600))
+
+	modTime := time.Now().Add(-time.Hour)
+	a.NoError(os.Chtimes(older, modTime, modTime))
+
+	files, err := ListRetryFiles(folder)
+	a.NoError(err)
+	a.Len(files, 2)
+	a.Equal(newer, files[0].Path)
+	a.Equal(older, files[1].Path)
+
+	transactions, errorCount, err := ReadRetryFile(older, domainResolver)
+	a.NoError(err)
+	a.Equal(0, errorCount)
+	a.Equal([]string{"endpoint1", "endpoint2"}, getEndpointsFromHTTPTransactions(transactions))
+
+	// Rewriting a file keeps its position in the queue
+	a.NoError(WriteRetryFile(older, transactions[1:], domainResolver))
+	transactions, _, err = ReadRetryFile(older, domainResolver)
+	a.NoError(err)
+	a.Equal([]string{"endpoint2"}, getEndpointsFromHTTPTransactions(transactions))
+	info, err := os.Stat(older)
+	a.NoError(err)
+	a.True(info.ModTime().Equal(files[1].ModTime))
+}
+
+func writeTestRetryFile(a *assert.Assertions, folder string, filename string, domainResolver resolver.DomainResolver, endpoints ...string) string {
+	serializer := NewHTTPTransactionsSerializer(domainResolver)
+	for _, t := range createHTTPTransactionCollectionTests(endpoints...) {
+		a.NoError(t.SerializeTo(serializer))
+	}
+	bytes, err := serializer.GetBytesAndReset()
+	a.NoError(err)
+
+	filePath := path.Join(folder, filename)
+	a.NoError(ioutil.WriteFile(filePath, bytes, 0600))
+	return filePath
+}
+
+func getEndpointsFromHTTPTransactions(transactions []*transaction.HTTPTransaction) []string {
+	var endpoints []string
+	for _, t := range transactions {
+		endpoints = append(endpoints, t.Endpoint.Name)
+	}
+	return endpoints
+}
@@ -0,0 +1,232 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	agentpayload "github.com/DataDog/agent-payload/v5/gogen"
+	model "github.com/DataDog/agent-payload/v5/process"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/endpoints"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// teeRecord is a payload written by the payload tee, one per line
+type teeRecord struct {
+	Timestamp string          `json:"timestamp"`
+	Endpoint  string          `json:"endpoint"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// payloadTee writes a sample of the payloads submitted to the forwarder to
+// local files, decoded to JSON, for debugging. The fraction of payloads
+// written is read from `forwarder_payload_tee_sample_rate` on each submission
+// so that it can be changed at runtime.
+type payloadTee struct {
+	m sync.Mutex
+	// file is opened on the first payload written
+	file     *os.File
+	fileSize int64
+
+	randFloat func() float64
+}
+
+func newPayloadTee() *payloadTee {
+	return &payloadTee{randFloat: rand.Float64}
+}
+
+// tee writes each payload with a probability of `forwarder_payload_tee_sample_rate`
+func (p *payloadTee) tee(endpoint transaction.Endpoint, payloads Payloads, extra http.Header) {
+	sampleRate := config.Datadog.GetFloat64("forwarder_payload_tee_sample_rate")
+	if sampleRate <= 0 {
+		return
+	}
+
+	for _, payload := range payloads {
+		if p.randFloat() >= sampleRate {
+			continue
+		}
+		decoded, err := decodePayload(endpoint, *payload, extra.Get("Content-Encoding"))
+		if err != nil {
+			log.Debugf("Payload tee: cannot decode the %s payload: %v", endpoint.Name, err)
+			continue
+		}
+		record := teeRecord{
+			Timestamp: time.Now().Format(time.RFC3339Nano),
+			Endpoint:  endpoint.Name,
+			Payload:   decoded,
+		}
+		if err := p.write(record); err != nil {
+			log.Debugf("Payload tee: cannot write the %s payload: %v", endpoint.Name, err)
+		}
+	}
+}
+
+// decodePayload decompresses the payload and decodes it to JSON using the
+// agent-payload types of its endpoint.
+func decodePayload(endpoint transaction.Endpoint, payload []byte, contentEncoding string) (json.RawMessage, error) {
+	switch endpoint.Name {
+	case endpoints.V1SeriesEndpoint.Name, endpoints.V1CheckRunsEndpoint.Name, endpoints.V1IntakeEndpoint.Name:
+		decompressed, err := decompressPayload(payload, contentEncoding)
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(decompressed) {
+			return nil, fmt.Errorf("invalid JSON payload")
+		}
+		return decompressed, nil
+	case endpoints.SeriesEndpoint.Name:
+		decompressed, err := decompressPayload(payload, contentEncoding)
+		if err != nil {
+			return nil, err
+		}
+		var metrics agentpayload.MetricPayload
+		if err := metrics.Unmarshal(decompressed); err != nil {
+			return nil, err
+		}
+		return json.Marshal(&metrics)
+	case endpoints.SketchSeriesEndpoint.Name:
+		decompressed, err := decompressPayload(payload, contentEncoding)
+		if err != nil {
+			return nil, err
+		}
+		var sketches agentpayload.SketchPayload
+		if err := sketches.Unmarshal(decompressed); err != nil {
+			return nil, err
+		}
+		return json.Marshal(&sketches)
+	default:
+		// The process-like payloads are encoded by the process-agent model,
+		// including their compression.
+		msg, err := model.DecodeMessage(payload)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(msg.Body)
+	}
+}
+
+func decompressPayload(payload []byte, contentEncoding string) ([]byte, error) {
+	codec, err := compression.CodecForContentEncoding(contentEncoding)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Decompress(nil, payload)
+}
+
+func (p *payloadTee) write(record teeRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	maxFileSize := config.Datadog.GetInt64("forwarder_payload_tee_max_file_size")
+	if p.file != nil && p.fileSize > 0 && p.fileSize+int64(len(line)) > maxFileSize {
+		if err := p.rotate(); err != nil {
+			return err
+		}
+	}
+	if p.file == nil {
+		if err := p.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := p.file.Write(line)
+	p.fileSize += int64(n)
+	return err
+}
+
+// teeFilePath returns the path of the file the payloads are written to. The
+// file is named after the executable as several agents may share the folder.
+func teeFilePath() string {
+	folder := config.Datadog.GetString("forwarder_payload_tee_path")
+	if folder == "" {
+		folder = path.Join(config.Datadog.GetString("run_path"), "payload_tee")
+	}
+	executable := filepath.Base(os.Args[0])
+	return path.Join(folder, fmt.Sprintf("payloads-%s.json", executable))
+}
+
+func (p *payloadTee) open() error {
+	filePath := teeFilePath()
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	log.Infof("Payload tee: writing a sample of the forwarder payloads to %s", filePath)
+	p.file = file
+	p.fileSize = info.Size()
+	return nil
+}
+
+// rotate renames the current file to <name>.1, shifting the previous rotated
+// files and removing the ones above `forwarder_payload_tee_max_files`.
+func (p *payloadTee) rotate() error {
+	filePath := p.file.Name()
+	if err := p.close(); err != nil {
+		return err
+	}
+
+	maxFiles := config.Datadog.GetInt("forwarder_payload_tee_max_files")
+	if maxFiles <= 0 {
+		return os.Remove(filePath)
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", filePath, maxFiles))
+	for i := maxFiles - 1; i > 0; i-- {
+		rotated := fmt.Sprintf("%s.%d", filePath, i)
+		if _, err := os.Stat(rotated); err == nil {
+			if err := os.Rename(rotated, fmt.Sprintf("%s.%d", filePath, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	return os.Rename(filePath, filePath+".1")
+}
+
+func (p *payloadTee) close() error {
+	if p.file == nil {
+		return nil
+	}
+	err := p.file.Close()
+	p.file = nil
+	p.fileSize = 0
+	return err
+}
+
+// stop closes the file the payloads are written to
+func (p *payloadTee) stop() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if err := p.close(); err != nil {
+		log.Debugf("Payload tee: cannot close the file: %v", err)
+	}
+}
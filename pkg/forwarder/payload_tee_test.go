@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	agentpayload "github.com/DataDog/agent-payload/v5/gogen"
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/endpoints"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+)
+
+func setupPayloadTee(t *testing.T, sampleRate float64) (*payloadTee, string, func()) {
+	folder, err := ioutil.TempDir("", "payload_tee")
+	require.NoError(t, err)
+
+	mockConfig := config.Mock()
+	mockConfig.Set("forwarder_payload_tee_sample_rate", sampleRate)
+	mockConfig.Set("forwarder_payload_tee_path", folder)
+
+	p := newPayloadTee()
+	return p, folder, func() {
+		p.stop()
+		os.RemoveAll(folder)
+	}
+}
+
+func readTeeRecords(t *testing.T, filePath string) []teeRecord {
+	file, err := os.Open(filePath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []teeRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record teeRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestPayloadTeeDecodesPayloads(t *testing.T) {
+	p, _, cleanup := setupPayloadTee(t, 1)
+	defer cleanup()
+
+	series := []byte(`{"series":[{"metric":"my.metric"}]}`)
+	compressedSeries, err := compression.ZlibCodec.Compress(nil, series)
+	require.NoError(t, err)
+	p.tee(endpoints.V1SeriesEndpoint, Payloads{&compressedSeries}, http.Header{"Content-Encoding": []string{"deflate"}})
+
+	sketches := agentpayload.SketchPayload{Sketches: []agentpayload.SketchPayload_Sketch{{Metric: "my.sketch"}}}
+	sketchesPayload, err := sketches.Marshal()
+	require.NoError(t, err)
+	p.tee(endpoints.SketchSeriesEndpoint, Payloads{&sketchesPayload}, nil)
+
+	processPayload, err := model.EncodeMessage(model.Message{
+		Header: model.MessageHeader{
+			Version:  model.MessageV3,
+			Encoding: model.MessageEncodingZstdPB,
+			Type:     model.TypeCollectorProc,
+		},
+		Body: &model.CollectorProc{HostName: "my-host"},
+	})
+	require.NoError(t, err)
+	p.tee(endpoints.ProcessesEndpoint, Payloads{&processPayload}, nil)
+
+	invalid := []byte("not a payload")
+	p.tee(endpoints.V1CheckRunsEndpoint, Payloads{&invalid}, nil)
+
+	records := readTeeRecords(t, teeFilePath())
+	require.Len(t, records, 3)
+	assert.Equal(t, "series_v1", records[0].Endpoint)
+	assert.JSONEq(t, string(series), string(records[0].Payload))
+	assert.Equal(t, "sketches_v2", records[1].Endpoint)
+	assert.Contains(t, string(records[1].Payload), `"my.sketch"`)
+	assert.Equal(t, "process", records[2].Endpoint)
+	assert.Contains(t, string(records[2].Payload), `"my-host"`)
+}
+
+func TestPayloadTeeSampleRate(t *testing.T) {
+	p, folder, cleanup := setupPayloadTee(t, 0.5)
+	defer cleanup()
+
+	samples := []float64{0.2, 0.7, 0.4, 0.9}
+	p.randFloat = func() float64 {
+		sample := samples[0]
+		samples = samples[1:]
+		return sample
+	}
+
+	var payloads Payloads
+	for i := 0; i < 4; i++ {
+		payload := []byte(`{"index":` + string(rune('0'+i)) + `}`)
+		payloads = append(payloads, &payload)
+	}
+	p.tee(endpoints.V1IntakeEndpoint, payloads, nil)
+
+	records := readTeeRecords(t, teeFilePath())
+	require.Len(t, records, 2)
+	assert.JSONEq(t, `{"index":0}`, string(records[0].Payload))
+	assert.JSONEq(t, `{"index":2}`, string(records[1].Payload))
+
+	// Disabled at runtime
+	config.Datadog.Set("forwarder_payload_tee_sample_rate", 0.0)
+	p.tee(endpoints.V1IntakeEndpoint, payloads, nil)
+	assert.Len(t, readTeeRecords(t, teeFilePath()), 2)
+
+	files, err := ioutil.ReadDir(folder)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestPayloadTeeRotation(t *testing.T) {
+	p, folder, cleanup := setupPayloadTee(t, 1)
+	defer cleanup()
+	config.Datadog.Set("forwarder_payload_tee_max_file_size", 1)
+	config.Datadog.Set("forwarder_payload_tee_max_files", 2)
+
+	for i := 0; i < 4; i++ {
+		payload := []byte(`{"index":` + string(rune('0'+i)) + `}`)
+		p.tee(endpoints.V1IntakeEndpoint, Payloads{&payload}, nil)
+	}
+
+	filePath := teeFilePath()
+	files, err := filepath.Glob(filepath.Join(folder, "*"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{filePath, filePath + ".1", filePath + ".2"}, files)
+
+	assert.JSONEq(t, `{"index":3}`, string(readTeeRecords(t, filePath)[0].Payload))
+	assert.JSONEq(t, `{"index":2}`, string(readTeeRecords(t, filePath+".1")[0].Payload))
+	assert.JSONEq(t, `{"index":1}`, string(readTeeRecords(t, filePath+".2")[0].Payload))
+}
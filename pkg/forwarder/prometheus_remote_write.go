@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const prometheusRemoteWriteEndpointName = "prometheus_remote_write"
+
+// prometheusRemoteWrite is a Prometheus-compatible store receiving the series
+// in addition to Datadog. It has its own domain forwarder, and so its own
+// workers, retry queue and circuit breaker.
+type prometheusRemoteWrite struct {
+	domain   string
+	endpoint transaction.Endpoint
+	headers  http.Header
+	priority transaction.Priority
+}
+
+// newPrometheusRemoteWrite returns the Prometheus remote-write destination
+// configured with `prometheus_remote_write.url`, nil if there is none.
+func newPrometheusRemoteWrite() (*prometheusRemoteWrite, error) {
+	rawURL := config.Datadog.GetString("prometheus_remote_write.url")
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid prometheus_remote_write.url %q, it must be an http or https URL", rawURL)
+	}
+
+	headers := make(http.Header)
+	for key, value := range config.Datadog.GetStringMapString("prometheus_remote_write.headers") {
+		headers.Set(key, value)
+	}
+
+	var priority transaction.Priority
+	switch p := config.Datadog.GetString("prometheus_remote_write.priority"); p {
+	case "normal":
+		priority = transaction.TransactionPriorityNormal
+	case "high":
+		priority = transaction.TransactionPriorityHigh
+	default:
+		return nil, fmt.Errorf("invalid prometheus_remote_write.priority %q, it must be \"normal\" or \"high\"", p)
+	}
+
+	return &prometheusRemoteWrite{
+		domain:   u.Scheme + "://" + u.Host,
+		endpoint: transaction.Endpoint{Route: u.RequestURI(), Name: prometheusRemoteWriteEndpointName},
+		headers:  headers,
+		priority: priority,
+	}, nil
+}
+
+func (p *prometheusRemoteWrite) createHTTPTransactions(payloads Payloads, extra http.Header) []*transaction.HTTPTransaction {
+	transactions := make([]*transaction.HTTPTransaction, 0, len(payloads))
+	for _, payload := range payloads {
+		t := transaction.NewHTTPTransaction()
+		t.Domain = p.domain
+		t.Endpoint = p.endpoint
+		t.Payload = payload
+		t.Priority = p.priority
+		// The headers may contain credentials and must not be stored on disk.
+		t.StorableOnDisk = false
+		t.Headers.Set(useragentHTTPHeaderKey, fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+		for key := range extra {
+			t.Headers.Set(key, extra.Get(key))
+		}
+		for key := range p.headers {
+			t.Headers.Set(key, p.headers.Get(key))
+		}
+
+		tlmTxInputCount.Inc(p.domain, p.endpoint.Name)
+		tlmTxInputBytes.Add(float64(t.GetPayloadSize()), p.domain, p.endpoint.Name)
+		transactionsInputCountByEndpoint.Add(p.endpoint.Name, 1)
+		transactionsInputBytesByEndpoint.Add(p.endpoint.Name, int64(t.GetPayloadSize()))
+
+		transactions = append(transactions, t)
+	}
+	return transactions
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+)
+
+func TestNewPrometheusRemoteWrite(t *testing.T) {
+	mockConfig := config.Mock()
+
+	remoteWrite, err := newPrometheusRemoteWrite()
+	assert.NoError(t, err)
+	assert.Nil(t, remoteWrite)
+
+	mockConfig.Set("prometheus_remote_write.url", "https://prometheus.example.com:9090/api/v1/write?tenant=a")
+	mockConfig.Set("prometheus_remote_write.headers", map[string]string{"Authorization": "Bearer token"})
+	mockConfig.Set("prometheus_remote_write.priority", "high")
+	remoteWrite, err = newPrometheusRemoteWrite()
+	require.NoError(t, err)
+	assert.Equal(t, "https://prometheus.example.com:9090", remoteWrite.domain)
+	assert.Equal(t, "/api/v1/write?tenant=a", remoteWrite.endpoint.Route)
+	assert.Equal(t, prometheusRemoteWriteEndpointName, remoteWrite.endpoint.Name)
+	assert.Equal(t, "Bearer token", remoteWrite.headers.Get("Authorization"))
+	assert.Equal(t, transaction.TransactionPriorityHigh, remoteWrite.priority)
+
+	mockConfig.Set("prometheus_remote_write.priority", "urgent")
+	_, err = newPrometheusRemoteWrite()
+	assert.Error(t, err)
+
+	mockConfig.Set("prometheus_remote_write.priority", "normal")
+	mockConfig.Set("prometheus_remote_write.url", "prometheus.example.com/api/v1/write")
+	_, err = newPrometheusRemoteWrite()
+	assert.Error(t, err)
+}
+
+func TestSubmitPrometheusRemoteWrite(t *testing.T) {
+	requests := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	mockConfig := config.Mock()
+	mockConfig.Set("prometheus_remote_write.url", ts.URL+"/api/v1/write")
+	mockConfig.Set("prometheus_remote_write.headers", map[string]string{"X-Scope-OrgID": "agents"})
+
+	options := NewOptions(map[string][]string{"https://datadog.example.com": {"api_key"}})
+	options.DisableAPIKeyChecking = true
+	options.EnabledFeatures = SetFeature(options.EnabledFeatures, CoreFeatures)
+	f := NewDefaultForwarder(options)
+	require.NotNil(t, f.prometheusRemoteWrite)
+	require.NoError(t, f.Start())
+	defer f.Stop()
+
+	data := []byte("remote write payload")
+	extra := http.Header{}
+	extra.Set("Content-Encoding", "snappy")
+	require.NoError(t, f.SubmitPrometheusRemoteWrite(Payloads{&data}, extra))
+
+	select {
+	case r := <-requests:
+		assert.Equal(t, "/api/v1/write", r.URL.Path)
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "agents", r.Header.Get("X-Scope-OrgID"))
+		assert.Empty(t, r.Header.Get("DD-Api-Key"))
+		assert.Equal(t, data, <-bodies)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the remote-write payload was not received")
+	}
+}
+
+func TestSubmitPrometheusRemoteWriteNotConfigured(t *testing.T) {
+	config.Mock()
+
+	options := NewOptions(map[string][]string{"https://datadog.example.com": {"api_key"}})
+	options.EnabledFeatures = SetFeature(options.EnabledFeatures, CoreFeatures)
+	f := NewDefaultForwarder(options)
+
+	data := []byte("remote write payload")
+	assert.Error(t, f.SubmitPrometheusRemoteWrite(Payloads{&data}, http.Header{}))
+}
@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/resolver"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/internal/retry"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+)
+
+// RetryQueueFile is a file of the on-disk retry queue of the forwarder,
+// storing the transactions that could not be sent to a domain.
+type RetryQueueFile struct {
+	Domain  string
+	Path    string
+	Size    int64
+	ModTime time.Time
+	// Transactions are the transactions stored in the file
+	Transactions []*transaction.HTTPTransaction
+	// DeserializeErrors is the number of transactions that could not be deserialized
+	DeserializeErrors int
+
+	resolver resolver.DomainResolver
+}
+
+// RetryQueueStoragePath returns the folder storing the on-disk retry queue of
+// the core agent.
+func RetryQueueStoragePath() string {
+	storagePath := config.Datadog.GetString("forwarder_storage_path")
+	if storagePath == "" {
+		storagePath = path.Join(config.Datadog.GetString("run_path"), "transactions_to_retry")
+	}
+	return path.Join(storagePath, "core")
+}
+
+// ReadRetryQueueFiles reads the retry files of each domain of keysPerDomain,
+// in the order they are replayed by the forwarder.
+func ReadRetryQueueFiles(storagePath string, keysPerDomain map[string][]string) ([]RetryQueueFile, error) {
+	var domains []string
+	for domain := range keysPerDomain {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	var files []RetryQueueFile
+	for _, domain := range domains {
+		// Use the same domain as the forwarder to find its folder
+		versionedDomain, err := config.AddAgentVersionToDomain(domain, "app")
+		if err != nil {
+			return nil, err
+		}
+		folderPath, err := retry.DomainFolderPath(storagePath, versionedDomain)
+		if err != nil {
+			return nil, err
+		}
+
+		retryFiles, err := retry.ListRetryFiles(folderPath)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		domainResolver := resolver.NewSingleDomainResolver(versionedDomain, keysPerDomain[domain])
+		for _, f := range retryFiles {
+			transactions, deserializeErrors, err := retry.ReadRetryFile(f.Path, domainResolver)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read %s: %v", f.Path, err)
+			}
+			files = append(files, RetryQueueFile{
+				Domain:            versionedDomain,
+				Path:              f.Path,
+				Size:              f.Size,
+				ModTime:           f.ModTime,
+				Transactions:      transactions,
+				DeserializeErrors: deserializeErrors,
+				resolver:          domainResolver,
+			})
+		}
+	}
+	return files, nil
+}
+
+// PurgeRetryQueueFile removes the transactions of a retry file for which
+// shouldRemove returns true. The file is removed when no transaction is left.
+// It returns the number of transactions removed.
+func PurgeRetryQueueFile(file RetryQueueFile, shouldRemove func(*transaction.HTTPTransaction) bool) (int, error) {
+	var kept []*transaction.HTTPTransaction
+	for _, t := range file.Transactions {
+		if !shouldRemove(t) {
+			kept = append(kept, t)
+		}
+	}
+
+	removed := len(file.Transactions) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	if len(kept) == 0 {
+		return removed, os.Remove(file.Path)
+	}
+	return removed, retry.WriteRetryFile(file.Path, kept, file.resolver)
+}
+
+// ReplayRetryQueueFile sends the transactions of a retry file to domain and
+// removes them from the file, unless keepFile is true. It stops at the first
+// transaction that cannot be sent and returns the number of transactions sent.
+func ReplayRetryQueueFile(ctx context.Context, file RetryQueueFile, domain string, keepFile bool) (int, error) {
+	return replayRetryQueueFile(ctx, file, domain, keepFile, newHTTPClient())
+}
+
+func replayRetryQueueFile(ctx context.Context, file RetryQueueFile, domain string, keepFile bool, client *http.Client) (int, error) {
+	sent := 0
+	var replayErr error
+	for _, t := range file.Transactions {
+		fileDomain := t.Domain
+		t.Domain = domain
+		err := t.Process(ctx, client)
+		t.Domain = fileDomain
+		if err != nil {
+			replayErr = fmt.Errorf("cannot send the transaction to the %q endpoint: %v", t.GetEndpointName(), err)
+			break
+		}
+		sent++
+	}
+
+	if !keepFile && sent > 0 {
+		// Remove the transactions sent to not send them twice
+		file.Transactions = file.Transactions[:sent]
+		if _, err := PurgeRetryQueueFile(file, func(*transaction.HTTPTransaction) bool { return true }); err != nil && replayErr == nil {
+			replayErr = err
+		}
+	}
+	return sent, replayErr
+}
@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/resolver"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/internal/retry"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+)
+
+const retryQueueTestDomain = "https://app.datadoghq.com"
+
+func createTestRetryQueueFile(t *testing.T, storagePath string, endpoints ...transaction.Endpoint) string {
+	versionedDomain, err := config.AddAgentVersionToDomain(retryQueueTestDomain, "app")
+	require.NoError(t, err)
+	folder, err := retry.DomainFolderPath(storagePath, versionedDomain)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(folder, 0755))
+
+	serializer := retry.NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver(versionedDomain, []string{"key1"}))
+	for i, endpoint := range endpoints {
+		tr := transaction.NewHTTPTransaction()
+		tr.Domain = versionedDomain
+		tr.Endpoint = endpoint
+		tr.Retryable = true
+		tr.CreatedAt = time.Now().Add(-time.Duration(i) * time.Hour)
+		payload := []byte(endpoint.Name)
+		tr.Payload = &payload
+		require.NoError(t, serializer.Add(tr))
+	}
+	bytes, err := serializer.GetBytesAndReset()
+	require.NoError(t, err)
+
+	filePath := path.Join(folder, "file.retry")
+	require.NoError(t, ioutil.WriteFile(filePath, bytes, 0600))
+	return filePath
+}
+
+func readTestRetryQueueFiles(t *testing.T, storagePath string) []RetryQueueFile {
+	files, err := ReadRetryQueueFiles(storagePath, map[string][]string{
+		retryQueueTestDomain:      {"key1"},
+		"https://unknown.domain/": {"key2"},
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestReadAndPurgeRetryQueueFiles(t *testing.T) {
+	storagePath, err := ioutil.TempDir("", "retry_queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(storagePath)
+
+	filePath := createTestRetryQueueFile(t, storagePath,
+		transaction.Endpoint{Route: "/api/v1/series?api_key=key1", Name: "series_v1"},
+		transaction.Endpoint{Route: "/api/v1/check_run", Name: "check_run_v1"},
+		transaction.Endpoint{Route: "/intake/", Name: "intake"})
+
+	files := readTestRetryQueueFiles(t, storagePath)
+	require.Len(t, files, 1)
+	assert.Equal(t, filePath, files[0].Path)
+	require.Len(t, files[0].Transactions, 3)
+	// The API keys are restored from the configuration
+	assert.Equal(t, "/api/v1/series?api_key=key1", files[0].Transactions[0].Endpoint.Route)
+
+	removed, err := PurgeRetryQueueFile(files[0], func(t *transaction.HTTPTransaction) bool {
+		return t.GetEndpointName() == "check_run_v1"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	files = readTestRetryQueueFiles(t, storagePath)
+	require.Len(t, files, 1)
+	require.Len(t, files[0].Transactions, 2)
+	assert.Equal(t, "series_v1", files[0].Transactions[0].GetEndpointName())
+	assert.Equal(t, "intake", files[0].Transactions[1].GetEndpointName())
+
+	// The file is removed once empty
+	removed, err = PurgeRetryQueueFile(files[0], func(*transaction.HTTPTransaction) bool { return true })
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.Empty(t, readTestRetryQueueFiles(t, storagePath))
+}
+
+func TestReplayRetryQueueFile(t *testing.T) {
+	storagePath, err := ioutil.TempDir("", "retry_queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(storagePath)
+
+	createTestRetryQueueFile(t, storagePath,
+		transaction.Endpoint{Route: "/api/v1/series?api_key=key1", Name: "series_v1"},
+		transaction.Endpoint{Route: "/intake/", Name: "intake"})
+
+	var received []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.URL.String())
+		if r.URL.Path == "/intake/" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	files := readTestRetryQueueFiles(t, storagePath)
+	require.Len(t, files, 1)
+
+	// The replay stops at the first error and only removes the transactions sent
+	sent, err := replayRetryQueueFile(context.Background(), files[0], ts.URL, false, ts.Client())
+	assert.Error(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"/api/v1/series?api_key=key1", "/intake/"}, received)
+
+	files = readTestRetryQueueFiles(t, storagePath)
+	require.Len(t, files, 1)
+	require.Len(t, files[0].Transactions, 1)
+	assert.Equal(t, "intake", files[0].Transactions[0].GetEndpointName())
+	assert.NotEqual(t, ts.URL, files[0].Transactions[0].Domain)
+
+	// The file is kept as is with keepFile
+	received = nil
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.URL.String())
+	})
+	sent, err = replayRetryQueueFile(context.Background(), files[0], ts.URL, true, ts.Client())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"/intake/"}, received)
+	assert.Len(t, readTestRetryQueueFiles(t, storagePath), 1)
+
+	sent, err = replayRetryQueueFile(context.Background(), files[0], ts.URL, false, ts.Client())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Empty(t, readTestRetryQueueFiles(t, storagePath))
+}
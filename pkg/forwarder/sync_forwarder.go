@@ -2,6 +2,7 @@ package forwarder
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -92,6 +93,20 @@ func (f *SyncForwarder) SubmitSketchSeries(payload Payloads, extra http.Header)
 	return f.sendHTTPTransactions(transactions)
 }
 
+// SubmitPrometheusRemoteWrite will send Prometheus remote-write payloads to the
+// endpoint configured with `prometheus_remote_write.url`
+func (f *SyncForwarder) SubmitPrometheusRemoteWrite(payload Payloads, extra http.Header) error {
+	remoteWrite, err := newPrometheusRemoteWrite()
+	if err != nil {
+		return err
+	}
+	if remoteWrite == nil {
+		return fmt.Errorf("no Prometheus remote-write endpoint is configured")
+	}
+	transactions := remoteWrite.createHTTPTransactions(payload, extra)
+	return f.sendHTTPTransactions(transactions)
+}
+
 // SubmitHostMetadata will send a host_metadata tag type payload to Datadog backend.
 func (f *SyncForwarder) SubmitHostMetadata(payload Payloads, extra http.Header) error {
 	return f.SubmitV1Intake(payload, extra)
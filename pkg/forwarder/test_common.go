@@ -113,6 +113,11 @@ func (tf *MockedForwarder) SubmitSketchSeries(payload Payloads, extra http.Heade
 	return tf.Called(payload, extra).Error(0)
 }
 
+// SubmitPrometheusRemoteWrite updates the internal mock struct
+func (tf *MockedForwarder) SubmitPrometheusRemoteWrite(payload Payloads, extra http.Header) error {
+	return tf.Called(payload, extra).Error(0)
+}
+
 // SubmitHostMetadata updates the internal mock struct
 func (tf *MockedForwarder) SubmitHostMetadata(payload Payloads, extra http.Header) error {
 	return tf.Called(payload, extra).Error(0)
@@ -19,6 +19,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
@@ -194,6 +195,17 @@ type HTTPTransaction struct {
 	Priority Priority
 }
 
+// HTTPError is the error returned by Process when the intake answered with an
+// error status code and the transaction must be retried.
+type HTTPError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
 // TransactionsSerializer serializes Transaction instances.
 type TransactionsSerializer interface {
 	Add(transaction *HTTPTransaction) error
@@ -347,11 +359,22 @@ func (t *HTTPTransaction) internalProcess(ctx context.Context, client *http.Clie
 		TransactionsDropped.Add(1)
 		TlmTxDropped.Inc(t.Domain, transactionEndpointName)
 		return resp.StatusCode, body, nil
+	} else if resp.StatusCode == http.StatusUnsupportedMediaType && t.fallbackToDeflate() {
+		t.ErrorCount++
+		transactionsErrors.Add(1)
+		tlmTxErrors.Inc(t.Domain, transactionEndpointName, "unsupported_encoding")
+		return resp.StatusCode, body, &HTTPError{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("content encoding not supported by %q, rescheduling the transaction with the %q content encoding", logURL, compression.ZlibCodec.ContentEncoding()),
+		}
 	} else if resp.StatusCode > 400 {
 		t.ErrorCount++
 		transactionsErrors.Add(1)
 		tlmTxErrors.Inc(t.Domain, transactionEndpointName, "gt_400")
-		return resp.StatusCode, body, fmt.Errorf("error %q while sending transaction to %q, rescheduling it", resp.Status, logURL)
+		return resp.StatusCode, body, &HTTPError{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("error %q while sending transaction to %q, rescheduling it", resp.Status, logURL),
+		}
 	}
 
 	tlmTxSuccessCount.Inc(t.Domain, transactionEndpointName)
@@ -376,6 +399,38 @@ func (t *HTTPTransaction) internalProcess(ctx context.Context, client *http.Clie
 	return resp.StatusCode, body, nil
 }
 
+// fallbackToDeflate recompresses the payload with zlib, which is supported by
+// every intake, when its content encoding is rejected. It returns false when
+// the payload is already compressed with zlib or cannot be recompressed.
+func (t *HTTPTransaction) fallbackToDeflate() bool {
+	contentEncoding := t.Headers.Get("Content-Encoding")
+	if t.Payload == nil || contentEncoding == compression.ZlibCodec.ContentEncoding() {
+		return false
+	}
+
+	codec, err := compression.CodecForContentEncoding(contentEncoding)
+	if err != nil {
+		log.Errorf("Cannot fall back to the %q content encoding: %s", compression.ZlibCodec.ContentEncoding(), err)
+		return false
+	}
+	decompressed, err := codec.Decompress(nil, *t.Payload)
+	if err != nil {
+		log.Errorf("Cannot decompress the %q payload: %s", contentEncoding, err)
+		return false
+	}
+	compressed, err := compression.ZlibCodec.Compress(nil, decompressed)
+	if err != nil {
+		log.Errorf("Cannot compress the payload with zlib: %s", err)
+		return false
+	}
+
+	log.Warnf("Content encoding %q rejected by %q, falling back to %q", contentEncoding, t.Domain, compression.ZlibCodec.ContentEncoding())
+	// The payload may be shared with the transactions of other domains: replace it instead of modifying it.
+	t.Payload = &compressed
+	t.Headers.Set("Content-Encoding", compression.ZlibCodec.ContentEncoding())
+	return true
+}
+
 // SerializeTo serializes the transaction using TransactionsSerializer
 func (t *HTTPTransaction) SerializeTo(serializer TransactionsSerializer) error {
 	if t.StorableOnDisk {
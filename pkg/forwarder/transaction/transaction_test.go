@@ -7,12 +7,17 @@ package transaction
 
 import (
 	"context"
+	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 func TestNewHTTPTransaction(t *testing.T) {
@@ -96,6 +101,9 @@ func TestProcessHTTPError(t *testing.T) {
 	err := transaction.Process(context.Background(), client)
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "error \"503 Service Unavailable\" while sending transaction")
+	var httpErr *HTTPError
+	assert.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, errorCode, httpErr.StatusCode)
 
 	errorCode = http.StatusBadRequest
 	err = transaction.Process(context.Background(), client)
@@ -112,6 +120,48 @@ func TestProcessHTTPError(t *testing.T) {
 	assert.Equal(t, transaction.ErrorCount, 1)
 }
 
+func TestProcessUnsupportedContentEncoding(t *testing.T) {
+	var received []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != compression.ZlibCodec.ContentEncoding() {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		received, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	zstdCodec := compression.NewZstdCodec(1)
+	payload, err := zstdCodec.Compress(nil, []byte("test payload"))
+	require.NoError(t, err)
+
+	transaction := NewHTTPTransaction()
+	transaction.Domain = ts.URL
+	transaction.Endpoint.Route = "/endpoint/test"
+	transaction.Headers.Set("Content-Encoding", zstdCodec.ContentEncoding())
+	transaction.Payload = &payload
+
+	client := &http.Client{}
+
+	// the transaction is recompressed with zlib and rescheduled
+	err = transaction.Process(context.Background(), client)
+	assert.NotNil(t, err)
+	assert.Equal(t, compression.ZlibCodec.ContentEncoding(), transaction.Headers.Get("Content-Encoding"))
+
+	err = transaction.Process(context.Background(), client)
+	assert.Nil(t, err)
+	decompressed, err := compression.ZlibCodec.Decompress(nil, received)
+	require.NoError(t, err)
+	assert.Equal(t, "test payload", string(decompressed))
+
+	// unknown content encodings are kept as is
+	transaction.Headers.Set("Content-Encoding", "unknown")
+	err = transaction.Process(context.Background(), client)
+	assert.NotNil(t, err)
+	assert.Equal(t, "unknown", transaction.Headers.Get("Content-Encoding"))
+}
+
 func TestProcessCancel(t *testing.T) {
 	transaction := NewHTTPTransaction()
 	transaction.Domain = "example.com"
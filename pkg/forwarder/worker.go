@@ -174,11 +174,11 @@ func (w *Worker) process(ctx context.Context, t transaction.Transaction) {
 
 	// Run the endpoint through our blockedEndpoints circuit breaker
 	target := t.GetTarget()
-	if w.blockedList.isBlock(target) {
+	if !w.blockedList.allow(target) {
 		requeue()
 		log.Errorf("Too many errors for endpoint '%s': retrying later", target)
 	} else if err := t.Process(ctx, w.Client); err != nil {
-		w.blockedList.close(target)
+		w.blockedList.fail(target, err)
 		requeue()
 		log.Errorf("Error while processing transaction: %v", err)
 	} else {
@@ -34,6 +34,7 @@ const registryAPIVersion = 2
 type Registry interface {
 	GetOffset(identifier string) string
 	GetTailingMode(identifier string) string
+	GetEncoding(identifier string) string
 }
 
 // A RegistryEntry represents an entry in the registry where we keep track
@@ -43,6 +44,8 @@ type RegistryEntry struct {
 	Offset             string
 	TailingMode        string
 	IngestionTimestamp int64
+	// Encoding is the encoding of the file, configured or detected
+	Encoding string `json:",omitempty"`
 }
 
 // JSONRegistry represents the registry that will be written on disk
@@ -146,6 +149,17 @@ func (a *RegistryAuditor) GetTailingMode(identifier string) string {
 	return entry.TailingMode
 }
 
+// GetEncoding returns the last committed encoding for a given identifier,
+// returns an empty string if it does not exist.
+func (a *RegistryAuditor) GetEncoding(identifier string) string {
+	r := a.readOnlyRegistryCopy()
+	entry, exists := r[identifier]
+	if !exists {
+		return ""
+	}
+	return entry.Encoding
+}
+
 // run keeps up to date the registry depending on different events
 func (a *RegistryAuditor) run() {
 	cleanUpTicker := time.NewTicker(defaultCleanupPeriod)
@@ -167,7 +181,7 @@ func (a *RegistryAuditor) run() {
 				return
 			}
 			// update the registry with new entry
-			a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.IngestionTimestamp)
+			a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.Origin.Encoding, msg.IngestionTimestamp)
 		case <-cleanUpTicker.C:
 			// remove expired offsets from registry
 			a.cleanupRegistry()
@@ -219,7 +233,7 @@ func (a *RegistryAuditor) cleanupRegistry() {
 }
 
 // updateRegistry updates the registry entry matching identifier with new the offset and timestamp
-func (a *RegistryAuditor) updateRegistry(identifier string, offset string, tailingMode string, ingestionTimestamp int64) {
+func (a *RegistryAuditor) updateRegistry(identifier string, offset string, tailingMode string, encoding string, ingestionTimestamp int64) {
 	a.registryMutex.Lock()
 	defer a.registryMutex.Unlock()
 	if identifier == "" {
@@ -242,6 +256,7 @@ func (a *RegistryAuditor) updateRegistry(identifier string, offset string, taili
 		Offset:             offset,
 		TailingMode:        tailingMode,
 		IngestionTimestamp: ingestionTimestamp,
+		Encoding:           encoding,
 	}
 }
 
@@ -298,3 +313,31 @@ func (a *RegistryAuditor) unmarshalRegistry(b []byte) (map[string]*RegistryEntry
 		return nil, fmt.Errorf("invalid registry version number")
 	}
 }
+
+// ReadRegistry reads the registry stored at path, this is used to inspect the
+// offsets outside of the agent.
+func ReadRegistry(path string) (map[string]RegistryEntry, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := (&RegistryAuditor{}).unmarshalRegistry(b)
+	if err != nil {
+		return nil, err
+	}
+	registry := make(map[string]RegistryEntry)
+	for identifier, entry := range r {
+		registry[identifier] = *entry
+	}
+	return registry, nil
+}
+
+// WriteRegistry writes the registry at path, the agent overwriting it when it
+// runs, it must be stopped first.
+func WriteRegistry(path string, registry map[string]RegistryEntry) error {
+	b, err := (&RegistryAuditor{}).marshalRegistry(registry)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
@@ -62,14 +62,15 @@ func (suite *AuditorTestSuite) TestAuditorStartStop() {
 func (suite *AuditorTestSuite) TestAuditorUpdatesRegistry() {
 	suite.a.registry = make(map[string]*RegistryEntry)
 	suite.Equal(0, len(suite.a.registry))
-	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", 0)
+	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", "", 0)
 	suite.Equal(1, len(suite.a.registry))
 	suite.Equal("42", suite.a.registry[suite.source.Config.Path].Offset)
 	suite.Equal("end", suite.a.registry[suite.source.Config.Path].TailingMode)
-	suite.a.updateRegistry(suite.source.Config.Path, "43", "beginning", 1)
+	suite.a.updateRegistry(suite.source.Config.Path, "43", "beginning", "utf-16-le", 1)
 	suite.Equal(1, len(suite.a.registry))
 	suite.Equal("43", suite.a.registry[suite.source.Config.Path].Offset)
 	suite.Equal("beginning", suite.a.registry[suite.source.Config.Path].TailingMode)
+	suite.Equal("utf-16-le", suite.a.GetEncoding(suite.source.Config.Path))
 }
 
 func (suite *AuditorTestSuite) TestAuditorFlushesAndRecoversRegistry() {
@@ -123,6 +124,30 @@ func (suite *AuditorTestSuite) TestAuditorCleansupRegistry() {
 	suite.Equal("43", suite.a.registry[otherpath].Offset)
 }
 
+func (suite *AuditorTestSuite) TestReadAndWriteRegistry() {
+	_, err := ReadRegistry(suite.testPath)
+	suite.NotNil(err)
+
+	registry := map[string]RegistryEntry{
+		"journald:default": {
+			LastUpdated: time.Date(2006, time.January, 12, 1, 1, 1, 1, time.UTC),
+			Offset:      "s=cursor",
+		},
+	}
+	suite.Nil(WriteRegistry(suite.testPath, registry))
+	r, err := ioutil.ReadFile(suite.testPath)
+	suite.Nil(err)
+	suite.Equal("{\"Version\":2,\"Registry\":{\"journald:default\":{\"LastUpdated\":\"2006-01-12T01:01:01.000000001Z\",\"Offset\":\"s=cursor\",\"TailingMode\":\"\",\"IngestionTimestamp\":0}}}", string(r))
+
+	recovered, err := ReadRegistry(suite.testPath)
+	suite.Nil(err)
+	suite.Equal(registry, recovered)
+
+	// the registry is readable by the auditor
+	suite.a.registry = suite.a.recoverRegistry()
+	suite.Equal("s=cursor", suite.a.GetOffset("journald:default"))
+}
+
 func TestScannerTestSuite(t *testing.T) {
 	suite.Run(t, new(AuditorTestSuite))
 }
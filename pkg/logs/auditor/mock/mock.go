@@ -9,6 +9,7 @@ package mock
 type Registry struct {
 	offset      string
 	tailingMode string
+	encoding    string
 }
 
 // NewRegistry returns a new registry.
@@ -35,3 +36,13 @@ func (r *Registry) GetTailingMode(identifier string) string {
 func (r *Registry) SetTailingMode(tailingMode string) {
 	r.tailingMode = tailingMode
 }
+
+// GetEncoding returns the encoding.
+func (r *Registry) GetEncoding(identifier string) string {
+	return r.encoding
+}
+
+// SetEncoding sets the encoding.
+func (r *Registry) SetEncoding(encoding string) {
+	r.encoding = encoding
+}
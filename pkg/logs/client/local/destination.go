@@ -0,0 +1,206 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package local
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	warningPeriod = 1000
+	// stdoutName is the name of the standard output destination in the metrics
+	stdoutName = "stdout"
+)
+
+// retryInterval is the time a destination waits before writing again after a failure
+var retryInterval = time.Second
+
+// Destination writes the payloads to a local file, rotated when it reaches its
+// maximum size, or to the standard output, one payload per line.
+type Destination struct {
+	name                string
+	path                string
+	maxSize             int64
+	maxFiles            int
+	writer              io.Writer
+	file                *os.File
+	size                int64
+	lastError           error
+	mu                  sync.Mutex
+	destinationsContext *client.DestinationsContext
+	inputChan           chan []byte
+	once                sync.Once
+}
+
+// NewDestination returns a new destination writing to the file or to the
+// standard output depending on the type of the endpoint.
+func NewDestination(endpoint config.Endpoint, destinationsContext *client.DestinationsContext) *Destination {
+	if endpoint.Type == config.EndpointTypeStdout {
+		return &Destination{
+			name:                stdoutName,
+			writer:              os.Stdout,
+			destinationsContext: destinationsContext,
+		}
+	}
+	return &Destination{
+		name:                endpoint.Path,
+		path:                endpoint.Path,
+		maxSize:             endpoint.MaxSize,
+		maxFiles:            endpoint.MaxFiles,
+		destinationsContext: destinationsContext,
+	}
+}
+
+// Send writes a payload, the file being rotated first when the payload would
+// exceed its maximum size. The errors are retryable, the destination waiting
+// before writing again after a failure.
+func (d *Destination) Send(payload []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.lastError != nil {
+		select {
+		case <-time.After(retryInterval):
+		case <-d.destinationsContext.Context().Done():
+			return d.destinationsContext.Context().Err()
+		}
+	}
+
+	d.lastError = d.write(payload)
+	if d.lastError != nil {
+		return client.NewRetryableError(d.lastError)
+	}
+	metrics.BytesSent.Add(int64(len(payload)))
+	metrics.TlmBytesSent.Add(float64(len(payload)))
+	metrics.EncodedBytesSent.Add(int64(len(payload)))
+	metrics.TlmEncodedBytesSent.Add(float64(len(payload)))
+	return nil
+}
+
+// SendAsync writes a payload without blocking. If the channel is full, the incoming payloads are dropped.
+func (d *Destination) SendAsync(payload []byte) {
+	d.once.Do(func() {
+		d.inputChan = make(chan []byte, config.ChanSize)
+		metrics.DestinationLogsDropped.Set(d.name, &expvar.Int{})
+		go d.runAsync()
+	})
+
+	select {
+	case d.inputChan <- payload:
+	default:
+		if metrics.DestinationLogsDropped.Get(d.name).(*expvar.Int).Value()%warningPeriod == 0 {
+			log.Warnf("Some logs written to additional destination %v were dropped", d.name)
+		}
+		metrics.DestinationLogsDropped.Add(d.name, 1)
+		metrics.TlmLogsDropped.Inc(d.name)
+	}
+}
+
+// runAsync reads the payloads from the channel and writes them
+func (d *Destination) runAsync() {
+	ctx := d.destinationsContext.Context()
+	for {
+		select {
+		case payload := <-d.inputChan:
+			d.Send(payload) //nolint:errcheck
+		case <-ctx.Done():
+			d.mu.Lock()
+			d.closeFile()
+			d.mu.Unlock()
+			return
+		}
+	}
+}
+
+// write writes the payload followed by a new line
+func (d *Destination) write(payload []byte) error {
+	if d.path != "" {
+		if err := d.openFile(int64(len(payload) + 1)); err != nil {
+			return err
+		}
+	}
+	line := make([]byte, 0, len(payload)+1)
+	line = append(append(line, payload...), '\n')
+	n, err := d.writer.Write(line)
+	d.size += int64(n)
+	if err != nil {
+		d.closeFile()
+	}
+	return err
+}
+
+// openFile opens the file when it is not yet, and rotates it when writing
+// size more bytes would exceed its maximum size.
+func (d *Destination) openFile(size int64) error {
+	if d.file != nil && d.maxSize > 0 && d.size > 0 && d.size+size > d.maxSize {
+		d.closeFile()
+		if err := d.rotate(); err != nil {
+			log.Warnf("Could not rotate %s: %v", d.path, err)
+		}
+	}
+	if d.file != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(d.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	d.file = file
+	d.writer = file
+	d.size = info.Size()
+	if d.maxSize > 0 && d.size > 0 && d.size+size > d.maxSize {
+		return d.openFile(size)
+	}
+	return nil
+}
+
+// rotate renames the file to <path>.1, the previous rotated files being
+// shifted and the ones above maxFiles removed.
+func (d *Destination) rotate() error {
+	if d.maxFiles <= 0 {
+		return os.Remove(d.path)
+	}
+	os.Remove(rotatedPath(d.path, d.maxFiles)) //nolint:errcheck
+	for i := d.maxFiles - 1; i >= 1; i-- {
+		os.Rename(rotatedPath(d.path, i), rotatedPath(d.path, i+1)) //nolint:errcheck
+	}
+	return os.Rename(d.path, rotatedPath(d.path, 1))
+}
+
+func (d *Destination) closeFile() {
+	if d.file == nil {
+		return
+	}
+	d.file.Close()
+	d.file = nil
+	d.writer = nil
+	d.size = 0
+}
+
+// rotatedPath returns the path of the i-th rotated file
+func rotatedPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestDestinationWritesLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "logs.json")
+	destinationsCtx := client.NewDestinationsContext()
+	destinationsCtx.Start()
+	defer destinationsCtx.Stop()
+
+	destination := NewDestination(config.Endpoint{Type: config.EndpointTypeFile, Path: path}, destinationsCtx)
+	require.NoError(t, destination.Send([]byte(`{"message":"1"}`+"\n"+`{"message":"2"}`)))
+	require.NoError(t, destination.Send([]byte(`{"message":"3"}`)))
+	assert.Equal(t, `{"message":"1"}`+"\n"+`{"message":"2"}`+"\n"+`{"message":"3"}`+"\n", readFile(t, path))
+
+	// the logs are appended to the existing file
+	destination = NewDestination(config.Endpoint{Type: config.EndpointTypeFile, Path: path}, destinationsCtx)
+	require.NoError(t, destination.Send([]byte(`{"message":"4"}`)))
+	assert.Equal(t, `{"message":"1"}`+"\n"+`{"message":"2"}`+"\n"+`{"message":"3"}`+"\n"+`{"message":"4"}`+"\n", readFile(t, path))
+}
+
+func TestDestinationRotatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.json")
+	destinationsCtx := client.NewDestinationsContext()
+	destinationsCtx.Start()
+	defer destinationsCtx.Stop()
+
+	destination := NewDestination(config.Endpoint{Type: config.EndpointTypeFile, Path: path, MaxSize: 10, MaxFiles: 2}, destinationsCtx)
+	for _, payload := range []string{"first", "second", "third", "fourth"} {
+		require.NoError(t, destination.Send([]byte(payload)))
+	}
+	assert.Equal(t, "fourth\n", readFile(t, path))
+	assert.Equal(t, "third\n", readFile(t, path+".1"))
+	assert.Equal(t, "second\n", readFile(t, path+".2"))
+	_, err := os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	// the payloads larger than the maximum size are written to their own file
+	require.NoError(t, destination.Send([]byte("a payload larger than the maximum size")))
+	assert.Equal(t, "a payload larger than the maximum size\n", readFile(t, path))
+	assert.Equal(t, "fourth\n", readFile(t, path+".1"))
+}
+
+func TestDestinationRetriesAfterFailure(t *testing.T) {
+	defer func(interval time.Duration) { retryInterval = interval }(retryInterval)
+	retryInterval = 10 * time.Millisecond
+
+	dir := t.TempDir()
+	// the parent of the file is not a directory
+	parent := filepath.Join(dir, "parent")
+	require.NoError(t, ioutil.WriteFile(parent, nil, 0644))
+	destinationsCtx := client.NewDestinationsContext()
+	destinationsCtx.Start()
+
+	destination := NewDestination(config.Endpoint{Type: config.EndpointTypeFile, Path: filepath.Join(parent, "logs.json")}, destinationsCtx)
+	err := destination.Send([]byte("line"))
+	assert.IsType(t, &client.RetryableError{}, err)
+
+	require.NoError(t, os.Remove(parent))
+	require.NoError(t, destination.Send([]byte("line")))
+	assert.Equal(t, "line\n", readFile(t, filepath.Join(parent, "logs.json")))
+
+	// the destination stops retrying once stopped
+	require.NoError(t, os.RemoveAll(parent))
+	require.NoError(t, ioutil.WriteFile(parent, nil, 0644))
+	destination = NewDestination(config.Endpoint{Type: config.EndpointTypeFile, Path: filepath.Join(parent, "logs.json")}, destinationsCtx)
+	assert.IsType(t, &client.RetryableError{}, destination.Send([]byte("line")))
+	destinationsCtx.Stop()
+	assert.NotNil(t, destination.Send([]byte("line")))
+	assert.NotPanics(t, func() { destination.SendAsync([]byte("line")) })
+}
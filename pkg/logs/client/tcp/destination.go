@@ -35,7 +35,11 @@ type Destination struct {
 
 // NewDestination returns a new destination.
 func NewDestination(endpoint config.Endpoint, useProto bool, destinationsContext *client.DestinationsContext) *Destination {
-	prefix := endpoint.APIKey + string(' ')
+	// the routed endpoints may be third-party servers receiving the logs without API key
+	prefix := ""
+	if endpoint.APIKey != "" {
+		prefix = endpoint.APIKey + string(' ')
+	}
 	return &Destination{
 		prefixer:            newPrefixer(prefix),
 		delimiter:           NewDelimiter(useProto),
@@ -122,6 +122,9 @@ func BuildEndpointsWithConfig(logsConfig *LogsConfigKeys, endpointPrefix string,
 		log.Warnf("Use of illegal configuration parameter, if you need to send your logs to a proxy, "+
 			"please use '%s' and '%s' instead", logsConfig.getConfigKey("logs_dd_url"), logsConfig.getConfigKey("logs_no_ssl"))
 	}
+	if localDestination := logsConfig.localDestination(); localDestination != "" {
+		return buildLocalEndpoints(logsConfig, localDestination)
+	}
 	if logsConfig.isForceHTTPUse() || (bool(httpConnectivity) && !(logsConfig.isForceTCPUse() || logsConfig.isSocks5ProxySet() || logsConfig.hasAdditionalEndpoints())) {
 		return BuildHTTPEndpointsWithConfig(logsConfig, endpointPrefix, intakeTrackType, intakeProtocol, intakeOrigin)
 	}
@@ -186,13 +189,35 @@ func buildTCPEndpoints(logsConfig *LogsConfigKeys) (*Endpoints, error) {
 
 	additionals := logsConfig.getAdditionalEndpoints()
 	for i := 0; i < len(additionals); i++ {
-		additionals[i].UseSSL = main.UseSSL
+		additionals[i].UseSSL = main.UseSSL && !additionals[i].NoSSL
 		additionals[i].ProxyAddress = proxyAddress
 		additionals[i].APIKey = coreConfig.SanitizeAPIKey(additionals[i].APIKey)
 	}
+	additionals = validateRoutedEndpoints(additionals, false)
 	return NewEndpoints(main, additionals, useProto, false), nil
 }
 
+// buildLocalEndpoints returns the endpoints writing the logs as JSON to a local
+// file or to stdout instead of sending them, e.g. on air-gapped hosts.
+func buildLocalEndpoints(logsConfig *LogsConfigKeys, destinationType string) (*Endpoints, error) {
+	main := Endpoint{Type: destinationType}
+	switch destinationType {
+	case EndpointTypeFile:
+		main.Path = logsConfig.localDestinationPath()
+		main.MaxSize = logsConfig.localDestinationMaxSize()
+		main.MaxFiles = logsConfig.localDestinationMaxFiles()
+	case EndpointTypeStdout:
+	default:
+		return nil, fmt.Errorf("invalid %s: %q, should be %q or %q", logsConfig.getConfigKey("local_destination"), destinationType, EndpointTypeFile, EndpointTypeStdout)
+	}
+
+	if logsConfig.hasAdditionalEndpoints() {
+		log.Warnf("Ignoring %s, the logs are only written to the local destination", logsConfig.getConfigKey("additional_endpoints"))
+	}
+
+	return NewEndpointsWithBatchSettings(main, nil, false, false, logsConfig.batchWait(), logsConfig.batchMaxConcurrentSend(), logsConfig.batchMaxSize(), logsConfig.batchMaxContentSize()), nil
+}
+
 // BuildHTTPEndpoints returns the HTTP endpoints to send logs to.
 func BuildHTTPEndpoints(intakeTrackType IntakeTrackType, intakeProtocol IntakeProtocol, intakeOrigin IntakeOrigin) (*Endpoints, error) {
 	return BuildHTTPEndpointsWithConfig(defaultLogsConfigKeys(), httpEndpointPrefix, intakeTrackType, intakeProtocol, intakeOrigin)
@@ -239,7 +264,7 @@ func BuildHTTPEndpointsWithConfig(logsConfig *LogsConfigKeys, endpointPrefix str
 
 	additionals := logsConfig.getAdditionalEndpoints()
 	for i := 0; i < len(additionals); i++ {
-		additionals[i].UseSSL = main.UseSSL
+		additionals[i].UseSSL = main.UseSSL && !additionals[i].NoSSL
 		additionals[i].APIKey = coreConfig.SanitizeAPIKey(additionals[i].APIKey)
 		if additionals[i].Version == 0 {
 			additionals[i].Version = main.Version
@@ -250,6 +275,7 @@ func BuildHTTPEndpointsWithConfig(logsConfig *LogsConfigKeys, endpointPrefix str
 			additionals[i].Origin = intakeOrigin
 		}
 	}
+	additionals = validateRoutedEndpoints(additionals, true)
 
 	batchWait := logsConfig.batchWait()
 	batchMaxConcurrentSend := logsConfig.batchMaxConcurrentSend()
@@ -281,3 +307,32 @@ func TaggerWarmupDuration() time.Duration {
 func AggregationTimeout() time.Duration {
 	return defaultLogsConfigKeys().aggregationTimeout()
 }
+
+// HasLocalDestination returns true if the logs are written to a local file or to stdout instead of being sent.
+func HasLocalDestination() bool {
+	return defaultLogsConfigKeys().localDestination() != ""
+}
+
+// DiskQueueSettings holds the settings of the on-disk queues of the senders.
+type DiskQueueSettings struct {
+	Path           string
+	MaxSizeInBytes int64
+	MaxDiskRatio   float64
+	MaxAge         time.Duration
+}
+
+// DiskQueue returns the settings of the on-disk queues of the senders,
+// or nil if they are disabled.
+func DiskQueue() *DiskQueueSettings {
+	logsConfig := defaultLogsConfigKeys()
+	maxSizeInBytes := logsConfig.diskQueueMaxSizeInBytes()
+	if maxSizeInBytes <= 0 {
+		return nil
+	}
+	return &DiskQueueSettings{
+		Path:           logsConfig.diskQueuePath(),
+		MaxSizeInBytes: maxSizeInBytes,
+		MaxDiskRatio:   logsConfig.diskQueueMaxDiskRatio(),
+		MaxAge:         logsConfig.diskQueueMaxAge(),
+	}
+}
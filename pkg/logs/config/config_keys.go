@@ -7,6 +7,7 @@ package config
 
 import (
 	"encoding/json"
+	"path/filepath"
 	"time"
 
 	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
@@ -239,3 +240,47 @@ func (l *LogsConfigKeys) aggregationTimeout() time.Duration {
 func (l *LogsConfigKeys) useV2API() bool {
 	return l.getConfig().GetBool(l.getConfigKey("use_v2_api"))
 }
+
+func (l *LogsConfigKeys) diskQueuePath() string {
+	if path := l.getConfig().GetString(l.getConfigKey("disk_queue_path")); path != "" {
+		return path
+	}
+	return filepath.Join(l.getConfig().GetString(l.getConfigKey("run_path")), "disk_queue")
+}
+
+func (l *LogsConfigKeys) diskQueueMaxSizeInBytes() int64 {
+	return l.getConfig().GetInt64(l.getConfigKey("disk_queue_max_size_in_bytes"))
+}
+
+func (l *LogsConfigKeys) diskQueueMaxDiskRatio() float64 {
+	key := l.getConfigKey("disk_queue_max_disk_ratio")
+	ratio := l.getConfig().GetFloat64(key)
+	if ratio <= 0 || ratio > 1 {
+		log.Warnf("Invalid %s: %v should be in ]0, 1], fallback on %v", key, ratio, coreConfig.DefaultLogsDiskQueueMaxDiskRatio)
+		return coreConfig.DefaultLogsDiskQueueMaxDiskRatio
+	}
+	return ratio
+}
+
+func (l *LogsConfigKeys) diskQueueMaxAge() time.Duration {
+	return l.getConfig().GetDuration(l.getConfigKey("disk_queue_max_age"))
+}
+
+func (l *LogsConfigKeys) localDestination() string {
+	return l.getConfig().GetString(l.getConfigKey("local_destination"))
+}
+
+func (l *LogsConfigKeys) localDestinationPath() string {
+	if path := l.getConfig().GetString(l.getConfigKey("local_destination_path")); path != "" {
+		return path
+	}
+	return filepath.Join(l.getConfig().GetString(l.getConfigKey("run_path")), "logs.json")
+}
+
+func (l *LogsConfigKeys) localDestinationMaxSize() int64 {
+	return l.getConfig().GetInt64(l.getConfigKey("local_destination_max_size"))
+}
+
+func (l *LogsConfigKeys) localDestinationMaxFiles() int {
+	return l.getConfig().GetInt(l.getConfigKey("local_destination_max_files"))
+}
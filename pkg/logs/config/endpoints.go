@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // EPIntakeVersion is the events platform intake API version
@@ -32,6 +33,17 @@ const (
 	EPIntakeVersion2
 )
 
+const (
+	// EndpointTypeHTTP is the type of the routed endpoints receiving their logs over HTTP
+	EndpointTypeHTTP = "http"
+	// EndpointTypeTCP is the type of the routed endpoints receiving their logs over TCP
+	EndpointTypeTCP = "tcp"
+	// EndpointTypeFile is the type of the endpoints writing their logs to a local file
+	EndpointTypeFile = "file"
+	// EndpointTypeStdout is the type of the endpoints writing their logs to the standard output
+	EndpointTypeStdout = "stdout"
+)
+
 // Endpoint holds all the organization and network parameters to send logs to Datadog.
 type Endpoint struct {
 	APIKey                  string `mapstructure:"api_key" json:"api_key"`
@@ -54,10 +66,49 @@ type Endpoint struct {
 	TrackType IntakeTrackType
 	Protocol  IntakeProtocol
 	Origin    IntakeOrigin
+
+	// Type, Include and Exclude make an additional endpoint routed: it only
+	// receives the logs matching its filters, over the protocol of its type.
+	Type    string      `mapstructure:"type" json:"type"`
+	Include *LogsFilter `mapstructure:"include" json:"include"`
+	Exclude *LogsFilter `mapstructure:"exclude" json:"exclude"`
+	NoSSL   bool        `mapstructure:"no_ssl" json:"no_ssl"`
+
+	// Path, MaxSize and MaxFiles configure the file of the file endpoints,
+	// rotated when it reaches MaxSize bytes, MaxFiles rotated files being kept.
+	Path     string `mapstructure:"path" json:"path"`
+	MaxSize  int64  `mapstructure:"max_size" json:"max_size"`
+	MaxFiles int    `mapstructure:"max_files" json:"max_files"`
+}
+
+// IsLocal returns true if the endpoint writes its logs to a local file or to the standard output
+func (e *Endpoint) IsLocal() bool {
+	return e.Type == EndpointTypeFile || e.Type == EndpointTypeStdout
+}
+
+// IsRouted returns true if the endpoint only receives the logs matching its filters
+func (e *Endpoint) IsRouted() bool {
+	return e.Type != "" || e.Include != nil || e.Exclude != nil
+}
+
+// Accepts returns true if a routed endpoint receives the log: it matches its
+// include filter when set, and does not match its exclude filter when set.
+func (e *Endpoint) Accepts(source, service, status string, tags []string) bool {
+	if e.Include != nil && !e.Include.Match(source, service, status, tags) {
+		return false
+	}
+	return e.Exclude == nil || !e.Exclude.Match(source, service, status, tags)
 }
 
 // GetStatus returns the endpoint status
 func (e *Endpoint) GetStatus(prefix string, useHTTP bool) string {
+	switch e.Type {
+	case EndpointTypeFile:
+		return fmt.Sprintf("%sWriting logs to %s", prefix, e.Path)
+	case EndpointTypeStdout:
+		return fmt.Sprintf("%sWriting logs to the standard output", prefix)
+	}
+
 	compression := "uncompressed"
 	if e.UseCompression {
 		compression = "compressed"
@@ -110,7 +161,11 @@ func (e *Endpoints) GetStatus() []string {
 	result := make([]string, 0)
 	result = append(result, e.Main.GetStatus("", e.UseHTTP))
 	for _, additional := range e.Additionals {
-		result = append(result, additional.GetStatus("Additional: ", e.UseHTTP))
+		if additional.IsRouted() {
+			result = append(result, additional.GetStatus("Routed: ", additional.Type == EndpointTypeHTTP))
+		} else {
+			result = append(result, additional.GetStatus("Additional: ", e.UseHTTP))
+		}
 	}
 	return result
 }
@@ -148,7 +203,7 @@ func NewEndpointsWithBatchSettings(main Endpoint, additionals []Endpoint, usePro
 func (e *Endpoints) GetReliableAdditionals() []Endpoint {
 	endpoints := []Endpoint{}
 	for _, endpoint := range e.Additionals {
-		if endpoint.IsReliable {
+		if endpoint.IsReliable && !endpoint.IsRouted() {
 			endpoints = append(endpoints, endpoint)
 		}
 	}
@@ -159,9 +214,63 @@ func (e *Endpoints) GetReliableAdditionals() []Endpoint {
 func (e *Endpoints) GetUnReliableAdditionals() []Endpoint {
 	endpoints := []Endpoint{}
 	for _, endpoint := range e.Additionals {
-		if !endpoint.IsReliable {
+		if !endpoint.IsReliable && !endpoint.IsRouted() {
 			endpoints = append(endpoints, endpoint)
 		}
 	}
 	return endpoints
 }
+
+// GetRoutedAdditionals returns the additional endpoints receiving only the logs matching their filters.
+// As the unreliable ones, they do not guarantee logs are received in the event of an error.
+func (e *Endpoints) GetRoutedAdditionals() []Endpoint {
+	endpoints := []Endpoint{}
+	for _, endpoint := range e.Additionals {
+		if endpoint.IsRouted() {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// validateRoutedEndpoints sets the type of the routed endpoints to the protocol
+// of the main endpoint when not set, and drops the routed endpoints of an
+// unsupported type. The logs being encoded for the main endpoint, the HTTP
+// routed endpoints require the main endpoint to use HTTP. The file endpoints
+// rotate their file with the default settings of the local destination when not set.
+func validateRoutedEndpoints(additionals []Endpoint, useHTTP bool) []Endpoint {
+	var endpoints []Endpoint
+	for _, endpoint := range additionals {
+		if endpoint.IsRouted() {
+			switch endpoint.Type {
+			case "":
+				endpoint.Type = EndpointTypeTCP
+				if useHTTP {
+					endpoint.Type = EndpointTypeHTTP
+				}
+			case EndpointTypeTCP, EndpointTypeStdout:
+			case EndpointTypeFile:
+				if endpoint.Path == "" {
+					log.Warnf("Ignoring the additional endpoint of type file without path")
+					continue
+				}
+				if endpoint.MaxSize == 0 {
+					endpoint.MaxSize = config.DefaultLogsLocalDestinationMaxSize
+				}
+				if endpoint.MaxFiles == 0 {
+					endpoint.MaxFiles = config.DefaultLogsLocalDestinationMaxFiles
+				}
+			case EndpointTypeHTTP:
+				if !useHTTP {
+					log.Warnf("Ignoring the additional endpoint %s: the http type requires logs to be sent over HTTP", endpoint.Host)
+					continue
+				}
+			default:
+				log.Warnf("Ignoring the additional endpoint %s: unsupported type %q", endpoint.Host, endpoint.Type)
+				continue
+			}
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
@@ -415,6 +415,109 @@ func (suite *EndpointsTestSuite) TestAdditionalEndpointsMappedCorrectly() {
 	suite.Equal("2", endpoint.APIKey)
 }
 
+func (suite *EndpointsTestSuite) TestRoutedAdditionalEndpoints() {
+	suite.config.Set("logs_config.additional_endpoints", []map[string]interface{}{
+		{
+			"host":    "a",
+			"api_key": "1",
+		},
+		{
+			"host":   "siem",
+			"port":   10514,
+			"no_ssl": true,
+			"include": map[string]interface{}{
+				"sources": []string{"auditd", "security-*"},
+			},
+		},
+		{
+			"host": "b",
+			"type": "http",
+			"exclude": map[string]interface{}{
+				"statuses": []string{"debug"},
+			},
+		},
+		{
+			"host": "c",
+			"type": "unknown",
+		},
+	})
+
+	endpoints, err := BuildEndpoints(HTTPConnectivityFailure, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.Len(endpoints.Additionals, 2)
+	suite.Len(endpoints.GetUnReliableAdditionals(), 1)
+	suite.Len(endpoints.GetRoutedAdditionals(), 1)
+
+	endpoint := endpoints.GetRoutedAdditionals()[0]
+	suite.Equal("siem", endpoint.Host)
+	suite.Equal(10514, endpoint.Port)
+	suite.Equal(EndpointTypeTCP, endpoint.Type)
+	suite.False(endpoint.UseSSL)
+	suite.Equal([]string{"auditd", "security-*"}, endpoint.Include.Sources)
+	suite.Nil(endpoint.Exclude)
+	suite.True(endpoints.GetUnReliableAdditionals()[0].UseSSL)
+
+	suite.config.Set("logs_config.use_http", true)
+	endpoints, err = BuildEndpoints(HTTPConnectivityFailure, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.Len(endpoints.Additionals, 3)
+	suite.Len(endpoints.GetRoutedAdditionals(), 2)
+
+	endpoint = endpoints.GetRoutedAdditionals()[0]
+	suite.Equal(EndpointTypeHTTP, endpoint.Type)
+	endpoint = endpoints.GetRoutedAdditionals()[1]
+	suite.Equal("b", endpoint.Host)
+	suite.Equal(EndpointTypeHTTP, endpoint.Type)
+	suite.Equal([]string{"debug"}, endpoint.Exclude.Statuses)
+}
+
+func (suite *EndpointsTestSuite) TestLocalDestination() {
+	suite.config.Set("logs_config.run_path", "/opt/datadog-agent/run")
+	suite.config.Set("logs_config.local_destination", "file")
+	suite.config.Set("logs_config.additional_endpoints", []map[string]interface{}{
+		{"host": "a", "api_key": "1"},
+	})
+
+	endpoints, err := BuildEndpoints(HTTPConnectivitySuccess, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.True(endpoints.Main.IsLocal())
+	suite.Equal(EndpointTypeFile, endpoints.Main.Type)
+	suite.Equal("/opt/datadog-agent/run/logs.json", endpoints.Main.Path)
+	suite.Equal(int64(coreConfig.DefaultLogsLocalDestinationMaxSize), endpoints.Main.MaxSize)
+	suite.Equal(coreConfig.DefaultLogsLocalDestinationMaxFiles, endpoints.Main.MaxFiles)
+	suite.False(endpoints.UseHTTP)
+	suite.Len(endpoints.Additionals, 0)
+	suite.Equal([]string{"Writing logs to /opt/datadog-agent/run/logs.json"}, endpoints.GetStatus())
+
+	suite.config.Set("logs_config.local_destination", "stdout")
+	endpoints, err = BuildEndpoints(HTTPConnectivitySuccess, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.Equal(EndpointTypeStdout, endpoints.Main.Type)
+
+	suite.config.Set("logs_config.local_destination", "syslog")
+	_, err = BuildEndpoints(HTTPConnectivitySuccess, "test-track", "test-proto", "test-source")
+	suite.NotNil(err)
+}
+
+func (suite *EndpointsTestSuite) TestRoutedLocalAdditionalEndpoints() {
+	suite.config.Set("logs_config.additional_endpoints", []map[string]interface{}{
+		{"type": "file", "path": "/var/log/security.json", "max_files": 2},
+		{"type": "file"},
+		{"type": "stdout"},
+	})
+
+	endpoints, err := BuildEndpoints(HTTPConnectivityFailure, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.Len(endpoints.GetRoutedAdditionals(), 2)
+
+	endpoint := endpoints.GetRoutedAdditionals()[0]
+	suite.Equal("/var/log/security.json", endpoint.Path)
+	suite.Equal(int64(coreConfig.DefaultLogsLocalDestinationMaxSize), endpoint.MaxSize)
+	suite.Equal(2, endpoint.MaxFiles)
+	suite.Equal(EndpointTypeStdout, endpoints.GetRoutedAdditionals()[1].Type)
+	suite.Equal("Routed: Writing logs to /var/log/security.json", endpoints.GetStatus()[1])
+}
+
 func TestEndpointsTestSuite(t *testing.T) {
 	suite.Run(t, new(EndpointsTestSuite))
 }
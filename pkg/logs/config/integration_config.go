@@ -25,6 +25,19 @@ const (
 	UTF16BE string = "utf-16-be"
 	// UTF16LE for UTF-16 Little Endian encoding
 	UTF16LE string = "utf-16-le"
+	// UTF8 for UTF-8 encoding, the default, with the byte order mark being stripped
+	UTF8 string = "utf-8"
+	// ShiftJIS for Shift JIS encoding
+	ShiftJIS string = "shift-jis"
+	// Latin1 for ISO 8859-1 encoding
+	Latin1 string = "latin-1"
+	// Windows1252 for Windows-1252 encoding
+	Windows1252 string = "windows-1252"
+	// GB18030 for GB 18030 encoding
+	GB18030 string = "gb18030"
+
+	// SyslogFormat for network sources receiving RFC 5424 or RFC 3164 syslog messages
+	SyslogFormat string = "syslog"
 )
 
 // LogsConfig represents a log source config, which can be for instance
@@ -34,15 +47,25 @@ type LogsConfig struct {
 
 	Port        int    // Network
 	IdleTimeout string `mapstructure:"idle_timeout" json:"idle_timeout"` // Network
+	Format      string `mapstructure:"format" json:"format"`             // Network
 	Path        string // File, Journald
 
 	Encoding     string   `mapstructure:"encoding" json:"encoding"`             // File
 	ExcludePaths []string `mapstructure:"exclude_paths" json:"exclude_paths"`   // File
 	TailingMode  string   `mapstructure:"start_position" json:"start_position"` // File
+	// TailRotatedFiles reads once the rotated siblings of the files, e.g.
+	// "app.log.1" or "app.log.2.gz", rotated while the agent was stopped.
+	TailRotatedFiles bool `mapstructure:"tail_rotated_files" json:"tail_rotated_files"` // File
 
 	IncludeUnits  []string `mapstructure:"include_units" json:"include_units"`   // Journald
 	ExcludeUnits  []string `mapstructure:"exclude_units" json:"exclude_units"`   // Journald
 	ContainerMode bool     `mapstructure:"container_mode" json:"container_mode"` // Journald
+	// IncludeMatches and ExcludeMatches filter the journal entries on their
+	// fields with FIELD=VALUE expressions, e.g. "PRIORITY=[0-3]" or "_COMM=ssh*".
+	IncludeMatches []string `mapstructure:"include_matches" json:"include_matches"` // Journald
+	ExcludeMatches []string `mapstructure:"exclude_matches" json:"exclude_matches"` // Journald
+	// FieldTags adds the values of journal fields as tags, with FIELD or FIELD:TAG_NAME expressions.
+	FieldTags []string `mapstructure:"field_tags" json:"field_tags"` // Journald
 
 	Image string // Docker
 	Label string // Docker
@@ -131,6 +154,17 @@ func (c *LogsConfig) Validate() error {
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
 	}
+	if c.Format != "" {
+		if c.Type != TCPType && c.Type != UDPType {
+			return fmt.Errorf("format is only supported by tcp and udp sources")
+		}
+		if c.Format != SyslogFormat {
+			return fmt.Errorf("invalid format '%v', the only supported format is '%v'", c.Format, SyslogFormat)
+		}
+	}
+	if c.TailRotatedFiles && c.Type != FileType {
+		return fmt.Errorf("tail_rotated_files is only supported by file sources")
+	}
 	err := ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
 		return err
@@ -16,8 +16,11 @@ import (
 func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 	validConfigs := []*LogsConfig{
 		{Type: FileType, Path: "/var/log/foo.log"},
+		{Type: FileType, Path: "/var/log/foo.log", TailRotatedFiles: true},
 		{Type: TCPType, Port: 1234},
 		{Type: UDPType, Port: 5678},
+		{Type: TCPType, Port: 1234, Format: SyslogFormat},
+		{Type: UDPType, Port: 5678, Format: SyslogFormat},
 		{Type: DockerType},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
 		{Type: SnmpTrapsType},
@@ -35,6 +38,9 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: FileType},
 		{Type: TCPType},
 		{Type: UDPType},
+		{Type: TCPType, Port: 1234, Format: "gelf"},
+		{Type: FileType, Path: "/var/log/foo.log", Format: SyslogFormat},
+		{Type: JournaldType, TailRotatedFiles: true},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: "bar"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch}}},
@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"path"
+)
+
+// LogsFilter matches the logs on their source, service, status and tags. A log
+// matches the filter when it matches one of the values of each field that is
+// set, the values being glob patterns, e.g. "security-*" or "team:*".
+type LogsFilter struct {
+	Sources  []string `mapstructure:"sources" json:"sources"`
+	Services []string `mapstructure:"services" json:"services"`
+	Statuses []string `mapstructure:"statuses" json:"statuses"`
+	Tags     []string `mapstructure:"tags" json:"tags"`
+}
+
+// Match returns true if the log matches the filter, the tags matching when one
+// of them matches one of the tag patterns.
+func (f *LogsFilter) Match(source, service, status string, tags []string) bool {
+	if !matchAnyPattern(f.Sources, source) || !matchAnyPattern(f.Services, service) || !matchAnyPattern(f.Statuses, status) {
+		return false
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if matchAnyPattern(f.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyPattern returns true if there are no patterns or if one of them
+// matches the value, the malformed patterns never matching.
+func matchAnyPattern(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogsFilterMatch(t *testing.T) {
+	filter := &LogsFilter{}
+	assert.True(t, filter.Match("nginx", "web", "info", nil))
+
+	filter = &LogsFilter{Sources: []string{"auditd", "security-*"}}
+	assert.True(t, filter.Match("auditd", "", "info", nil))
+	assert.True(t, filter.Match("security-scanner", "", "info", nil))
+	assert.False(t, filter.Match("nginx", "", "info", nil))
+
+	filter = &LogsFilter{Services: []string{"web"}, Statuses: []string{"error", "critical"}}
+	assert.True(t, filter.Match("nginx", "web", "error", nil))
+	assert.False(t, filter.Match("nginx", "web", "info", nil))
+	assert.False(t, filter.Match("nginx", "api", "error", nil))
+
+	filter = &LogsFilter{Tags: []string{"team:security", "env:prod*"}}
+	assert.True(t, filter.Match("", "", "info", []string{"env:production"}))
+	assert.True(t, filter.Match("", "", "info", []string{"team:web", "team:security"}))
+	assert.False(t, filter.Match("", "", "info", []string{"team:web"}))
+	assert.False(t, filter.Match("", "", "info", nil))
+
+	// the malformed patterns never match
+	filter = &LogsFilter{Sources: []string{"[nginx"}}
+	assert.False(t, filter.Match("[nginx", "", "info", nil))
+}
+
+func TestEndpointAccepts(t *testing.T) {
+	endpoint := Endpoint{}
+	assert.False(t, endpoint.IsRouted())
+	assert.True(t, endpoint.Accepts("nginx", "web", "info", nil))
+
+	endpoint = Endpoint{
+		Include: &LogsFilter{Sources: []string{"auditd"}},
+		Exclude: &LogsFilter{Statuses: []string{"debug"}},
+	}
+	assert.True(t, endpoint.IsRouted())
+	assert.True(t, endpoint.Accepts("auditd", "", "info", nil))
+	assert.False(t, endpoint.Accepts("auditd", "", "debug", nil))
+	assert.False(t, endpoint.Accepts("nginx", "", "info", nil))
+
+	endpoint = Endpoint{Exclude: &LogsFilter{Tags: []string{"team:security"}}}
+	assert.True(t, endpoint.Accepts("nginx", "", "info", []string{"team:web"}))
+	assert.False(t, endpoint.Accepts("nginx", "", "info", []string{"team:security"}))
+}
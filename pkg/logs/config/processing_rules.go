@@ -16,6 +16,25 @@ const (
 	IncludeAtMatch = "include_at_match"
 	MaskSequences  = "mask_sequences"
 	MultiLine      = "multi_line"
+
+	// Structured processing rules, parsing the content into attributes
+	ParseJSONContent = "parse_json"
+	ParseLogfmt      = "parse_logfmt"
+	ParseKeyValue    = "parse_key_value"
+	ExtractTags      = "extract_tags"
+	RemapStatus      = "remap_status"
+	ExtractTimestamp = "extract_timestamp"
+
+	// Sampling processing rules, dropping a part of the logs matching the pattern
+	Sample    = "sample"
+	RateLimit = "rate_limit"
+)
+
+// Timestamp formats of the extract_timestamp rules, any other value being a Go time layout
+const (
+	TimestampFormatRFC3339 = "rfc3339"
+	TimestampFormatUnix    = "unix"
+	TimestampFormatUnixMs  = "unix_ms"
 )
 
 // ProcessingRule defines an exclusion or a masking rule to
@@ -25,16 +44,28 @@ type ProcessingRule struct {
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
 	Pattern            string
+	// Attribute is the parsed attribute, e.g. "http.status_code", the rule
+	// applies to instead of the whole content.
+	Attribute         string  `mapstructure:"attribute" json:"attribute"`
+	KeyValueSeparator string  `mapstructure:"key_value_separator" json:"key_value_separator"` // parse_key_value
+	PairSeparator     string  `mapstructure:"pair_separator" json:"pair_separator"`           // parse_key_value
+	TimestampFormat   string  `mapstructure:"timestamp_format" json:"timestamp_format"`       // extract_timestamp
+	KeepOneIn         int     `mapstructure:"keep_one_in" json:"keep_one_in"`                 // sample
+	KeepPercentage    float64 `mapstructure:"keep_percentage" json:"keep_percentage"`         // sample
+	LinesPerSecond    float64 `mapstructure:"lines_per_second" json:"lines_per_second"`       // rate_limit
 	// TODO: should be moved out
 	Regex       *regexp.Regexp
 	Placeholder []byte
+	Sampler     *RuleSampler
 }
 
 // ValidateProcessingRules validates the rules and raises an error if one is misconfigured.
 // Each processing rule must have:
 // - a valid name
 // - a valid type
-// - a valid pattern that compiles
+// - a valid pattern that compiles, for the rules matching the content
+// - an attribute, for the rules reading a parsed attribute
+// - a rate, for the sampling rules
 func ValidateProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
 		if rule.Name == "" {
@@ -42,8 +73,23 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		}
 
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
+		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine, ExtractTags:
 			break
+		case ParseJSONContent, ParseLogfmt, ParseKeyValue:
+			continue
+		case RemapStatus, ExtractTimestamp:
+			if rule.Attribute == "" {
+				return fmt.Errorf("no attribute provided for processing rule: %s", rule.Name)
+			}
+			continue
+		case Sample, RateLimit:
+			if err := validateSamplingRule(rule); err != nil {
+				return err
+			}
+			// the pattern is optional, every log being sampled without it
+			if rule.Pattern == "" {
+				continue
+			}
 		case "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
 		default:
@@ -53,23 +99,76 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		if rule.Pattern == "" {
 			return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
 		}
-		_, err := regexp.Compile(rule.Pattern)
+		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
 		}
+		if rule.Type == ExtractTags && !hasNamedGroup(re) {
+			return fmt.Errorf("the pattern %s of processing rule %s has no named capture group", rule.Pattern, rule.Name)
+		}
 	}
 	return nil
 }
 
+func validateSamplingRule(rule *ProcessingRule) error {
+	if rule.Type == RateLimit {
+		if rule.LinesPerSecond <= 0 {
+			return fmt.Errorf("lines_per_second must be positive for processing rule: %s", rule.Name)
+		}
+		return nil
+	}
+	switch {
+	case rule.KeepOneIn != 0 && rule.KeepPercentage != 0:
+		return fmt.Errorf("keep_one_in and keep_percentage cannot be both set for processing rule: %s", rule.Name)
+	case rule.KeepOneIn < 0:
+		return fmt.Errorf("keep_one_in must be positive for processing rule: %s", rule.Name)
+	case rule.KeepOneIn == 0 && (rule.KeepPercentage <= 0 || rule.KeepPercentage > 100):
+		return fmt.Errorf("keep_one_in or keep_percentage between 0 and 100 must be set for processing rule: %s", rule.Name)
+	}
+	return nil
+}
+
+func hasNamedGroup(re *regexp.Regexp) bool {
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // CompileProcessingRules compiles all processing rule regular expressions.
 func CompileProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
+		switch rule.Type {
+		case ParseKeyValue:
+			if rule.KeyValueSeparator == "" {
+				rule.KeyValueSeparator = "="
+			}
+			if rule.PairSeparator == "" {
+				rule.PairSeparator = " "
+			}
+			continue
+		case ParseJSONContent, ParseLogfmt, RemapStatus, ExtractTimestamp:
+			continue
+		case Sample:
+			if rule.KeepOneIn > 0 {
+				rule.Sampler = NewSampleRuleSampler(1 / float64(rule.KeepOneIn))
+			} else {
+				rule.Sampler = NewSampleRuleSampler(rule.KeepPercentage / 100)
+			}
+		case RateLimit:
+			rule.Sampler = NewRateLimitRuleSampler(rule.LinesPerSecond)
+		}
+		if rule.Sampler != nil && rule.Pattern == "" {
+			continue
+		}
 		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return err
 		}
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch:
+		case ExcludeAtMatch, IncludeAtMatch, ExtractTags, Sample, RateLimit:
 			rule.Regex = re
 		case MaskSequences:
 			rule.Regex = re
@@ -33,3 +33,59 @@ func TestCompileShouldFailWithInvalidRules(t *testing.T) {
 		assert.Nil(t, rule.Regex)
 	}
 }
+
+func TestValidateStructuredRules(t *testing.T) {
+	validRules := []*ProcessingRule{
+		{Name: "json", Type: ParseJSONContent},
+		{Name: "logfmt", Type: ParseLogfmt},
+		{Name: "kv", Type: ParseKeyValue},
+		{Name: "tags", Type: ExtractTags, Pattern: `user=(?P<user>\w+)`},
+		{Name: "status", Type: RemapStatus, Attribute: "level"},
+		{Name: "timestamp", Type: ExtractTimestamp, Attribute: "ts", TimestampFormat: TimestampFormatUnixMs},
+		{Name: "exclude", Type: ExcludeAtMatch, Attribute: "http.path", Pattern: "^/health$"},
+	}
+	assert.Nil(t, ValidateProcessingRules(validRules))
+	assert.Nil(t, CompileProcessingRules(validRules))
+	assert.Equal(t, "=", validRules[2].KeyValueSeparator)
+	assert.Equal(t, " ", validRules[2].PairSeparator)
+	assert.NotNil(t, validRules[3].Regex)
+
+	invalidRules := []*ProcessingRule{
+		{Name: "tags", Type: ExtractTags},
+		{Name: "tags", Type: ExtractTags, Pattern: `user=(\w+)`},
+		{Name: "status", Type: RemapStatus},
+		{Name: "timestamp", Type: ExtractTimestamp},
+	}
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}))
+	}
+}
+
+func TestValidateSamplingRules(t *testing.T) {
+	validRules := []*ProcessingRule{
+		{Name: "sample", Type: Sample, KeepOneIn: 10},
+		{Name: "sample_percentage", Type: Sample, KeepPercentage: 2.5, Pattern: `GET (/\w+)`},
+		{Name: "rate_limit", Type: RateLimit, LinesPerSecond: 100},
+		{Name: "rate_limit_status", Type: RateLimit, LinesPerSecond: 0.5, Attribute: "status", Pattern: "^5"},
+	}
+	assert.Nil(t, ValidateProcessingRules(validRules))
+	assert.Nil(t, CompileProcessingRules(validRules))
+	for _, rule := range validRules {
+		assert.NotNil(t, rule.Sampler)
+	}
+	assert.Nil(t, validRules[0].Regex)
+	assert.NotNil(t, validRules[1].Regex)
+
+	invalidRules := []*ProcessingRule{
+		{Name: "sample", Type: Sample},
+		{Name: "sample", Type: Sample, KeepOneIn: -1},
+		{Name: "sample", Type: Sample, KeepPercentage: 120},
+		{Name: "sample", Type: Sample, KeepOneIn: 10, KeepPercentage: 10},
+		{Name: "sample", Type: Sample, KeepOneIn: 10, Pattern: "(?=abf)"},
+		{Name: "rate_limit", Type: RateLimit},
+		{Name: "rate_limit", Type: RateLimit, LinesPerSecond: -1},
+	}
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}))
+	}
+}
@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// maxRuleSamplerKeys bounds the number of keys a sampler keeps a state for,
+// the lines of the keys above sharing the same state.
+const maxRuleSamplerKeys = 1024
+
+// overflowRuleSamplerKey is the key of the lines above maxRuleSamplerKeys
+const overflowRuleSamplerKey = "\x00overflow"
+
+// creditEpsilon absorbs the rounding errors of the credits, e.g. 3 times 1/3
+const creditEpsilon = 1e-9
+
+// RuleSampler keeps the state of a sample or a rate_limit rule per key. The
+// rules being shared by all the pipelines, it is safe for concurrent use.
+type RuleSampler struct {
+	// keepRate is the ratio of lines kept by a sample rule
+	keepRate float64
+	// linesPerSecond is the number of lines kept per second by a rate_limit rule
+	linesPerSecond float64
+	burst          float64
+	mu             sync.Mutex
+	states         map[string]*ruleSamplerState
+}
+
+// ruleSamplerState holds the credit of lines a key can still keep
+type ruleSamplerState struct {
+	credit  float64
+	updated time.Time
+}
+
+// NewSampleRuleSampler returns a sampler keeping the given ratio of the lines of each key
+func NewSampleRuleSampler(keepRate float64) *RuleSampler {
+	return &RuleSampler{
+		keepRate: keepRate,
+		states:   make(map[string]*ruleSamplerState),
+	}
+}
+
+// NewRateLimitRuleSampler returns a sampler keeping up to linesPerSecond lines per second
+// of each key, allowing bursts of one second worth of lines.
+func NewRateLimitRuleSampler(linesPerSecond float64) *RuleSampler {
+	return &RuleSampler{
+		linesPerSecond: linesPerSecond,
+		burst:          math.Max(1, linesPerSecond),
+		states:         make(map[string]*ruleSamplerState),
+	}
+}
+
+// Keep returns true if the line of the given key should be kept. The sample
+// rules keep the first line of each key then one every 1/keepRate lines, the
+// rate_limit rules keep the lines until the token bucket of the key is empty.
+func (s *RuleSampler) Keep(key string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.state(key, now)
+	if s.linesPerSecond > 0 {
+		state.credit = math.Min(s.burst, state.credit+now.Sub(state.updated).Seconds()*s.linesPerSecond)
+	} else {
+		state.credit += s.keepRate
+	}
+	state.updated = now
+
+	if state.credit < 1-creditEpsilon {
+		return false
+	}
+	state.credit = math.Max(0, state.credit-1)
+	return true
+}
+
+// state returns the state of a key, creating it with the credit to keep its first line
+func (s *RuleSampler) state(key string, now time.Time) *ruleSamplerState {
+	if state, found := s.states[key]; found {
+		return state
+	}
+	if len(s.states) >= maxRuleSamplerKeys {
+		s.expire(now)
+		if len(s.states) >= maxRuleSamplerKeys {
+			key = overflowRuleSamplerKey
+			if state, found := s.states[key]; found {
+				return state
+			}
+		}
+	}
+	state := &ruleSamplerState{updated: now}
+	if s.linesPerSecond > 0 {
+		state.credit = s.burst
+	} else {
+		state.credit = 1 - s.keepRate
+	}
+	s.states[key] = state
+	return state
+}
+
+// expire removes the states of the keys that would be created again identical,
+// the token buckets refilled since their last line.
+func (s *RuleSampler) expire(now time.Time) {
+	if s.linesPerSecond <= 0 {
+		return
+	}
+	for key, state := range s.states {
+		if state.credit+now.Sub(state.updated).Seconds()*s.linesPerSecond >= s.burst {
+			delete(s.states, key)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func keptLines(sampler *RuleSampler, key string, lines int, now time.Time) int {
+	kept := 0
+	for i := 0; i < lines; i++ {
+		if sampler.Keep(key, now) {
+			kept++
+		}
+	}
+	return kept
+}
+
+func TestSampleRuleSampler(t *testing.T) {
+	now := time.Now()
+
+	sampler := NewSampleRuleSampler(1.0 / 3)
+	var kept []bool
+	for i := 0; i < 6; i++ {
+		kept = append(kept, sampler.Keep("a", now))
+	}
+	assert.Equal(t, []bool{true, false, false, true, false, false}, kept)
+	// each key keeps its own rate
+	assert.True(t, sampler.Keep("b", now))
+	assert.True(t, sampler.Keep("a", now))
+
+	sampler = NewSampleRuleSampler(0.25)
+	assert.Equal(t, 250, keptLines(sampler, "a", 1000, now))
+
+	sampler = NewSampleRuleSampler(1)
+	assert.Equal(t, 10, keptLines(sampler, "a", 10, now))
+}
+
+func TestRateLimitRuleSampler(t *testing.T) {
+	now := time.Now()
+
+	sampler := NewRateLimitRuleSampler(10)
+	assert.Equal(t, 10, keptLines(sampler, "a", 100, now))
+	assert.Equal(t, 10, keptLines(sampler, "b", 100, now))
+
+	// the bucket is refilled over time, up to one second worth of lines
+	assert.Equal(t, 5, keptLines(sampler, "a", 100, now.Add(500*time.Millisecond)))
+	assert.Equal(t, 10, keptLines(sampler, "a", 100, now.Add(time.Minute)))
+
+	sampler = NewRateLimitRuleSampler(0.5)
+	assert.True(t, sampler.Keep("a", now))
+	assert.False(t, sampler.Keep("a", now.Add(time.Second)))
+	assert.True(t, sampler.Keep("a", now.Add(2*time.Second)))
+}
+
+func TestRuleSamplerMaxKeys(t *testing.T) {
+	now := time.Now()
+
+	sampler := NewRateLimitRuleSampler(1)
+	for i := 0; i < maxRuleSamplerKeys; i++ {
+		assert.True(t, sampler.Keep(fmt.Sprint(i), now))
+	}
+	// the keys above the limit share the same state
+	assert.True(t, sampler.Keep("new", now))
+	assert.False(t, sampler.Keep("other", now))
+	assert.Len(t, sampler.states, maxRuleSamplerKeys+1)
+
+	// the idle keys are expired to make room for the new ones
+	assert.True(t, sampler.Keep("other", now.Add(time.Minute)))
+	assert.Len(t, sampler.states, 1)
+}
@@ -58,6 +58,12 @@ type AutoMultilineHandler struct {
 	source            *config.LogSource
 	timeoutTimer      *time.Timer
 	detectedPattern   *DetectedPattern
+	// stackTraceLines is the number of sampled lines looking like stack trace lines,
+	// they are not taken into account in the match ratio since they never start with a timestamp.
+	stackTraceLines int
+	// onDetection is called with the outcome of the detection, the pattern being nil
+	// if no pattern met the threshold, when all the lines to assess were tested.
+	onDetection func(pattern *regexp.Regexp, matchRatio float64, linesTested int)
 }
 
 // NewAutoMultilineHandler returns a new AutoMultilineHandler.
@@ -135,9 +141,11 @@ func (h *AutoMultilineHandler) processAndTry(message *Message) {
 	// Process message before anything else
 	h.singleLineHandler.process(message)
 
+	matched := false
 	for i, scoredPattern := range h.scoredMatches {
 		match := scoredPattern.regexp.Match(message.Content)
 		if match {
+			matched = true
 			scoredPattern.score++
 
 			// By keeping the scored matches sorted, the best match always comes first. Since we expect one timestamp to match overwhelmingly
@@ -161,23 +169,41 @@ func (h *AutoMultilineHandler) processAndTry(message *Message) {
 		break
 	}
 
+	if !matched && isStackTraceLine(message.Content) {
+		h.stackTraceLines++
+	}
+
 	h.linesTested++
 	if h.linesTested >= h.linesToAssess || timeout {
 		topMatch := h.scoredMatches[0]
-		matchRatio := float64(topMatch.score) / float64(h.linesTested)
+		matchRatio := 0.0
+		if h.linesTested > h.stackTraceLines {
+			matchRatio = float64(topMatch.score) / float64(h.linesTested-h.stackTraceLines)
+		}
 
 		if matchRatio >= h.matchThreshold {
 			log.Debugf("Pattern %v matched %d lines with a ratio of %f", topMatch.regexp.String(), topMatch.score, matchRatio)
 			h.detectedPattern.Set(topMatch.regexp)
+			h.notifyDetection(topMatch.regexp, matchRatio, timeout)
 			h.switchToMultilineHandler(topMatch.regexp)
 		} else {
 			log.Debug("No pattern met the line match threshold during multiline autosensing - using single line handler")
+			h.notifyDetection(nil, matchRatio, timeout)
 			// Stay with the single line handler and no longer attempt to detect multiline matches.
 			h.processsingFunc = h.singleLineHandler.process
 		}
 	}
 }
 
+// notifyDetection reports the outcome of the detection, unless the detection
+// timed out as a partial sample is not representative enough to be reused.
+func (h *AutoMultilineHandler) notifyDetection(pattern *regexp.Regexp, matchRatio float64, timeout bool) {
+	if h.onDetection == nil || timeout {
+		return
+	}
+	h.onDetection(pattern, matchRatio, h.linesTested)
+}
+
 func (h *AutoMultilineHandler) switchToMultilineHandler(r *regexp.Regexp) {
 	h.isRunning = false
 	h.singleLineHandler = nil
@@ -189,12 +215,38 @@ func (h *AutoMultilineHandler) switchToMultilineHandler(r *regexp.Regexp) {
 	// At this point control is handed over to the multiline handler and the AutoMultilineHandler read loop has stopped.
 }
 
+// isStackTraceLine returns true if the line looks like a line of a Java or Go
+// stack trace, which follows the line holding the timestamp of a log.
+func isStackTraceLine(content []byte) bool {
+	for _, r := range stackTraceLines {
+		if r.Match(content) {
+			return true
+		}
+	}
+	return false
+}
+
+var stackTraceLines = []*regexp.Regexp{
+	// Java frames, e.g. "	at com.example.Main.main(Main.java:12)"
+	regexp.MustCompile(`^\s*at [\w$.<>/]+\(.*\)\s*$`),
+	// Java elided frames, e.g. "	... 12 more"
+	regexp.MustCompile(`^\s*\.\.\. \d+ (more|common frames omitted)`),
+	// Java causes and exceptions, e.g. "Caused by: java.lang.Exception: boom"
+	regexp.MustCompile(`^(Caused by: |Suppressed: |\s*([\w$]+\.)+[\w$]*(Exception|Error)(: |$))`),
+	// Go goroutine headers, e.g. "goroutine 1 [running]:"
+	regexp.MustCompile(`^goroutine \d+ \[.*\]:$`),
+	// Go source lines, e.g. "	/usr/local/go/src/runtime/proc.go:225 +0x256"
+	regexp.MustCompile(`^\s*\S+\.go:\d+( \+0x[0-9a-f]+)?$`),
+	// Go function lines, e.g. "main.(*server).run(0xc000010000, 0x1)"
+	regexp.MustCompile(`^[\w./-]+(\.\(\*?[\w]+\))?\.[\w]+\(.*\)$`),
+}
+
 // Originally referenced from https://github.com/egnyte/ax/blob/master/pkg/heuristic/timestamp.go
 // All line matching rules must only match the beginning of a line, so when adding new expressions
 // make sure to prepend it with `^`
 var formatsToTry = []*regexp.Regexp{
-	// time.RFC3339,
-	regexp.MustCompile(`^\d+-\d+-\d+T\d+:\d+:\d+(\.\d+)?(Z\d*:?\d*)?`),
+	// time.RFC3339, with a Z or an offset
+	regexp.MustCompile(`^\d+-\d+-\d+T\d+:\d+:\d+(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`),
 	// time.ANSIC,
 	regexp.MustCompile(`^[A-Za-z_]+ [A-Za-z_]+ +\d+ \d+:\d+:\d+ \d+`),
 	// time.UnixDate,
@@ -213,8 +265,20 @@ var formatsToTry = []*regexp.Regexp{
 	regexp.MustCompile(`^[A-Za-z_]+, \d+ [A-Za-z_]+ \d+ \d+:\d+:\d+ -\d+`),
 	// time.RFC3339Nano,
 	regexp.MustCompile(`^\d+-\d+-\d+[A-Za-z_]+\d+:\d+:\d+\.\d+[A-Za-z_]+\d+:\d+`),
+	// ISO 8601 with a space separator and an offset, 2021-07-08 05:08:19.214+02:00
+	regexp.MustCompile(`^\d+-\d+-\d+ \d+:\d+:\d+(\.\d+)?( ?[+-]\d{2}:?\d{2}|Z)`),
 	// 2021-07-08 05:08:19,214
 	regexp.MustCompile(`^\d+-\d+-\d+ \d+:\d+:\d+(,\d+)?`),
 	// Default java logging SimpleFormatter date format
 	regexp.MustCompile(`^[A-Za-z_]+ \d+, \d+ \d+:\d+:\d+ (AM|PM)`),
+	// Go log package default format, 2021/07/08 05:08:19
+	regexp.MustCompile(`^\d+/\d+/\d+ \d+:\d+:\d+`),
+	// RFC 3164 syslog, <34>Oct 11 22:14:15 or Oct 11 22:14:15
+	regexp.MustCompile(`^(<\d+>)?[A-Za-z_]+ +\d+ \d+:\d+:\d+`),
+	// RFC 5424 syslog, <34>1 2003-10-11T22:14:15.003Z
+	regexp.MustCompile(`^<\d+>\d+ \d+-\d+-\d+T\d+:\d+:\d+`),
+	// epoch in milliseconds, 1625720899214
+	regexp.MustCompile(`^\d{13}\b`),
+	// epoch in seconds with a fractional part, 1625720899.214
+	regexp.MustCompile(`^\d{10}\.\d+\b`),
 }
@@ -131,6 +131,8 @@ func NewDecoderWithEndLineMatcher(source *config.LogSource, parser parser.Parser
 				detectedPattern.Set(multiLinePattern)
 
 				lineHandler = NewMultiLineHandler(outputChan, multiLinePattern, config.AggregationTimeout(), lineLimit)
+			} else if learnedHandler := buildLearnedLineHandler(outputChan, lineLimit, source, detectedPattern); learnedHandler != nil {
+				lineHandler = learnedHandler
 			} else {
 				lineHandler = buildAutoMultilineHandlerFromConfig(outputChan, lineLimit, source, detectedPattern)
 			}
@@ -170,7 +172,7 @@ func buildAutoMultilineHandlerFromConfig(outputChan chan *Message, lineLimit int
 	}
 
 	matchTimeout := time.Second * dd_conf.Datadog.GetDuration("logs_config.auto_multi_line_default_match_timeout")
-	return NewAutoMultilineHandler(outputChan,
+	h := NewAutoMultilineHandler(outputChan,
 		lineLimit,
 		linesToSample,
 		matchThreshold,
@@ -179,6 +181,52 @@ func buildAutoMultilineHandlerFromConfig(outputChan chan *Message, lineLimit int
 		source,
 		additionalPatternsCompiled,
 		detectedPattern)
+
+	if persistLearnedPatterns(source) {
+		h.onDetection = func(pattern *regexp.Regexp, matchRatio float64, linesTested int) {
+			learned := LearnedPattern{
+				Confidence:  matchRatio,
+				LinesTested: linesTested,
+				LastUpdated: time.Now().UTC(),
+			}
+			if pattern != nil {
+				learned.Pattern = pattern.String()
+			}
+			if err := WriteLearnedPattern(source.Name, learned); err != nil {
+				log.Warnf("Could not store the multiline pattern learned for %s: %v", source.Name, err)
+			}
+		}
+	}
+	return h
+}
+
+// persistLearnedPatterns returns true if the outcome of the detection is stored for the source
+func persistLearnedPatterns(source *config.LogSource) bool {
+	return source.Name != "" && dd_conf.Datadog.GetBool("logs_config.auto_multi_line_persist_learned_patterns")
+}
+
+// buildLearnedLineHandler returns a line handler using the outcome of a previous
+// detection for the source, or nil if the detection must run.
+func buildLearnedLineHandler(outputChan chan *Message, lineLimit int, source *config.LogSource, detectedPattern *DetectedPattern) LineHandler {
+	if !persistLearnedPatterns(source) {
+		return nil
+	}
+	learned, found := getLearnedPattern(source.Name)
+	if !found {
+		return nil
+	}
+	pattern, err := learned.compile()
+	if err != nil {
+		log.Warnf("Invalid multiline pattern stored for %s, running the detection again: %v", source.Name, err)
+		return nil
+	}
+	if pattern == nil {
+		log.Infof("Using the single line handler learned for %s", source.Name)
+		return NewSingleLineHandler(outputChan, lineLimit)
+	}
+	log.Infof("Using the multiline pattern %v learned for %s with a confidence of %f", pattern, source.Name, learned.Confidence)
+	detectedPattern.Set(pattern)
+	return NewMultiLineHandler(outputChan, pattern, config.AggregationTimeout(), lineLimit)
 }
 
 // New returns an initialized Decoder
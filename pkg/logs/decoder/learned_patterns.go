@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package decoder
+
+import (
+	"encoding/json"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/persistentcache"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// learnedPatternsCacheKey is the key of the persistent cache entry holding the learned patterns
+const learnedPatternsCacheKey = "logs:multiline_patterns"
+
+// learnedPatternsMutex serializes the updates of the learned patterns done by the decoders
+var learnedPatternsMutex sync.Mutex
+
+// LearnedPattern is the outcome of the multiline auto detection of a source,
+// persisted so that the detection is not run again after a restart.
+type LearnedPattern struct {
+	// Pattern is the regular expression matching the beginning of the logs,
+	// empty if the logs of the source were detected as single line logs.
+	Pattern string `json:"pattern"`
+	// Confidence is the ratio of the sampled lines matching the pattern,
+	// the stack trace lines being ignored.
+	Confidence  float64   `json:"confidence"`
+	LinesTested int       `json:"lines_tested"`
+	LastUpdated time.Time `json:"last_updated"`
+	// Override is true if the pattern was set by the user instead of being learned
+	Override bool `json:"override"`
+}
+
+// ReadLearnedPatterns returns the learned patterns by source name
+func ReadLearnedPatterns() (map[string]LearnedPattern, error) {
+	learnedPatternsMutex.Lock()
+	defer learnedPatternsMutex.Unlock()
+	return readLearnedPatterns()
+}
+
+// WriteLearnedPattern stores the pattern of a source, overriding any pattern
+// already learned for it.
+func WriteLearnedPattern(source string, pattern LearnedPattern) error {
+	if _, err := pattern.compile(); err != nil {
+		return err
+	}
+	return updateLearnedPatterns(func(patterns map[string]LearnedPattern) {
+		patterns[source] = pattern
+	})
+}
+
+// RemoveLearnedPatterns removes the patterns of the sources, or all the
+// patterns if no source is given, the detection running again for them.
+func RemoveLearnedPatterns(sources ...string) error {
+	return updateLearnedPatterns(func(patterns map[string]LearnedPattern) {
+		if len(sources) == 0 {
+			for source := range patterns {
+				delete(patterns, source)
+			}
+		}
+		for _, source := range sources {
+			delete(patterns, source)
+		}
+	})
+}
+
+// getLearnedPattern returns the pattern stored for a source, if any
+func getLearnedPattern(source string) (LearnedPattern, bool) {
+	patterns, err := ReadLearnedPatterns()
+	if err != nil {
+		log.Warnf("Could not read the learned multiline patterns: %v", err)
+		return LearnedPattern{}, false
+	}
+	pattern, exists := patterns[source]
+	return pattern, exists
+}
+
+// compile returns the regular expression matching the beginning of the logs,
+// or nil if the logs are single line logs.
+func (p LearnedPattern) compile() (*regexp.Regexp, error) {
+	if p.Pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(p.Pattern)
+}
+
+// updateLearnedPatterns reads the patterns, updates them and writes them back,
+// they are read every time as they can be updated outside of the agent.
+func updateLearnedPatterns(update func(map[string]LearnedPattern)) error {
+	learnedPatternsMutex.Lock()
+	defer learnedPatternsMutex.Unlock()
+	patterns, err := readLearnedPatterns()
+	if err != nil {
+		return err
+	}
+	update(patterns)
+	value, err := json.Marshal(patterns)
+	if err != nil {
+		return err
+	}
+	return persistentcache.Write(learnedPatternsCacheKey, string(value))
+}
+
+func readLearnedPatterns() (map[string]LearnedPattern, error) {
+	patterns := make(map[string]LearnedPattern)
+	value, err := persistentcache.Read(learnedPatternsCacheKey)
+	if err != nil || value == "" {
+		return patterns, err
+	}
+	if err := json.Unmarshal([]byte(value), &patterns); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package decoder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/parser"
+)
+
+func TestLearnedPatterns(t *testing.T) {
+	mockConfig := coreConfig.Mock()
+	mockConfig.Set("run_path", t.TempDir())
+
+	patterns, err := ReadLearnedPatterns()
+	require.NoError(t, err)
+	assert.Empty(t, patterns)
+
+	assert.NotNil(t, WriteLearnedPattern("nginx", LearnedPattern{Pattern: "^[0-9"}))
+
+	nginx := LearnedPattern{Pattern: `^\d+-\d+-\d+`, Confidence: 0.9, LinesTested: 500, LastUpdated: time.Date(2021, time.July, 8, 5, 8, 19, 0, time.UTC)}
+	require.NoError(t, WriteLearnedPattern("nginx", nginx))
+	require.NoError(t, WriteLearnedPattern("redis", LearnedPattern{Confidence: 0.1, LinesTested: 500}))
+	require.NoError(t, WriteLearnedPattern("java", LearnedPattern{Pattern: `^\d+`, Override: true}))
+
+	patterns, err = ReadLearnedPatterns()
+	require.NoError(t, err)
+	assert.Len(t, patterns, 3)
+	assert.Equal(t, nginx, patterns["nginx"])
+
+	require.NoError(t, RemoveLearnedPatterns("redis", "unknown"))
+	patterns, err = ReadLearnedPatterns()
+	require.NoError(t, err)
+	assert.Len(t, patterns, 2)
+
+	require.NoError(t, RemoveLearnedPatterns())
+	patterns, err = ReadLearnedPatterns()
+	require.NoError(t, err)
+	assert.Empty(t, patterns)
+}
+
+func TestDecoderUsesLearnedPattern(t *testing.T) {
+	mockConfig := coreConfig.Mock()
+	mockConfig.Set("run_path", t.TempDir())
+
+	source := config.NewLogSource("java", &config.LogsConfig{AutoMultiLine: true})
+	decoder := NewDecoderWithEndLineMatcher(source, parser.Noop, &NewLineMatcher{}, nil)
+	assert.IsType(t, &AutoMultilineHandler{}, decoder.lineParser.(*SingleLineParser).lineHandler)
+	assert.Nil(t, decoder.GetDetectedPattern())
+
+	require.NoError(t, WriteLearnedPattern("java", LearnedPattern{Pattern: `^\d+-\d+-\d+`, Override: true}))
+	decoder = NewDecoderWithEndLineMatcher(source, parser.Noop, &NewLineMatcher{}, nil)
+	assert.IsType(t, &MultiLineHandler{}, decoder.lineParser.(*SingleLineParser).lineHandler)
+	assert.Equal(t, `^\d+-\d+-\d+`, decoder.GetDetectedPattern().String())
+
+	require.NoError(t, WriteLearnedPattern("java", LearnedPattern{LinesTested: 500}))
+	decoder = NewDecoderWithEndLineMatcher(source, parser.Noop, &NewLineMatcher{}, nil)
+	assert.IsType(t, &SingleLineHandler{}, decoder.lineParser.(*SingleLineParser).lineHandler)
+
+	// the learned patterns are ignored when they are not persisted
+	mockConfig.Set("logs_config.auto_multi_line_persist_learned_patterns", false)
+	decoder = NewDecoderWithEndLineMatcher(source, parser.Noop, &NewLineMatcher{}, nil)
+	assert.IsType(t, &AutoMultilineHandler{}, decoder.lineParser.(*SingleLineParser).lineHandler)
+}
+
+func TestAutoMultiLineHandlerStoresLearnedPattern(t *testing.T) {
+	mockConfig := coreConfig.Mock()
+	mockConfig.Set("run_path", t.TempDir())
+	mockConfig.Set("logs_config.auto_multi_line_default_sample_size", 2)
+
+	outputChan := make(chan *Message, 10)
+	source := config.NewLogSource("java", &config.LogsConfig{})
+	h := buildAutoMultilineHandlerFromConfig(outputChan, 100, source, &DetectedPattern{})
+	h.Start()
+
+	h.Handle(getDummyMessageWithLF("2021-07-08T05:08:19Z test message 1"))
+	h.Handle(getDummyMessageWithLF("2021-07-08T05:08:20Z test message 2"))
+	<-outputChan
+	<-outputChan
+
+	// the pattern is stored once the second line is processed
+	var patterns map[string]LearnedPattern
+	require.Eventually(t, func() bool {
+		patterns, _ = ReadLearnedPatterns()
+		return len(patterns) == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, formatsToTry[0].String(), patterns["java"].Pattern)
+	assert.Equal(t, 1.0, patterns["java"].Confidence)
+	assert.Equal(t, 2, patterns["java"].LinesTested)
+	assert.False(t, patterns["java"].Override)
+}
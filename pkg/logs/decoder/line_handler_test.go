@@ -332,3 +332,97 @@ func TestAutoMultiLineHandlerHandelsMessageConflictingPatternsNoWinner(t *testin
 
 	assert.Equal(t, "Jul 12, 2021 12:55:15 PM test message 2", string(output.Content))
 }
+
+func TestAutoMultiLineHandlerIgnoresStackTraceLines(t *testing.T) {
+
+	outputChan := make(chan *Message, 10)
+	source := config.NewLogSource("config", &config.LogsConfig{})
+	h := NewAutoMultilineHandler(outputChan, 500, 6, 0.9, time.Second, 10*time.Millisecond, source, []*regexp.Regexp{}, &DetectedPattern{})
+	matchRatios := make(chan float64, 1)
+	h.onDetection = func(pattern *regexp.Regexp, matchRatio float64, linesTested int) {
+		assert.NotNil(t, pattern)
+		assert.Equal(t, 6, linesTested)
+		matchRatios <- matchRatio
+	}
+	h.Start()
+
+	// only the lines not looking like stack trace lines are taken into account in the match ratio
+	h.Handle(getDummyMessageWithLF("2021-07-08 05:08:19.214+02:00 panic: boom"))
+	h.Handle(getDummyMessageWithLF("goroutine 1 [running]:"))
+	h.Handle(getDummyMessageWithLF("main.(*server).run(0xc000010000, 0x1)"))
+	h.Handle(getDummyMessageWithLF("\t/go/src/main.go:12 +0x1d"))
+	h.Handle(getDummyMessageWithLF("2021-07-08 05:08:20.214+02:00 java.lang.Exception: boom"))
+	h.Handle(getDummyMessageWithLF("\tat com.example.Main.main(Main.java:12)"))
+
+	for i := 0; i < 6; i++ {
+		<-outputChan
+	}
+	assert.Equal(t, 1.0, <-matchRatios)
+}
+
+func TestAutoMultiLineHandlerDoesNotReportTimedOutDetection(t *testing.T) {
+
+	outputChan := make(chan *Message, 10)
+	source := config.NewLogSource("config", &config.LogsConfig{})
+	h := NewAutoMultilineHandler(outputChan, 500, 1000, 0.9, time.Nanosecond, 10*time.Millisecond, source, []*regexp.Regexp{}, &DetectedPattern{})
+	reported := false
+	h.onDetection = func(pattern *regexp.Regexp, matchRatio float64, linesTested int) {
+		reported = true
+	}
+	time.Sleep(time.Millisecond)
+	h.Start()
+
+	h.Handle(getDummyMessageWithLF("1625720899214 test message"))
+	<-outputChan
+	h.Handle(getDummyMessageWithLF("1625720899215 test message"))
+	<-outputChan
+
+	assert.Nil(t, h.singleLineHandler)
+	assert.NotNil(t, h.multiLineHandler)
+	assert.False(t, reported)
+}
+
+func TestAutoMultiLineTimestampFormats(t *testing.T) {
+	for _, line := range []string{
+		"2021-07-08T05:08:19.214+02:00 message",
+		"2021-07-08 05:08:19+0200 message",
+		"2021/07/08 05:08:19 message",
+		"<34>Oct 11 22:14:15 mymachine su: message",
+		"Oct  1 22:14:15 mymachine su: message",
+		"<34>1 2003-10-11T22:14:15.003Z mymachine su - ID47 - message",
+		"1625720899214 message",
+		"1625720899.214 message",
+	} {
+		matched := false
+		for _, format := range formatsToTry {
+			if format.MatchString(line) {
+				matched = true
+				break
+			}
+		}
+		assert.True(t, matched, line)
+	}
+}
+
+func TestIsStackTraceLine(t *testing.T) {
+	for _, line := range []string{
+		"\tat com.example.Main.main(Main.java:12)",
+		"at Main.funcd(Main.java:62)",
+		"\t... 12 more",
+		"Caused by: java.lang.IllegalStateException: boom",
+		"java.lang.Exception: boom",
+		"goroutine 1 [running]:",
+		"main.main()",
+		"main.(*server).run(0xc000010000, 0x1)",
+		"\t/usr/local/go/src/runtime/proc.go:225 +0x256",
+	} {
+		assert.True(t, isStackTraceLine([]byte(line)), line)
+	}
+	for _, line := range []string{
+		"a regular message",
+		"at the end of the day",
+		"2021-07-08 05:08:19 main.main()",
+	} {
+		assert.False(t, isStackTraceLine([]byte(line)), line)
+	}
+}
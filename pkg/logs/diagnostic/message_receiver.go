@@ -7,43 +7,107 @@ package diagnostic
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"regexp"
 	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // MessageReceiver interface to handle messages for diagnostics
 type MessageReceiver interface {
 	HandleMessage(message.Message, []byte)
+	HandleReceivedMessage(message.Message)
+	HandleDroppedMessage(message.Message, string)
 }
 
+// Stage is the step of the processing at which a message is handled for diagnostics
+type Stage string
+
+const (
+	// Received messages are not processed by the processing rules yet
+	Received Stage = "received"
+	// Processed messages went through the processing rules and are sent
+	Processed Stage = "processed"
+	// Dropped messages were dropped by a processing rule
+	Dropped Stage = "dropped"
+)
+
 type messagePair struct {
 	msg         *message.Message
 	redactedMsg []byte
+	stage       Stage
+	// rule is the name of the processing rule which dropped the message
+	rule string
 }
 
 // BufferedMessageReceiver handles in coming log messages and makes them available for diagnostics
 type BufferedMessageReceiver struct {
 	inputChan chan messagePair
 	enabled   bool
+	stages    map[Stage]bool
 	m         sync.RWMutex
 }
 
 // Filters for processing log messages
 type Filters struct {
-	Name   string `json:"name"`
-	Type   string `json:"type"`
-	Source string `json:"source"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Source  string `json:"source"`
+	Service string `json:"service"`
+	Status  string `json:"status"`
+	// Content is a regular expression matching the content of the messages
+	Content string `json:"content"`
+	// Stages are the stages of the processing at which the messages are
+	// handled, the processed messages only when empty
+	Stages []Stage `json:"stages"`
+
+	contentRegex *regexp.Regexp
+}
+
+// StreamOptions are the filters and the format of a stream of messages
+type StreamOptions struct {
+	Filters
+	// JSON formats the messages as JSON objects instead of text lines
+	JSON bool `json:"json"`
+	// Count is the number of messages after which the stream ends, no limit when 0
+	Count int `json:"count"`
+	// Duration is the duration after which the stream ends, no limit when 0
+	Duration time.Duration `json:"duration"`
+}
+
+// Validate checks the stages and compiles the content regular expression of the filters
+func (f *Filters) Validate() error {
+	for _, stage := range f.Stages {
+		switch stage {
+		case Received, Processed, Dropped:
+		default:
+			return fmt.Errorf("unknown stage %q, expected one of %s, %s, %s", stage, Received, Processed, Dropped)
+		}
+	}
+
+	f.contentRegex = nil
+	if f.Content == "" {
+		return nil
+	}
+	contentRegex, err := regexp.Compile(f.Content)
+	if err != nil {
+		return fmt.Errorf("invalid content regular expression: %v", err)
+	}
+	f.contentRegex = contentRegex
+	return nil
 }
 
 // NewBufferedMessageReceiver creates a new MessageReceiver
 func NewBufferedMessageReceiver() *BufferedMessageReceiver {
 	return &BufferedMessageReceiver{
 		inputChan: make(chan messagePair, config.ChanSize),
+		stages:    stagesSet(nil),
 	}
 }
 
@@ -88,25 +152,85 @@ func (b *BufferedMessageReceiver) IsEnabled() bool {
 	return b.enabled
 }
 
+// setStages sets the stages at which the messages are buffered
+func (b *BufferedMessageReceiver) setStages(stages []Stage) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.stages = stagesSet(stages)
+}
+
+// isStageEnabled returns true if the messages are buffered at a stage
+func (b *BufferedMessageReceiver) isStageEnabled(stage Stage) bool {
+	b.m.RLock()
+	defer b.m.RUnlock()
+	return b.enabled && b.stages[stage]
+}
+
 // HandleMessage buffers a message for diagnostic processing
 func (b *BufferedMessageReceiver) HandleMessage(m message.Message, redactedMsg []byte) {
-	if !b.IsEnabled() {
+	if !b.isStageEnabled(Processed) {
+		return
+	}
+	b.inputChan <- messagePair{msg: &m, redactedMsg: redactedMsg, stage: Processed}
+}
+
+// HandleReceivedMessage buffers a message before it goes through the processing rules
+func (b *BufferedMessageReceiver) HandleReceivedMessage(m message.Message) {
+	if !b.isStageEnabled(Received) {
+		return
+	}
+	b.inputChan <- messagePair{msg: &m, redactedMsg: m.Content, stage: Received}
+}
+
+// HandleDroppedMessage buffers a message dropped by a processing rule
+func (b *BufferedMessageReceiver) HandleDroppedMessage(m message.Message, rule string) {
+	if !b.isStageEnabled(Dropped) {
 		return
 	}
-	b.inputChan <- messagePair{&m, redactedMsg}
+	b.inputChan <- messagePair{msg: &m, redactedMsg: m.Content, stage: Dropped, rule: rule}
 }
 
 // Filter writes the buffered events from the input channel formatted as a string to the output channel
 func (b *BufferedMessageReceiver) Filter(filters *Filters, done <-chan struct{}) <-chan string {
+	options := &StreamOptions{}
+	if filters != nil {
+		options.Filters = *filters
+	}
+	return b.Stream(options, done)
+}
+
+// Stream writes the buffered events from the input channel matching the filters
+// of the options, formatted as a string, to the output channel which is closed
+// once the count or the duration limit of the options is reached.
+func (b *BufferedMessageReceiver) Stream(options *StreamOptions, done <-chan struct{}) <-chan string {
+	if err := options.Validate(); err != nil {
+		log.Warnf("Invalid diagnostic filters, no message will match: %v", err)
+	}
+	b.setStages(options.Stages)
+
 	out := make(chan string, config.ChanSize)
 	go func() {
 		defer close(out)
+		var timeout <-chan time.Time
+		if options.Duration > 0 {
+			timer := time.NewTimer(options.Duration)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		count := 0
 		for {
 			select {
 			case msgPair := <-b.inputChan:
-				if shouldHandleMessage(msgPair.msg, filters) {
-					out <- formatMessage(msgPair.msg, msgPair.redactedMsg)
+				if !shouldHandleMessage(&msgPair, &options.Filters) {
+					continue
+				}
+				out <- formatMessage(&msgPair, options)
+				count++
+				if options.Count > 0 && count >= options.Count {
+					return
 				}
+			case <-timeout:
+				return
 			case <-done:
 				return
 			}
@@ -115,10 +239,20 @@ func (b *BufferedMessageReceiver) Filter(filters *Filters, done <-chan struct{})
 	return out
 }
 
-func shouldHandleMessage(m *message.Message, filters *Filters) bool {
-	if filters == nil {
-		return true
+// stagesSet returns the set of the stages, the processed stage only when empty
+func stagesSet(stages []Stage) map[Stage]bool {
+	if len(stages) == 0 {
+		stages = []Stage{Processed}
+	}
+	set := make(map[Stage]bool, len(stages))
+	for _, stage := range stages {
+		set[stage] = true
 	}
+	return set
+}
+
+func shouldHandleMessage(msgPair *messagePair, filters *Filters) bool {
+	m := msgPair.msg
 
 	shouldHandle := true
 
@@ -134,10 +268,40 @@ func shouldHandleMessage(m *message.Message, filters *Filters) bool {
 		shouldHandle = shouldHandle && filters.Source == m.Origin.Source()
 	}
 
+	if filters.Service != "" {
+		shouldHandle = shouldHandle && filters.Service == m.Origin.Service()
+	}
+
+	if filters.Status != "" {
+		shouldHandle = shouldHandle && filters.Status == m.GetStatus()
+	}
+
+	if filters.Content != "" {
+		// an invalid expression is not compiled and matches nothing
+		shouldHandle = shouldHandle && filters.contentRegex != nil && filters.contentRegex.Match(msgPair.redactedMsg)
+	}
+
 	return shouldHandle
 }
 
-func formatMessage(m *message.Message, redactedMsg []byte) string {
+// jsonMessage is the JSON format of the streamed messages
+type jsonMessage struct {
+	Stage           Stage     `json:"stage"`
+	Rule            string    `json:"rule,omitempty"`
+	IntegrationName string    `json:"integration_name"`
+	Type            string    `json:"type"`
+	Status          string    `json:"status"`
+	Timestamp       time.Time `json:"timestamp"`
+	Hostname        string    `json:"hostname"`
+	Service         string    `json:"service"`
+	Source          string    `json:"source"`
+	Tags            []string  `json:"tags"`
+	Message         string    `json:"message"`
+}
+
+func formatMessage(msgPair *messagePair, options *StreamOptions) string {
+	m := msgPair.msg
+
 	hostname, err := util.GetHostname(context.TODO())
 	if err != nil {
 		hostname = "unknown"
@@ -148,7 +312,37 @@ func formatMessage(m *message.Message, redactedMsg []byte) string {
 		ts = m.Timestamp
 	}
 
-	return fmt.Sprintf("Integration Name: %s | Type: %s | Status: %s | Timestamp: %s | Hostname: %s | Service: %s | Source: %s | Tags: %s | Message: %s\n",
+	if options.JSON {
+		line, err := json.Marshal(jsonMessage{
+			Stage:           msgPair.stage,
+			Rule:            msgPair.rule,
+			IntegrationName: m.Origin.LogSource.Name,
+			Type:            m.Origin.LogSource.Config.Type,
+			Status:          m.GetStatus(),
+			Timestamp:       ts,
+			Hostname:        hostname,
+			Service:         m.Origin.Service(),
+			Source:          m.Origin.Source(),
+			Tags:            m.Origin.Tags(),
+			Message:         string(msgPair.redactedMsg),
+		})
+		if err != nil {
+			return fmt.Sprintf("{\"error\": %q}\n", err)
+		}
+		return string(line) + "\n"
+	}
+
+	// the stage is only printed when several stages can be streamed
+	stage := ""
+	if len(options.Stages) > 0 {
+		stage = fmt.Sprintf("Stage: %s | ", msgPair.stage)
+		if msgPair.stage == Dropped {
+			stage = fmt.Sprintf("Stage: %s by %s | ", msgPair.stage, msgPair.rule)
+		}
+	}
+
+	return fmt.Sprintf("%sIntegration Name: %s | Type: %s | Status: %s | Timestamp: %s | Hostname: %s | Service: %s | Source: %s | Tags: %s | Message: %s\n",
+		stage,
 		m.Origin.LogSource.Name,
 		m.Origin.LogSource.Config.Type,
 		m.GetStatus(),
@@ -157,5 +351,5 @@ func formatMessage(m *message.Message, redactedMsg []byte) string {
 		m.Origin.Service(),
 		m.Origin.Source(),
 		m.Origin.TagsToString(),
-		string(redactedMsg))
+		string(msgPair.redactedMsg))
 }
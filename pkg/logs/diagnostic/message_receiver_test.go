@@ -5,7 +5,10 @@
 package diagnostic
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -154,6 +157,117 @@ func TestNoFilters(t *testing.T) {
 	readFilteredLines(t, b, &filters, 15)
 }
 
+func TestFilterServiceStatusAndContent(t *testing.T) {
+	b := NewBufferedMessageReceiver()
+	b.SetEnabled(true)
+
+	for i := 0; i < 5; i++ {
+		b.HandleMessage(newMessageWithService("test", "web", message.StatusError), []byte("GET /index"))
+		b.HandleMessage(newMessageWithService("test", "web", message.StatusInfo), []byte("GET /index"))
+		b.HandleMessage(newMessageWithService("test", "db", message.StatusError), []byte("GET /index"))
+		b.HandleMessage(newMessageWithService("test", "web", message.StatusError), []byte("POST /index"))
+	}
+
+	readFilteredLines(t, b, &Filters{Service: "web", Status: message.StatusError, Content: "^GET"}, 5)
+}
+
+func TestFilterInvalidContentMatchesNothing(t *testing.T) {
+	b := NewBufferedMessageReceiver()
+	b.SetEnabled(true)
+
+	b.HandleMessage(newMessage("test", "a", "b"), []byte("a"))
+
+	filters := Filters{Content: "[a"}
+	assert.Error(t, filters.Validate())
+	readFilteredLines(t, b, &filters, 0)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, (&Filters{}).Validate())
+	assert.NoError(t, (&Filters{Content: "a+", Stages: []Stage{Received, Processed, Dropped}}).Validate())
+	assert.Error(t, (&Filters{Content: "a("}).Validate())
+	assert.Error(t, (&Filters{Stages: []Stage{"sent"}}).Validate())
+}
+
+func TestStages(t *testing.T) {
+	b := NewBufferedMessageReceiver()
+	b.SetEnabled(true)
+
+	// only the processed messages are streamed by default
+	done := make(chan struct{})
+	lineChan := b.Filter(nil, done)
+	b.HandleReceivedMessage(newMessage("test", "a", "b"))
+	b.HandleDroppedMessage(newMessage("test", "a", "b"), "exclude")
+	b.HandleMessage(newMessage("test", "a", "b"), []byte("a"))
+	assert.True(t, strings.HasPrefix(<-lineChan, "Integration Name: test |"))
+	close(done)
+	for range lineChan {
+		// wait for the stream to end
+	}
+
+	done = make(chan struct{})
+	defer close(done)
+	lineChan = b.Stream(&StreamOptions{Filters: Filters{Stages: []Stage{Received, Dropped}}}, done)
+	b.HandleReceivedMessage(newMessage("test", "a", "b"))
+	b.HandleMessage(newMessage("test", "a", "b"), []byte("a"))
+	b.HandleDroppedMessage(newMessage("test", "a", "b"), "exclude")
+	assert.True(t, strings.HasPrefix(<-lineChan, "Stage: received | Integration Name: test |"))
+	assert.True(t, strings.HasPrefix(<-lineChan, "Stage: dropped by exclude | Integration Name: test |"))
+}
+
+func TestStreamJSON(t *testing.T) {
+	b := NewBufferedMessageReceiver()
+	b.SetEnabled(true)
+
+	done := make(chan struct{})
+	defer close(done)
+	lineChan := b.Stream(&StreamOptions{JSON: true}, done)
+	b.HandleMessage(newMessageWithService("test", "web", message.StatusError), []byte("redacted"))
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(<-lineChan), &line))
+	assert.Equal(t, "processed", line["stage"])
+	assert.Equal(t, "test", line["integration_name"])
+	assert.Equal(t, "web", line["service"])
+	assert.Equal(t, message.StatusError, line["status"])
+	assert.Equal(t, "redacted", line["message"])
+	assert.NotContains(t, line, "rule")
+}
+
+func TestStreamLimits(t *testing.T) {
+	b := NewBufferedMessageReceiver()
+	b.SetEnabled(true)
+
+	for i := 0; i < 5; i++ {
+		b.HandleMessage(newMessage("test", "a", "b"), []byte("a"))
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	lineChan := b.Stream(&StreamOptions{Count: 3}, done)
+	for i := 0; i < 3; i++ {
+		assert.NotEqual(t, "", <-lineChan)
+	}
+	_, ok := <-lineChan
+	assert.False(t, ok)
+
+	lineChan = b.Stream(&StreamOptions{Duration: 10 * time.Millisecond}, done)
+	for range lineChan {
+		// the stream ends after the duration
+	}
+	_, ok = <-lineChan
+	assert.False(t, ok)
+}
+
+func newMessageWithService(n string, service string, status string) message.Message {
+	cfg := &config.LogsConfig{
+		Service: service,
+	}
+	source := config.NewLogSource(n, cfg)
+	origin := message.NewOrigin(source)
+	return *message.NewMessage([]byte("a"), origin, status, 0)
+}
+
 func newMessage(n string, t string, s string) message.Message {
 	cfg := &config.LogsConfig{
 		Type:   t,
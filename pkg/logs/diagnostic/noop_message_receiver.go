@@ -12,3 +12,9 @@ type NoopMessageReceiver struct{}
 
 // HandleMessage does nothing with the message
 func (n *NoopMessageReceiver) HandleMessage(m message.Message, redactedMsg []byte) {}
+
+// HandleReceivedMessage does nothing with the message
+func (n *NoopMessageReceiver) HandleReceivedMessage(m message.Message) {}
+
+// HandleDroppedMessage does nothing with the message
+func (n *NoopMessageReceiver) HandleDroppedMessage(m message.Message, rule string) {}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// encodingInfoKey is the key of the status page info listing the encodings of the files of a source
+const encodingInfoKey = "Encodings"
+
+// byteOrderMarks are the byte order marks the encodings are detected from
+var byteOrderMarks = []struct {
+	bom      []byte
+	encoding string
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, config.UTF8},
+	{[]byte{0xFF, 0xFE}, config.UTF16LE},
+	{[]byte{0xFE, 0xFF}, config.UTF16BE},
+}
+
+// encodingFromByteOrderMark returns the encoding of a file starting with head,
+// or an empty string if it does not start with a byte order mark.
+func encodingFromByteOrderMark(head []byte) string {
+	for _, mark := range byteOrderMarks {
+		if bytes.HasPrefix(head, mark.bom) {
+			return mark.encoding
+		}
+	}
+	return ""
+}
+
+// detectEncoding returns the encoding of the file at path from its byte order mark,
+// or an empty string if it does not start with a byte order mark.
+func detectEncoding(path string) string {
+	f, err := openFile(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	head := make([]byte, 3)
+	n, _ := io.ReadFull(f, head)
+	return encodingFromByteOrderMark(head[:n])
+}
+
+// resolveEncoding sets the encoding of a file: the configured one, or the one
+// detected from its byte order mark, or the one stored in the registry for the
+// file as its beginning may have been truncated since it was detected.
+// The encoding is reported on the status page of the source.
+func resolveEncoding(file *File, registry auditor.Registry) {
+	source := file.Source
+	if source.GetSourceType() == config.KubernetesSourceType || source.GetSourceType() == config.DockerSourceType {
+		return
+	}
+
+	encoding, origin := source.Config.Encoding, "configured"
+	if encoding == "" {
+		encoding, origin = detectEncoding(file.Path), "detected from the byte order mark"
+	}
+	if encoding == "" {
+		encoding, origin = registry.GetEncoding(fileIdentifier(file.Path)), "stored in the registry"
+	}
+	file.Encoding = encoding
+	if encoding == "" {
+		return
+	}
+
+	log.Debugf("Using the encoding %s %s for %s", encoding, origin, file.Path)
+	encodingInfo(source).SetMessage(file.Path, fmt.Sprintf("%s: %s (%s)", file.Path, encoding, origin))
+}
+
+// removeEncodingInfo removes the encoding of a file from the status page of the source
+func removeEncodingInfo(file *File) {
+	if info, ok := file.Source.GetInfo(encodingInfoKey).(*config.MappedInfo); ok {
+		info.RemoveMessage(file.Path)
+	}
+}
+
+// encodingInfo returns the info listing the encodings of the files of a source,
+// shared by all its tailers.
+func encodingInfo(source *config.LogSource) *config.MappedInfo {
+	if info, ok := source.GetInfo(encodingInfoKey).(*config.MappedInfo); ok {
+		return info
+	}
+	info := config.NewMappedInfo(encodingInfoKey)
+	source.RegisterInfo(info)
+	return info
+}
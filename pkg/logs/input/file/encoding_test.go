@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor/mock"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestEncodingFromByteOrderMark(t *testing.T) {
+	assert.Equal(t, config.UTF8, encodingFromByteOrderMark([]byte{0xEF, 0xBB, 0xBF, 'a'}))
+	assert.Equal(t, config.UTF16LE, encodingFromByteOrderMark([]byte{0xFF, 0xFE, 'a', 0x0}))
+	assert.Equal(t, config.UTF16BE, encodingFromByteOrderMark([]byte{0xFE, 0xFF, 0x0, 'a'}))
+	assert.Equal(t, "", encodingFromByteOrderMark([]byte("abc")))
+	assert.Equal(t, "", encodingFromByteOrderMark([]byte{0xEF, 0xBB}))
+	assert.Equal(t, "", encodingFromByteOrderMark(nil))
+}
+
+func TestResolveEncoding(t *testing.T) {
+	dir := t.TempDir()
+	bomPath := filepath.Join(dir, "bom.log")
+	require.NoError(t, ioutil.WriteFile(bomPath, []byte{0xFF, 0xFE, 'a', 0x0, '\n', 0x0}, 0644))
+	plainPath := filepath.Join(dir, "plain.log")
+	require.NoError(t, ioutil.WriteFile(plainPath, []byte("a\n"), 0644))
+	registry := mock.NewRegistry()
+
+	// the configured encoding wins
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: bomPath, Encoding: config.ShiftJIS})
+	file := NewFile(bomPath, source, false)
+	resolveEncoding(file, registry)
+	assert.Equal(t, config.ShiftJIS, file.Encoding)
+	assert.Equal(t, []string{bomPath + ": shift-jis (configured)"}, source.GetInfoStatus()[encodingInfoKey])
+
+	// the encoding is detected from the byte order mark
+	source = config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: bomPath})
+	file = NewFile(bomPath, source, false)
+	resolveEncoding(file, registry)
+	assert.Equal(t, config.UTF16LE, file.Encoding)
+	assert.Equal(t, []string{bomPath + ": utf-16-le (detected from the byte order mark)"}, source.GetInfoStatus()[encodingInfoKey])
+	removeEncodingInfo(file)
+	assert.NotContains(t, source.GetInfoStatus(), encodingInfoKey)
+
+	// without byte order mark, the encoding stored in the registry is used
+	source = config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: plainPath})
+	file = NewFile(plainPath, source, false)
+	resolveEncoding(file, registry)
+	assert.Equal(t, "", file.Encoding)
+	assert.NotContains(t, source.GetInfoStatus(), encodingInfoKey)
+
+	registry.SetEncoding(config.UTF16BE)
+	resolveEncoding(file, registry)
+	assert.Equal(t, config.UTF16BE, file.Encoding)
+	assert.Equal(t, []string{plainPath + ": utf-16-be (stored in the registry)"}, source.GetInfoStatus()[encodingInfoKey])
+
+	// the container files are not decoded
+	source = config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: bomPath})
+	source.SetSourceType(config.DockerSourceType)
+	file = NewFile(bomPath, source, false)
+	resolveEncoding(file, registry)
+	assert.Equal(t, "", file.Encoding)
+}
+
+func TestTailerDecodesDetectedEncoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utf16.log")
+	content := []byte{0xFF, 0xFE}
+	for _, r := range "hello\nworld\n" {
+		content = append(content, byte(r), 0x0)
+	}
+	require.NoError(t, ioutil.WriteFile(path, content, 0644))
+
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	file := NewFile(path, source, false)
+	resolveEncoding(file, mock.NewRegistry())
+	outputChan := make(chan *message.Message, 2)
+	tailer := NewTailer(outputChan, file, 10*time.Millisecond, NewDecoderFromSourceWithEncoding(source, file.Encoding, nil))
+	require.NoError(t, tailer.StartFromBeginning())
+	defer tailer.Stop()
+
+	for _, expected := range []string{"hello", "world"} {
+		msg := <-outputChan
+		assert.Equal(t, expected, string(msg.Content))
+		assert.Equal(t, config.UTF16LE, msg.Origin.Encoding)
+	}
+}
@@ -28,6 +28,8 @@ type File struct {
 	// in a directory with wildcard(s) in the configuration.
 	IsWildcardPath bool
 	Source         *config.LogSource
+	// Encoding is the encoding of the file, configured or detected when the tailer is created
+	Encoding string
 }
 
 // NewFile returns a new File
@@ -0,0 +1,420 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// rotatedFileSuffix matches the suffixes appended to the rotated files by
+// logrotate, e.g. "app.log.1", "app.log.2.gz" or "app.log-20210601.gz"
+var rotatedFileSuffix = regexp.MustCompile(`^(\.\d+|-\d{8,10})(\.gz)?$`)
+
+// rotatedFileCompleted is the offset recorded in the registry for the rotated
+// files read up to their end.
+const rotatedFileCompleted = "completed"
+
+// rotatedFingerprintSize is the number of bytes at the beginning of a rotated
+// file identifying it, the file being renamed and compressed as it ages.
+const rotatedFingerprintSize = 1024
+
+const rotatedReadBufferSize = 4096
+
+// rotatedFile is a rotated sibling of a tailed file
+type rotatedFile struct {
+	path       string
+	compressed bool
+	modTime    time.Time
+}
+
+// findRotatedFiles returns the rotated siblings of a file modified since the
+// given time, from the oldest to the most recent one.
+func findRotatedFiles(path string, since time.Time) ([]*rotatedFile, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*rotatedFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Mode().IsRegular() || !strings.HasPrefix(name, base) || !rotatedFileSuffix.MatchString(name[len(base):]) {
+			continue
+		}
+		if entry.ModTime().Before(since) {
+			continue
+		}
+		files = append(files, &rotatedFile{
+			path:       filepath.Join(dir, name),
+			compressed: strings.HasSuffix(name, ".gz"),
+			modTime:    entry.ModTime(),
+		})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files, nil
+}
+
+// rotatedFileReader reads the content of a rotated file, decompressing it if needed
+type rotatedFileReader struct {
+	io.Reader
+	file *os.File
+}
+
+func openRotatedFile(rotated *rotatedFile) (*rotatedFileReader, error) {
+	f, err := openFile(rotated.path)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated.compressed {
+		return &rotatedFileReader{Reader: f, file: f}, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &rotatedFileReader{Reader: gz, file: f}, nil
+}
+
+// Close closes the underlying file
+func (r *rotatedFileReader) Close() error {
+	return r.file.Close()
+}
+
+// rotatedFileIdentifier returns the registry identifier of a rotated file
+// from the first bytes of its content, which do not change when it is
+// renamed or compressed.
+func rotatedFileIdentifier(path string, head []byte) string {
+	return fmt.Sprintf("file:%s:rotated:%x", path, sha256.Sum256(head))
+}
+
+// RotatedTailer reads once the rotated files of a tailed file that have not
+// been read yet, from the oldest to the most recent one, and records their
+// completion in the registry. The offsets of the compressed files are the
+// offsets in their decompressed content.
+type RotatedTailer struct {
+	file       *File
+	files      []*rotatedFile
+	registry   auditor.Registry
+	outputChan chan *message.Message
+	// handoverOffset is the offset to read the most recent rotated file from
+	// when it has no offset recorded yet, the offset recorded for the tailed
+	// file when it was rotated while the agent was stopped, or -1.
+	handoverOffset int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRotatedTailer returns a new RotatedTailer
+func NewRotatedTailer(outputChan chan *message.Message, file *File, files []*rotatedFile, registry auditor.Registry, handoverOffset int64) *RotatedTailer {
+	return &RotatedTailer{
+		file:           file,
+		files:          files,
+		registry:       registry,
+		outputChan:     outputChan,
+		handoverOffset: handoverOffset,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+// Start starts reading the rotated files
+func (t *RotatedTailer) Start() {
+	go t.run()
+}
+
+// Stop stops reading the rotated files, the progress being recorded in the
+// registry to resume from it.
+func (t *RotatedTailer) Stop() {
+	close(t.stop)
+	<-t.done
+}
+
+// isDone returns true once all the rotated files have been read
+func (t *RotatedTailer) isDone() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *RotatedTailer) run() {
+	defer close(t.done)
+	for i, rotated := range t.files {
+		handoverOffset := int64(-1)
+		if i == len(t.files)-1 {
+			handoverOffset = t.handoverOffset
+		}
+		if err := t.readFile(rotated, handoverOffset); err != nil {
+			log.Warnf("Could not read the rotated file %s: %v", rotated.path, err)
+		}
+		select {
+		case <-t.stop:
+			return
+		default:
+		}
+	}
+}
+
+// readFile reads a rotated file from the offset recorded in the registry
+func (t *RotatedTailer) readFile(rotated *rotatedFile, handoverOffset int64) error {
+	reader, err := openRotatedFile(rotated)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	head := make([]byte, rotatedFingerprintSize)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			// nothing to read in an empty file
+			return nil
+		}
+		return err
+	}
+	head = head[:n]
+	identifier := rotatedFileIdentifier(t.file.Path, head)
+
+	offset := handoverOffset
+	switch recorded := t.registry.GetOffset(identifier); recorded {
+	case rotatedFileCompleted:
+		return nil
+	case "":
+		if offset < 0 {
+			offset = 0
+		}
+	default:
+		offset, err = strconv.ParseInt(recorded, 10, 64)
+		if err != nil {
+			offset = 0
+		}
+	}
+
+	log.Infof("Reading the rotated file %s of %s from offset %d", rotated.path, t.file.Path, offset)
+	t.file.Source.AddInput(rotated.path)
+	defer t.file.Source.RemoveInput(rotated.path)
+
+	encoding := t.encodingOf(head)
+	dec := NewDecoderFromSourceWithEncoding(t.file.Source, encoding, nil)
+	forwarded := make(chan struct{})
+	var last *message.Message
+	go func() {
+		defer close(forwarded)
+		last = t.forwardMessages(dec, identifier, offset)
+	}()
+	dec.Start()
+
+	completed, err := t.feed(dec, reader, head, offset, endOfLine(encoding))
+	dec.Stop()
+	<-forwarded
+
+	// the last message carries the completion of the file
+	switch {
+	case completed && last == nil:
+		t.recordCompletion(identifier)
+	case completed:
+		last.Origin.Offset = rotatedFileCompleted
+		fallthrough
+	case last != nil:
+		t.outputChan <- last
+	}
+	return err
+}
+
+// feed sends the content of the rotated file after offset to the decoder,
+// returning true if it was read up to its end. The last line is terminated
+// if needed to be decoded, the file not being written anymore.
+func (t *RotatedTailer) feed(dec *decoder.Decoder, reader io.Reader, head []byte, offset int64, eol []byte) (bool, error) {
+	var last []byte
+	send := func(data []byte) {
+		dec.InputChan <- decoder.NewInput(data)
+		t.recordBytes(int64(len(data)))
+		last = append(last, data...)
+		if len(last) > len(eol) {
+			last = last[len(last)-len(eol):]
+		}
+	}
+	terminate := func() {
+		if len(last) > 0 && !bytes.Equal(last, eol) {
+			dec.InputChan <- decoder.NewInput(eol)
+		}
+	}
+
+	if offset < int64(len(head)) {
+		send(head[offset:])
+	} else if _, err := io.CopyN(ioutil.Discard, reader, offset-int64(len(head))); err != nil {
+		if err == io.EOF {
+			return true, nil
+		}
+		return false, err
+	}
+
+	for {
+		select {
+		case <-t.stop:
+			return false, nil
+		default:
+		}
+		buf := make([]byte, rotatedReadBufferSize)
+		n, err := reader.Read(buf)
+		if n > 0 {
+			send(buf[:n])
+		}
+		if err == io.EOF {
+			terminate()
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// encodingOf returns the encoding of a rotated file starting with head: the
+// configured one, or the one detected from its byte order mark, or the one of
+// the tailed file as they are written by the same application.
+func (t *RotatedTailer) encodingOf(head []byte) string {
+	if t.file.Source.Config.Encoding != "" {
+		return t.file.Source.Config.Encoding
+	}
+	if encoding := encodingFromByteOrderMark(head); encoding != "" {
+		return encoding
+	}
+	return t.file.Encoding
+}
+
+// endOfLine returns the end of line of an encoding
+func endOfLine(encoding string) []byte {
+	switch encoding {
+	case config.UTF16BE:
+		return decoder.Utf16beEOL
+	case config.UTF16LE:
+		return decoder.Utf16leEOL
+	default:
+		return []byte{'\n'}
+	}
+}
+
+// forwardMessages sends the decoded messages to the output channel but the
+// last one, returned once the decoder is flushed.
+func (t *RotatedTailer) forwardMessages(dec *decoder.Decoder, identifier string, offset int64) *message.Message {
+	tags := fileTags(t.file)
+	var last *message.Message
+	for output := range dec.OutputChan {
+		offset += int64(output.RawDataLen)
+		if len(output.Content) == 0 {
+			continue
+		}
+		origin := message.NewOrigin(t.file.Source)
+		origin.Identifier = identifier
+		origin.Offset = strconv.FormatInt(offset, 10)
+		origin.SetTags(tags)
+		if last != nil {
+			t.outputChan <- last
+		}
+		last = message.NewMessage(output.Content, origin, output.Status, output.IngestionTimestamp)
+	}
+	return last
+}
+
+// recordCompletion records the completion of a rotated file having no log
+// left to send, sending an empty message to the auditor directly as there is
+// no message to carry it through the pipeline.
+func (t *RotatedTailer) recordCompletion(identifier string) {
+	a, ok := t.registry.(auditor.Auditor)
+	if !ok || a.Channel() == nil {
+		return
+	}
+	origin := message.NewOrigin(t.file.Source)
+	origin.Identifier = identifier
+	origin.Offset = rotatedFileCompleted
+	a.Channel() <- message.NewMessage(nil, origin, message.StatusInfo, time.Now().UnixNano())
+}
+
+func (t *RotatedTailer) recordBytes(n int64) {
+	t.file.Source.BytesRead.Add(n)
+	if t.file.Source.ParentSource != nil {
+		t.file.Source.ParentSource.BytesRead.Add(n)
+	}
+}
+
+// rotatedHandoverOffset returns the offset recorded for a tailed file when it
+// is beyond its size, the file having been rotated since, in which case the
+// offset belongs to its most recent rotated file. It returns -1 otherwise.
+func rotatedHandoverOffset(path string, offset int64, whence int) int64 {
+	if whence != io.SeekStart || offset <= 0 {
+		return -1
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() >= offset {
+		return -1
+	}
+	return offset
+}
+
+// startRotatedTailer starts reading the rotated files of a file tailed with
+// tail_rotated_files that was already tailed before, as some of them may have
+// been rotated while the agent was stopped. It returns the position the file
+// should be tailed from, its beginning when its offset was handed over to its
+// most recent rotated file.
+func (s *Scanner) startRotatedTailer(file *File, identifier string, mode config.TailingMode, offset int64, whence int) (int64, int) {
+	if _, isReading := s.rotatedTailers[file.GetScanKey()]; isReading {
+		return offset, whence
+	}
+	if mode == config.ForceEnd || s.registry.GetOffset(identifier) == "" {
+		return offset, whence
+	}
+
+	// the files modified before the entries of the registry expire have been
+	// read while the agent was running
+	files, err := findRotatedFiles(file.Path, time.Now().Add(-s.rotatedFilesMaxAge))
+	if err != nil {
+		log.Warnf("Could not look for the rotated files of %s: %v", file.Path, err)
+		return offset, whence
+	}
+	if len(files) == 0 {
+		return offset, whence
+	}
+
+	handoverOffset := rotatedHandoverOffset(file.Path, offset, whence)
+	tailer := NewRotatedTailer(s.pipelineProvider.NextPipelineChan(), file, files, s.registry, handoverOffset)
+	tailer.Start()
+	s.rotatedTailers[file.GetScanKey()] = tailer
+
+	if handoverOffset >= 0 {
+		return 0, io.SeekStart
+	}
+	return offset, whence
+}
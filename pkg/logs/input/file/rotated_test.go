@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build !windows
+
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// testRegistry is a registry holding an offset per identifier
+type testRegistry map[string]string
+
+func (r testRegistry) GetOffset(identifier string) string {
+	return r[identifier]
+}
+
+func (r testRegistry) GetTailingMode(identifier string) string {
+	return ""
+}
+
+func (r testRegistry) GetEncoding(identifier string) string {
+	return ""
+}
+
+func writeRotatedFile(t *testing.T, path string, content string, modTime time.Time) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	if filepath.Ext(path) == ".gz" {
+		gz := gzip.NewWriter(f)
+		_, err = gz.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+	} else {
+		_, err = f.WriteString(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func readRotatedMessages(t *testing.T, outputChan chan *message.Message, tailer *RotatedTailer) []*message.Message {
+	select {
+	case <-tailer.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the rotated files were not read")
+	}
+	var messages []*message.Message
+	for len(outputChan) > 0 {
+		messages = append(messages, <-outputChan)
+	}
+	return messages
+}
+
+func TestFindRotatedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log-rotated-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	path := filepath.Join(dir, "app.log")
+	writeRotatedFile(t, path, "live\n", now)
+	writeRotatedFile(t, path+".1", "1\n", now.Add(-time.Hour))
+	writeRotatedFile(t, path+".2.gz", "2\n", now.Add(-2*time.Hour))
+	writeRotatedFile(t, path+"-20210601.gz", "3\n", now.Add(-3*time.Hour))
+	writeRotatedFile(t, path+".3.gz", "old\n", now.Add(-48*time.Hour))
+	writeRotatedFile(t, path+".bak", "backup\n", now.Add(-time.Hour))
+	writeRotatedFile(t, filepath.Join(dir, "other.log.1"), "other\n", now.Add(-time.Hour))
+
+	files, err := findRotatedFiles(path, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Equal(t, path+"-20210601.gz", files[0].path)
+	assert.True(t, files[0].compressed)
+	assert.Equal(t, path+".2.gz", files[1].path)
+	assert.Equal(t, path+".1", files[2].path)
+	assert.False(t, files[2].compressed)
+}
+
+func TestRotatedTailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log-rotated-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	path := filepath.Join(dir, "app.log")
+	writeRotatedFile(t, path+".2.gz", "first\nsecond\n", now.Add(-2*time.Hour))
+	writeRotatedFile(t, path+".1", "third\nfourth", now.Add(-time.Hour))
+	files, err := findRotatedFiles(path, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, TailRotatedFiles: true})
+	file := NewFile(path, source, false)
+	outputChan := make(chan *message.Message, 10)
+	registry := testRegistry{}
+
+	tailer := NewRotatedTailer(outputChan, file, files, registry, -1)
+	tailer.Start()
+	messages := readRotatedMessages(t, outputChan, tailer)
+	require.Len(t, messages, 4)
+	for i, content := range []string{"first", "second", "third", "fourth"} {
+		assert.Equal(t, content, string(messages[i].Content))
+		assert.Contains(t, messages[i].Origin.Tags(), "filename:app.log")
+	}
+	assert.Equal(t, "6", messages[0].Origin.Offset)
+	assert.Equal(t, rotatedFileCompleted, messages[1].Origin.Offset)
+	assert.Equal(t, rotatedFileCompleted, messages[3].Origin.Offset)
+	assert.NotEqual(t, messages[1].Origin.Identifier, messages[3].Origin.Identifier)
+
+	// the files are identified by their content, whatever their name
+	for _, msg := range messages {
+		registry[msg.Origin.Identifier] = msg.Origin.Offset
+	}
+	require.NoError(t, os.Rename(path+".2.gz", path+".3.gz"))
+	files, err = findRotatedFiles(path, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	tailer = NewRotatedTailer(outputChan, file, files, registry, -1)
+	tailer.Start()
+	assert.Len(t, readRotatedMessages(t, outputChan, tailer), 0)
+
+	// the files are read from their recorded offset
+	registry[messages[3].Origin.Identifier] = "6"
+	tailer = NewRotatedTailer(outputChan, file, files, registry, -1)
+	tailer.Start()
+	messages = readRotatedMessages(t, outputChan, tailer)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "fourth", string(messages[0].Content))
+}
+
+func TestRotatedTailerHandoverOffset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log-rotated-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	path := filepath.Join(dir, "app.log")
+	writeRotatedFile(t, path+".2.gz", "first\n", now.Add(-2*time.Hour))
+	writeRotatedFile(t, path+".1", "second\nthird\n", now.Add(-time.Hour))
+	writeRotatedFile(t, path, "new\n", now)
+	files, err := findRotatedFiles(path, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+
+	// the offset recorded for the live file is beyond its size, it was rotated
+	handoverOffset := rotatedHandoverOffset(path, 7, io.SeekStart)
+	assert.Equal(t, int64(7), handoverOffset)
+	assert.Equal(t, int64(-1), rotatedHandoverOffset(path, 4, io.SeekStart))
+	assert.Equal(t, int64(-1), rotatedHandoverOffset(path, 0, io.SeekEnd))
+
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, TailRotatedFiles: true})
+	outputChan := make(chan *message.Message, 10)
+	tailer := NewRotatedTailer(outputChan, NewFile(path, source, false), files, testRegistry{}, handoverOffset)
+	tailer.Start()
+	messages := readRotatedMessages(t, outputChan, tailer)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "first", string(messages[0].Content))
+	assert.Equal(t, "third", string(messages[1].Content))
+}
@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
@@ -51,6 +52,9 @@ type Scanner struct {
 	// Feature flag defaulting to false, use `logs_config.validate_pod_container_id`.
 	validatePodContainerID bool
 	scanPeriod             time.Duration
+	// rotatedTailers read the rotated files of the files tailed with tail_rotated_files
+	rotatedTailers     map[string]*RotatedTailer
+	rotatedFilesMaxAge time.Duration
 }
 
 // NewScanner returns a new scanner.
@@ -68,6 +72,9 @@ func NewScanner(sources *config.LogSources, tailingLimit int, pipelineProvider p
 		stop:                   make(chan struct{}),
 		validatePodContainerID: validatePodContainerID,
 		scanPeriod:             scanPeriod,
+		rotatedTailers:         make(map[string]*RotatedTailer),
+		// the rotated files older than the registry entries may have been read already
+		rotatedFilesMaxAge: time.Duration(coreConfig.Datadog.GetInt("logs_config.auditor_ttl")) * time.Hour,
 	}
 }
 
@@ -110,6 +117,10 @@ func (s *Scanner) cleanup() {
 		stopper.Add(tailer)
 		delete(s.tailers, tailer.file.GetScanKey())
 	}
+	for key, tailer := range s.rotatedTailers {
+		stopper.Add(tailer)
+		delete(s.rotatedTailers, key)
+	}
 	stopper.Stop()
 }
 
@@ -171,6 +182,13 @@ func (s *Scanner) scan() {
 		filesTailed[tailerKey] = true
 	}
 
+	for key, tailer := range s.rotatedTailers {
+		// forget the rotated tailers done reading their files
+		if tailer.isDone() {
+			delete(s.rotatedTailers, key)
+		}
+	}
+
 	for _, tailer := range s.tailers {
 		// stop all tailers which have not been selected
 		_, shouldTail := filesTailed[tailer.file.GetScanKey()]
@@ -261,6 +279,9 @@ func (s *Scanner) startNewTailer(file *File, m config.TailingMode) bool {
 	if err != nil {
 		log.Warnf("Could not recover offset for file with path %v: %v", file.Path, err)
 	}
+	if file.Source.Config.TailRotatedFiles {
+		offset, whence = s.startRotatedTailer(file, tailer.Identifier(), mode, offset, whence)
+	}
 
 	log.Infof("Starting a new tailer for: %s (offset: %d, whence: %d) for tailer key %s", file.Path, offset, whence, file.GetScanKey())
 	err = tailer.Start(offset, whence)
@@ -382,9 +403,11 @@ func (s *Scanner) restartTailerAfterFileRotation(tailer *Tailer, file *File) boo
 
 // createTailer returns a new initialized tailer
 func (s *Scanner) createTailer(file *File, outputChan chan *message.Message) *Tailer {
-	return NewTailer(outputChan, file, s.tailerSleepDuration, NewDecoderFromSource(file.Source))
+	resolveEncoding(file, s.registry)
+	return NewTailer(outputChan, file, s.tailerSleepDuration, NewDecoderFromSourceWithEncoding(file.Source, file.Encoding, nil))
 }
 
 func (s *Scanner) createRotatedTailer(file *File, outputChan chan *message.Message, pattern *regexp.Regexp) *Tailer {
-	return NewTailer(outputChan, file, s.tailerSleepDuration, NewDecoderFromSourceWithPattern(file.Source, pattern))
+	resolveEncoding(file, s.registry)
+	return NewTailer(outputChan, file, s.tailerSleepDuration, NewDecoderFromSourceWithEncoding(file.Source, file.Encoding, pattern))
 }
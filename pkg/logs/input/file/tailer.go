@@ -67,6 +67,22 @@ func NewDecoderFromSource(source *config.LogSource) *decoder.Decoder {
 
 // NewDecoderFromSourceWithPattern creates a new decoder from a log source with a multiline pattern
 func NewDecoderFromSourceWithPattern(source *config.LogSource, multiLinePattern *regexp.Regexp) *decoder.Decoder {
+	return NewDecoderFromSourceWithEncoding(source, source.Config.Encoding, multiLinePattern)
+}
+
+// newLineEncodings are the encodings other than UTF-16 supported by the files,
+// their lines ending with the same new line byte as in UTF-8
+var newLineEncodings = map[string]parser.Encoding{
+	config.UTF8:        parser.UTF8,
+	config.ShiftJIS:    parser.ShiftJIS,
+	config.Latin1:      parser.Latin1,
+	config.Windows1252: parser.Windows1252,
+	config.GB18030:     parser.GB18030,
+}
+
+// NewDecoderFromSourceWithEncoding creates a new decoder from a log source decoding
+// the given encoding, that can differ from the configured one when it was detected.
+func NewDecoderFromSourceWithEncoding(source *config.LogSource, encoding string, multiLinePattern *regexp.Regexp) *decoder.Decoder {
 
 	// TODO: remove those checks and add to source a reference to a tagProvider and a lineParser.
 	var lineParser parser.Parser
@@ -84,7 +100,7 @@ func NewDecoderFromSourceWithPattern(source *config.LogSource, multiLinePattern
 		}
 		matcher = &decoder.NewLineMatcher{}
 	default:
-		switch source.Config.Encoding {
+		switch encoding {
 		case config.UTF16BE:
 			lineParser = parser.NewEncodedText(parser.UTF16BE)
 			matcher = decoder.NewBytesSequenceMatcher(decoder.Utf16beEOL)
@@ -93,6 +109,9 @@ func NewDecoderFromSourceWithPattern(source *config.LogSource, multiLinePattern
 			matcher = decoder.NewBytesSequenceMatcher(decoder.Utf16leEOL)
 		default:
 			lineParser = parser.Noop
+			if e, found := newLineEncodings[encoding]; found {
+				lineParser = parser.NewEncodedText(e)
+			}
 			matcher = &decoder.NewLineMatcher{}
 		}
 	}
@@ -135,7 +154,12 @@ func NewTailer(outputChan chan *message.Message, file *File, sleepDuration time.
 // where the dead container still has a tailer running on the log file, and the tailer
 // of the freshly spawned container starts tailing this file as well.
 func (t *Tailer) Identifier() string {
-	return fmt.Sprintf("file:%s", t.file.Path)
+	return fileIdentifier(t.file.Path)
+}
+
+// fileIdentifier returns the identifier of the file at path in the registry
+func fileIdentifier(path string) string {
+	return fmt.Sprintf("file:%s", path)
 }
 
 // Start let's the tailer open a file and tail from whence
@@ -184,9 +208,14 @@ func (t *Tailer) readForever() {
 
 // buildTailerTags groups the file tag, directory (if wildcard path) and user tags
 func (t *Tailer) buildTailerTags() []string {
-	tags := []string{fmt.Sprintf("filename:%s", filepath.Base(t.file.Path))}
-	if t.file.IsWildcardPath {
-		tags = append(tags, fmt.Sprintf("dirname:%s", filepath.Dir(t.file.Path)))
+	return fileTags(t.file)
+}
+
+// fileTags returns the file tag and the directory tag, if wildcard path, of a file
+func fileTags(file *File) []string {
+	tags := []string{fmt.Sprintf("filename:%s", filepath.Base(file.Path))}
+	if file.IsWildcardPath {
+		tags = append(tags, fmt.Sprintf("dirname:%s", filepath.Dir(file.Path)))
 	}
 	return tags
 }
@@ -202,6 +231,7 @@ func (t *Tailer) Stop() {
 	atomic.StoreInt32(&t.didFileRotate, 0)
 	t.stop <- struct{}{}
 	t.file.Source.RemoveInput(t.file.Path)
+	removeEncodingInfo(t.file)
 	// wait for the decoder to be flushed
 	<-t.done
 }
@@ -247,6 +277,7 @@ func (t *Tailer) forwardMessages() {
 		origin := message.NewOrigin(t.file.Source)
 		origin.Identifier = identifier
 		origin.Offset = strconv.FormatInt(offset, 10)
+		origin.Encoding = t.file.Encoding
 		origin.SetTags(append(t.tags, t.tagProvider.GetTags()...))
 		// Ignore empty lines once the registry offset is updated
 		if len(output.Content) == 0 {
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build systemd
+
+package journald
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// fieldMatcher matches the journal entries on their fields with FIELD=VALUE
+// expressions, the value being a glob pattern, e.g. "_COMM=ssh*". As with the
+// journal matches, the expressions on the same field are alternatives.
+type fieldMatcher struct {
+	patterns map[string][]string
+}
+
+// newFieldMatcher returns a matcher for the expressions, or nil if there are none.
+func newFieldMatcher(expressions []string) (*fieldMatcher, error) {
+	if len(expressions) == 0 {
+		return nil, nil
+	}
+	matcher := &fieldMatcher{patterns: make(map[string][]string)}
+	for _, expression := range expressions {
+		i := strings.Index(expression, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid match %q, should be FIELD=VALUE", expression)
+		}
+		field, pattern := expression[:i], expression[i+1:]
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid match %q: %v", expression, err)
+		}
+		matcher.patterns[field] = append(matcher.patterns[field], pattern)
+	}
+	return matcher, nil
+}
+
+// matchAll returns true if the entry matches one of the expressions of each
+// field, the entries missing a field never matching.
+func (m *fieldMatcher) matchAll(fields map[string]string) bool {
+	for field, patterns := range m.patterns {
+		value, exists := fields[field]
+		if !exists || !matchAnyPattern(patterns, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny returns true if the entry matches one of the expressions
+func (m *fieldMatcher) matchAny(fields map[string]string) bool {
+	for field, patterns := range m.patterns {
+		if value, exists := fields[field]; exists && matchAnyPattern(patterns, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldTag adds the value of a journal field to the tags of the entries
+type fieldTag struct {
+	field string
+	name  string
+}
+
+// parseFieldTags parses FIELD or FIELD:TAG_NAME expressions, the tag name
+// being by default the field in lower case without its leading underscores,
+// e.g. "_COMM" adds the tag "comm:<value>".
+func parseFieldTags(expressions []string) ([]fieldTag, error) {
+	var fieldTags []fieldTag
+	for _, expression := range expressions {
+		field, name := expression, ""
+		if i := strings.Index(expression, tagSeparator); i >= 0 {
+			field, name = expression[:i], expression[i+1:]
+		}
+		if name == "" {
+			name = strings.ToLower(strings.TrimLeft(field, "_"))
+		}
+		if field == "" || name == "" {
+			return nil, fmt.Errorf("invalid field tag %q, should be FIELD or FIELD:TAG_NAME", expression)
+		}
+		fieldTags = append(fieldTags, fieldTag{field: field, name: name})
+	}
+	return fieldTags, nil
+}
+
+// fieldTagsOf returns the tags added from the fields of an entry
+func (t *Tailer) fieldTagsOf(fields map[string]string) []string {
+	var tags []string
+	for _, fieldTag := range t.fieldTags {
+		if value, exists := fields[fieldTag.field]; exists && value != "" {
+			tags = append(tags, fieldTag.name+tagSeparator+value)
+		}
+	}
+	return tags
+}
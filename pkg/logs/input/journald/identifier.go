@@ -0,0 +1,30 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package journald
+
+// journaldIntegration represents the name of the integration,
+// it's used to override the source of the message and as a fingerprint to store the journal cursor.
+const journaldIntegration = "journald"
+
+// defaultJournalPath is the path of the default journal in the identifiers
+const defaultJournalPath = "default"
+
+// IdentifierPrefix is the prefix of the identifiers under which the auditor stores the journal cursors
+const IdentifierPrefix = journaldIntegration + ":"
+
+// Identifier returns the identifier under which the auditor stores the cursor
+// of the journal at path, the default journal having an empty path.
+func Identifier(path string) string {
+	return IdentifierPrefix + journalPath(path)
+}
+
+// journalPath returns the path of the journal, "default" for the default journal
+func journalPath(path string) string {
+	if path != "" {
+		return path
+	}
+	return defaultJournalPath
+}
@@ -32,6 +32,9 @@ type Tailer struct {
 	outputChan chan *message.Message
 	journal    *sdjournal.Journal
 	blacklist  map[string]bool
+	include    *fieldMatcher
+	exclude    *fieldMatcher
+	fieldTags  []fieldTag
 	stop       chan struct{}
 	done       chan struct{}
 }
@@ -104,6 +107,17 @@ func (t *Tailer) setup() error {
 		t.blacklist[unit] = true
 	}
 
+	// the field matches are applied by the tailer, their values being glob patterns
+	if t.include, err = newFieldMatcher(config.IncludeMatches); err != nil {
+		return err
+	}
+	if t.exclude, err = newFieldMatcher(config.ExcludeMatches); err != nil {
+		return err
+	}
+	if t.fieldTags, err = parseFieldTags(config.FieldTags); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -163,6 +177,12 @@ func (t *Tailer) tail() {
 // shouldDrop returns true if the entry should be dropped,
 // returns false otherwise.
 func (t *Tailer) shouldDrop(entry *sdjournal.JournalEntry) bool {
+	if t.include != nil && !t.include.matchAll(entry.Fields) {
+		return true
+	}
+	if t.exclude != nil && t.exclude.matchAny(entry.Fields) {
+		return true
+	}
 	unit, exists := entry.Fields[sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT]
 	if !exists {
 		return false
@@ -263,9 +283,9 @@ func (t *Tailer) getApplicationName(entry *sdjournal.JournalEntry, tags []string
 func (t *Tailer) getTags(entry *sdjournal.JournalEntry) []string {
 	var tags []string
 	if t.isContainerEntry(entry) {
-		tags = t.getContainerTags(t.getContainerID(entry))
+		tags = append(tags, t.getContainerTags(t.getContainerID(entry))...)
 	}
-	return tags
+	return append(tags, t.fieldTagsOf(entry.Fields)...)
 }
 
 // priorityStatusMapping represents the 1:1 mapping between journal entry priorities and statuses.
@@ -294,19 +314,12 @@ func (t *Tailer) getStatus(entry *sdjournal.JournalEntry) string {
 	return status
 }
 
-// journaldIntegration represents the name of the integration,
-// it's used to override the source of the message and as a fingerprint to store the journal cursor.
-const journaldIntegration = "journald"
-
 // Identifier returns the unique identifier of the current journal being tailed.
 func (t *Tailer) Identifier() string {
-	return journaldIntegration + ":" + t.journalPath()
+	return Identifier(t.source.Config.Path)
 }
 
 // journalPath returns the path of the journal
 func (t *Tailer) journalPath() string {
-	if t.source.Config.Path != "" {
-		return t.source.Config.Path
-	}
-	return "default"
+	return journalPath(t.source.Config.Path)
 }
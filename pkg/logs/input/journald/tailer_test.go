@@ -62,6 +62,78 @@ func TestShouldDropEntry(t *testing.T) {
 		}))
 }
 
+func TestShouldDropEntryOnFieldMatches(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{
+		IncludeMatches: []string{"PRIORITY=[0-3]", "_COMM=sshd", "_COMM=sudo*"},
+		ExcludeMatches: []string{"_HOSTNAME=test-*"},
+	})
+	tailer := NewTailer(source, nil)
+	err := tailer.setup()
+	assert.Nil(t, err)
+
+	assert.False(t, tailer.shouldDrop(
+		&sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_PRIORITY: "2",
+				sdjournal.SD_JOURNAL_FIELD_COMM:     "sudoedit",
+			},
+		}))
+
+	// the priority does not match
+	assert.True(t, tailer.shouldDrop(
+		&sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_PRIORITY: "6",
+				sdjournal.SD_JOURNAL_FIELD_COMM:     "sshd",
+			},
+		}))
+
+	// the command is missing
+	assert.True(t, tailer.shouldDrop(
+		&sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_PRIORITY: "2",
+			},
+		}))
+
+	// the hostname is excluded
+	assert.True(t, tailer.shouldDrop(
+		&sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_PRIORITY: "2",
+				sdjournal.SD_JOURNAL_FIELD_COMM:     "sshd",
+				sdjournal.SD_JOURNAL_FIELD_HOSTNAME: "test-1",
+			},
+		}))
+}
+
+func TestSetupFailsOnInvalidExpressions(t *testing.T) {
+	for _, logsConfig := range []*config.LogsConfig{
+		{IncludeMatches: []string{"PRIORITY"}},
+		{ExcludeMatches: []string{"=foo"}},
+		{IncludeMatches: []string{"_COMM=[ssh"}},
+		{FieldTags: []string{":comm"}},
+	} {
+		tailer := NewTailer(config.NewLogSource("", logsConfig), nil)
+		assert.NotNil(t, tailer.setup())
+	}
+}
+
+func TestFieldTags(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{FieldTags: []string{"_COMM", "_SYSTEMD_UNIT:unit", "MISSING"}})
+	tailer := NewTailer(source, nil)
+	err := tailer.setup()
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"comm:sshd", "unit:ssh.service"}, tailer.getTags(
+		&sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_COMM:         "sshd",
+				sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT: "ssh.service",
+			},
+		}))
+}
+
 func TestApplicationName(t *testing.T) {
 	source := config.NewLogSource("", &config.LogsConfig{})
 	tailer := NewTailer(source, nil)
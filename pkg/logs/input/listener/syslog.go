@@ -0,0 +1,249 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listener
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// syslogNilValue is the RFC 5424 NILVALUE of the header fields
+const syslogNilValue = "-"
+
+// utf8BOM may start the MSG part of RFC 5424 messages
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// syslogMessage is a syslog message parsed from an RFC 5424 or RFC 3164 frame
+type syslogMessage struct {
+	Facility       int                          `json:"facility"`
+	Severity       int                          `json:"severity"`
+	Version        int                          `json:"version,omitempty"`
+	Timestamp      time.Time                    `json:"-"`
+	Hostname       string                       `json:"hostname,omitempty"`
+	AppName        string                       `json:"appname,omitempty"`
+	ProcID         string                       `json:"procid,omitempty"`
+	MsgID          string                       `json:"msgid,omitempty"`
+	StructuredData map[string]map[string]string `json:"structured_data,omitempty"`
+	Message        []byte                       `json:"-"`
+}
+
+// severityStatusMapping represents the 1:1 mapping between syslog severities and statuses
+var severityStatusMapping = []string{
+	message.StatusEmergency,
+	message.StatusAlert,
+	message.StatusCritical,
+	message.StatusError,
+	message.StatusWarning,
+	message.StatusNotice,
+	message.StatusInfo,
+	message.StatusDebug,
+}
+
+// Status returns the status matching the severity of the message
+func (m *syslogMessage) Status() string {
+	return severityStatusMapping[m.Severity]
+}
+
+// parseSyslog parses an RFC 5424 frame, or an RFC 3164 one when there is no
+// version after the priority. It returns an error when the frame does not start
+// with a valid priority, RFC 3164 being too loose to detect any other error.
+func parseSyslog(frame []byte, now time.Time) (*syslogMessage, error) {
+	priority, rest, err := parseSyslogPriority(frame)
+	if err != nil {
+		return nil, err
+	}
+	msg := &syslogMessage{
+		Facility: priority / 8,
+		Severity: priority % 8,
+	}
+
+	if len(rest) >= 2 && rest[0] >= '1' && rest[0] <= '9' && (rest[1] == ' ' || (rest[1] >= '0' && rest[1] <= '9')) {
+		rfc5424 := *msg
+		if err := parseRFC5424(&rfc5424, rest); err == nil {
+			return &rfc5424, nil
+		}
+	}
+	parseRFC3164(msg, rest, now)
+	return msg, nil
+}
+
+// parseSyslogPriority parses the <PRI> header, between 0 and 191
+func parseSyslogPriority(frame []byte) (int, []byte, error) {
+	if len(frame) < 3 || frame[0] != '<' {
+		return 0, nil, fmt.Errorf("missing syslog priority")
+	}
+	end := bytes.IndexByte(frame[:min(len(frame), 5)], '>')
+	if end < 2 {
+		return 0, nil, fmt.Errorf("invalid syslog priority")
+	}
+	priority, err := strconv.Atoi(string(frame[1:end]))
+	if err != nil || priority < 0 || priority > 191 {
+		return 0, nil, fmt.Errorf("invalid syslog priority %q", frame[1:end])
+	}
+	return priority, frame[end+1:], nil
+}
+
+// parseRFC5424 parses VERSION SP TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]
+func parseRFC5424(msg *syslogMessage, data []byte) error {
+	var fields [6]string
+	for i := range fields {
+		end := bytes.IndexByte(data, ' ')
+		if end <= 0 {
+			return fmt.Errorf("truncated RFC 5424 header")
+		}
+		fields[i] = string(data[:end])
+		data = data[end+1:]
+	}
+
+	version, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return err
+	}
+	msg.Version = version
+	if fields[1] != syslogNilValue {
+		timestamp, err := time.Parse(time.RFC3339Nano, fields[1])
+		if err != nil {
+			return err
+		}
+		msg.Timestamp = timestamp
+	}
+	msg.Hostname = nilValueToEmpty(fields[2])
+	msg.AppName = nilValueToEmpty(fields[3])
+	msg.ProcID = nilValueToEmpty(fields[4])
+	msg.MsgID = nilValueToEmpty(fields[5])
+
+	structuredData, rest, err := parseStructuredData(data)
+	if err != nil {
+		return err
+	}
+	msg.StructuredData = structuredData
+	if len(rest) > 0 && rest[0] == ' ' {
+		rest = rest[1:]
+	}
+	msg.Message = bytes.TrimPrefix(rest, utf8BOM)
+	return nil
+}
+
+func nilValueToEmpty(value string) string {
+	if value == syslogNilValue {
+		return ""
+	}
+	return value
+}
+
+// parseStructuredData parses the NILVALUE or the [SD-ID PARAM-NAME="PARAM-VALUE" ...] elements
+func parseStructuredData(data []byte) (map[string]map[string]string, []byte, error) {
+	if len(data) > 0 && data[0] == '-' {
+		return nil, data[1:], nil
+	}
+	structuredData := make(map[string]map[string]string)
+	for len(data) > 0 && data[0] == '[' {
+		data = data[1:]
+		end := bytes.IndexAny(data, " ]")
+		if end <= 0 {
+			return nil, nil, fmt.Errorf("invalid structured data element")
+		}
+		params := make(map[string]string)
+		structuredData[string(data[:end])] = params
+		data = data[end:]
+
+		for len(data) > 0 && data[0] == ' ' {
+			data = data[1:]
+			eq := bytes.Index(data, []byte(`="`))
+			if eq <= 0 {
+				return nil, nil, fmt.Errorf("invalid structured data parameter")
+			}
+			name := string(data[:eq])
+			value, rest, err := parseParamValue(data[eq+2:])
+			if err != nil {
+				return nil, nil, err
+			}
+			params[name] = value
+			data = rest
+		}
+		if len(data) == 0 || data[0] != ']' {
+			return nil, nil, fmt.Errorf("unterminated structured data element")
+		}
+		data = data[1:]
+	}
+	if len(structuredData) == 0 {
+		return nil, nil, fmt.Errorf("invalid structured data")
+	}
+	return structuredData, data, nil
+}
+
+// parseParamValue reads a parameter value up to its closing quote, unescaping \", \\ and \]
+func parseParamValue(data []byte) (string, []byte, error) {
+	var value []byte
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '\\' && i+1 < len(data) && (data[i+1] == '"' || data[i+1] == '\\' || data[i+1] == ']'):
+			value = append(value, data[i+1])
+			i++
+		case c == '"':
+			return string(value), data[i+1:], nil
+		default:
+			value = append(value, c)
+		}
+	}
+	return "", nil, fmt.Errorf("unterminated structured data parameter value")
+}
+
+// rfc3164TimestampLayout is the "Mmm dd hh:mm:ss" timestamp of RFC 3164, the
+// day being padded with a space
+const rfc3164TimestampLayout = time.Stamp
+
+// parseRFC3164 parses TIMESTAMP SP HOSTNAME SP TAG MSG, each part being optional
+// as many devices do not follow the RFC strictly.
+func parseRFC3164(msg *syslogMessage, data []byte, now time.Time) {
+	hasTimestamp := false
+	if len(data) >= len(rfc3164TimestampLayout) {
+		if timestamp, err := time.ParseInLocation(rfc3164TimestampLayout, string(data[:len(rfc3164TimestampLayout)]), now.Location()); err == nil {
+			// The year is not part of the timestamp, messages sent just before
+			// the new year are from the previous one.
+			timestamp = timestamp.AddDate(now.Year(), 0, 0)
+			if timestamp.After(now.Add(24 * time.Hour)) {
+				timestamp = timestamp.AddDate(-1, 0, 0)
+			}
+			msg.Timestamp = timestamp
+			hasTimestamp = true
+			data = bytes.TrimPrefix(data[len(rfc3164TimestampLayout):], []byte(" "))
+		}
+	}
+
+	// The hostname follows the timestamp, unless the sender omitted it and
+	// the next word is already the tag, ending with ':'.
+	if end := bytes.IndexByte(data, ' '); hasTimestamp && end > 0 && !isRFC3164Tag(data[:end]) {
+		msg.Hostname = string(data[:end])
+		data = data[end+1:]
+	}
+
+	if end := bytes.IndexByte(data, ' '); end > 0 && isRFC3164Tag(data[:end]) {
+		tag := data[:end-1]
+		if start := bytes.IndexByte(tag, '['); start > 0 && tag[len(tag)-1] == ']' {
+			msg.ProcID = string(tag[start+1 : len(tag)-1])
+			tag = tag[:start]
+		}
+		msg.AppName = string(tag)
+		data = data[end+1:]
+	}
+	msg.Message = data
+}
+
+func isRFC3164Tag(word []byte) bool {
+	return len(word) > 1 && word[len(word)-1] == ':'
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
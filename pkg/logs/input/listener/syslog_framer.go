@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listener
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// syslogMaxFrameSize is the maximum size of a syslog frame, the bytes above
+// being dropped.
+const syslogMaxFrameSize = 256 * 1000
+
+// syslogFramer splits the data received on a connection into syslog frames.
+// Over TCP, frames use either the octet-counting framing, "MSG-LEN SP SYSLOG-MSG",
+// or the non-transparent framing ending frames with a line feed (RFC 6587),
+// a sender possibly mixing both. Over UDP, each datagram is a frame (RFC 5426).
+type syslogFramer struct {
+	datagram     bool
+	maxFrameSize int
+	buf          []byte
+	// skip is the number of bytes of a truncated octet-counted frame left to drop
+	skip int
+}
+
+func newSyslogFramer(datagram bool, maxFrameSize int) *syslogFramer {
+	return &syslogFramer{
+		datagram:     datagram,
+		maxFrameSize: maxFrameSize,
+	}
+}
+
+// frames returns the complete frames received so far, buffering the partial one
+func (f *syslogFramer) frames(data []byte) [][]byte {
+	if f.datagram {
+		frame := bytes.TrimRight(data, "\r\n")
+		if len(frame) == 0 {
+			return nil
+		}
+		return [][]byte{frame}
+	}
+
+	if f.skip > 0 {
+		n := min(f.skip, len(data))
+		f.skip -= n
+		data = data[n:]
+	}
+	f.buf = append(f.buf, data...)
+
+	var frames [][]byte
+	buf := f.buf
+	for {
+		buf = bytes.TrimLeft(buf, "\r\n")
+		if len(buf) == 0 {
+			break
+		}
+		frame, rest, ok := f.nextFrame(buf)
+		if !ok {
+			break
+		}
+		if len(frame) > 0 {
+			frames = append(frames, append([]byte(nil), frame...))
+		}
+		buf = rest
+	}
+	f.buf = append(f.buf[:0], buf...)
+	return frames
+}
+
+// nextFrame returns the first frame of buf and the bytes after it, ok being
+// false when the frame is not complete yet.
+func (f *syslogFramer) nextFrame(buf []byte) ([]byte, []byte, bool) {
+	if length, start, isOctetCounted := octetCount(buf); isOctetCounted {
+		if length > f.maxFrameSize {
+			if len(buf)-start < f.maxFrameSize {
+				return nil, nil, false
+			}
+			if len(buf)-start >= length {
+				return buf[start : start+f.maxFrameSize], buf[start+length:], true
+			}
+			f.skip = length - (len(buf) - start)
+			return buf[start : start+f.maxFrameSize], nil, true
+		}
+		if len(buf)-start < length {
+			return nil, nil, false
+		}
+		return buf[start : start+length], buf[start+length:], true
+	}
+
+	if end := bytes.IndexByte(buf, '\n'); end >= 0 && end <= f.maxFrameSize {
+		return bytes.TrimRight(buf[:end], "\r"), buf[end+1:], true
+	}
+	if len(buf) >= f.maxFrameSize {
+		return buf[:f.maxFrameSize], buf[f.maxFrameSize:], true
+	}
+	return nil, nil, false
+}
+
+// octetCount parses the MSG-LEN SP prefix of an octet-counted frame, returning
+// the length of the message and the position of its first byte.
+func octetCount(buf []byte) (int, int, bool) {
+	const maxDigits = 10
+	if buf[0] < '1' || buf[0] > '9' {
+		return 0, 0, false
+	}
+	for i := 1; i < len(buf) && i <= maxDigits; i++ {
+		switch {
+		case buf[i] == ' ':
+			// the syslog message starts with its priority
+			if i+1 < len(buf) && buf[i+1] != '<' {
+				return 0, 0, false
+			}
+			length, err := strconv.Atoi(string(buf[:i]))
+			return length, i + 1, err == nil
+		case buf[i] < '0' || buf[i] > '9':
+			return 0, 0, false
+		}
+	}
+	return 0, 0, false
+}
+
+// flush returns the bytes buffered, when the connection is closed
+func (f *syslogFramer) flush() []byte {
+	frame := bytes.TrimRight(f.buf, "\r\n")
+	f.buf = nil
+	f.skip = 0
+	if len(frame) == 0 {
+		return nil
+	}
+	return frame
+}
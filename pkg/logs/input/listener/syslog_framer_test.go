@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listener
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func framesToStrings(frames [][]byte) []string {
+	var s []string
+	for _, frame := range frames {
+		s = append(s, string(frame))
+	}
+	return s
+}
+
+func TestSyslogFramerStream(t *testing.T) {
+	framer := newSyslogFramer(false, 100)
+
+	assert.Equal(t, []string{"<13>a", "<13>b"}, framesToStrings(framer.frames([]byte("<13>a\n<13>b\r\n<13>c"))))
+	assert.Equal(t, []string{"<13>c", "<14>multi\nline"}, framesToStrings(framer.frames([]byte("\n14 <14>multi\nline"))))
+
+	// octet-counted frames split across reads
+	assert.Empty(t, framer.frames([]byte("9")))
+	assert.Empty(t, framer.frames([]byte(" <13>he")))
+	assert.Equal(t, []string{"<13>hello", "<13>world"}, framesToStrings(framer.frames([]byte("llo9 <13>world"))))
+
+	// a line starting with digits is not octet-counted
+	assert.Equal(t, []string{"12 apples"}, framesToStrings(framer.frames([]byte("12 apples\n"))))
+
+	assert.Empty(t, framer.frames([]byte("<13>partial")))
+	assert.Equal(t, "<13>partial", string(framer.flush()))
+	assert.Nil(t, framer.flush())
+}
+
+func TestSyslogFramerTruncatesFrames(t *testing.T) {
+	framer := newSyslogFramer(false, 10)
+
+	assert.Equal(t, []string{"<13>012345", "6789"}, framesToStrings(framer.frames([]byte("<13>0123456789\n"))))
+
+	// the end of an octet-counted frame bigger than the maximum is dropped
+	assert.Equal(t, []string{"<13>012345"}, framesToStrings(framer.frames([]byte("20 <13>0123456789"))))
+	assert.Empty(t, framer.frames([]byte("abc")))
+	assert.Equal(t, []string{"<13>next"}, framesToStrings(framer.frames([]byte("def<13>next\n"))))
+}
+
+func TestSyslogFramerDatagram(t *testing.T) {
+	framer := newSyslogFramer(true, 100)
+
+	assert.Equal(t, []string{"<13>multi\nline"}, framesToStrings(framer.frames([]byte("<13>multi\nline\n"))))
+	assert.Empty(t, framer.frames([]byte("\n")))
+	assert.Nil(t, framer.flush())
+}
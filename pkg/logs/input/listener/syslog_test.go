@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listener
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestParseSyslogRFC5424(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	msg, err := parseSyslog([]byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application \"x\" \]"][examplePriority@32473 class="high"] `+"\xEF\xBB\xBF"+`An application event`), now)
+	require.NoError(t, err)
+	assert.Equal(t, 20, msg.Facility)
+	assert.Equal(t, 5, msg.Severity)
+	assert.Equal(t, message.StatusNotice, msg.Status())
+	assert.Equal(t, 1, msg.Version)
+	assert.Equal(t, time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), msg.Timestamp.UTC())
+	assert.Equal(t, "mymachine.example.com", msg.Hostname)
+	assert.Equal(t, "evntslog", msg.AppName)
+	assert.Equal(t, "1234", msg.ProcID)
+	assert.Equal(t, "ID47", msg.MsgID)
+	assert.Equal(t, map[string]map[string]string{
+		"exampleSDID@32473":     {"iut": "3", "eventSource": `Application "x" ]`},
+		"examplePriority@32473": {"class": "high"},
+	}, msg.StructuredData)
+	assert.Equal(t, "An application event", string(msg.Message))
+
+	msg, err = parseSyslog([]byte(`<34>1 - - - - - -`), now)
+	require.NoError(t, err)
+	assert.Equal(t, message.StatusCritical, msg.Status())
+	assert.True(t, msg.Timestamp.IsZero())
+	assert.Empty(t, msg.Hostname)
+	assert.Empty(t, msg.AppName)
+	assert.Nil(t, msg.StructuredData)
+	assert.Empty(t, msg.Message)
+}
+
+func TestParseSyslogRFC3164(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	msg, err := parseSyslog([]byte(`<34>Oct 11 22:14:15 mymachine su[42]: 'su root' failed for lonvick on /dev/pts/8`), now)
+	require.NoError(t, err)
+	assert.Equal(t, 4, msg.Facility)
+	assert.Equal(t, message.StatusCritical, msg.Status())
+	assert.Equal(t, 0, msg.Version)
+	// October is later than June, the message is from the previous year
+	assert.Equal(t, time.Date(2020, 10, 11, 22, 14, 15, 0, time.UTC), msg.Timestamp)
+	assert.Equal(t, "mymachine", msg.Hostname)
+	assert.Equal(t, "su", msg.AppName)
+	assert.Equal(t, "42", msg.ProcID)
+	assert.Equal(t, "'su root' failed for lonvick on /dev/pts/8", string(msg.Message))
+
+	msg, err = parseSyslog([]byte(`<13>Feb  5 17:32:18 10.0.0.99 Use the BFG!`), now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 2, 5, 17, 32, 18, 0, time.UTC), msg.Timestamp)
+	assert.Equal(t, "10.0.0.99", msg.Hostname)
+	assert.Empty(t, msg.AppName)
+	assert.Equal(t, "Use the BFG!", string(msg.Message))
+
+	msg, err = parseSyslog([]byte(`<11>sshd: connection closed`), now)
+	require.NoError(t, err)
+	assert.Equal(t, message.StatusError, msg.Status())
+	assert.True(t, msg.Timestamp.IsZero())
+	assert.Empty(t, msg.Hostname)
+	assert.Equal(t, "sshd", msg.AppName)
+	assert.Equal(t, "connection closed", string(msg.Message))
+
+	// an invalid RFC 5424 header is parsed as RFC 3164
+	msg, err = parseSyslog([]byte(`<14>1 is the loneliest number`), now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, msg.Version)
+	assert.Equal(t, "1 is the loneliest number", string(msg.Message))
+}
+
+func TestParseSyslogShouldFailWithInvalidPriority(t *testing.T) {
+	for _, frame := range []string{"", "hello", "<>1 - - - - - -", "<192>hello", "<1a>hello", "<12345>hello"} {
+		_, err := parseSyslog([]byte(frame), time.Now())
+		assert.Error(t, err, frame)
+	}
+}
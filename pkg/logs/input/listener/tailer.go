@@ -6,8 +6,10 @@
 package listener
 
 import (
+	"encoding/json"
 	"io"
 	"net"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -24,20 +26,28 @@ type Tailer struct {
 	outputChan chan *message.Message
 	read       func(*Tailer) ([]byte, error)
 	decoder    *decoder.Decoder
-	stop       chan struct{}
-	done       chan struct{}
+	// syslogFramer is set when the source format is syslog, the frames being
+	// parsed instead of decoded as lines.
+	syslogFramer *syslogFramer
+	stop         chan struct{}
+	done         chan struct{}
 }
 
 // NewTailer returns a new Tailer
 func NewTailer(source *config.LogSource, conn net.Conn, outputChan chan *message.Message, read func(*Tailer) ([]byte, error)) *Tailer {
+	var framer *syslogFramer
+	if source.Config.Format == config.SyslogFormat {
+		framer = newSyslogFramer(source.Config.Type == config.UDPType, syslogMaxFrameSize)
+	}
 	return &Tailer{
-		source:     source,
-		conn:       conn,
-		outputChan: outputChan,
-		read:       read,
-		decoder:    decoder.InitializeDecoder(source, parser.Noop),
-		stop:       make(chan struct{}, 1),
-		done:       make(chan struct{}, 1),
+		source:       source,
+		conn:         conn,
+		outputChan:   outputChan,
+		read:         read,
+		decoder:      decoder.InitializeDecoder(source, parser.Noop),
+		syslogFramer: framer,
+		stop:         make(chan struct{}, 1),
+		done:         make(chan struct{}, 1),
 	}
 }
 
@@ -72,6 +82,11 @@ func (t *Tailer) forwardMessages() {
 func (t *Tailer) readForever() {
 	defer func() {
 		t.conn.Close()
+		if t.syslogFramer != nil {
+			if frame := t.syslogFramer.flush(); frame != nil {
+				t.outputChan <- t.syslogToMessage(frame)
+			}
+		}
 		t.decoder.Stop()
 	}()
 	for {
@@ -91,7 +106,70 @@ func (t *Tailer) readForever() {
 				return
 			}
 			t.source.BytesRead.Add(int64(len(data)))
+			if t.syslogFramer != nil {
+				for _, frame := range t.syslogFramer.frames(data) {
+					t.outputChan <- t.syslogToMessage(frame)
+				}
+				continue
+			}
 			t.decoder.InputChan <- decoder.NewInput(data)
 		}
 	}
 }
+
+// syslogToMessage parses a syslog frame into a message. Its content is a
+// json-string with the syslog header fields bundled in a "syslog" attribute,
+// the severity giving its status and the hostname and the application name
+// being added as tags. A frame that cannot be parsed is sent as it is.
+// ex:
+// * frame:
+//   <165>1 2003-10-11T22:14:15.003Z mymachine evntslog - ID47 [exampleSDID@32473 iut="3"] An application event
+// * message-content:
+//  {
+//    "message": "An application event",
+//    "syslog": {
+//      "facility": 20,
+//      "severity": 5,
+//      "version": 1,
+//      "hostname": "mymachine",
+//      "appname": "evntslog",
+//      "msgid": "ID47",
+//      "structured_data": {"exampleSDID@32473": {"iut": "3"}}
+//    }
+//  }
+func (t *Tailer) syslogToMessage(frame []byte) *message.Message {
+	now := time.Now()
+	syslogMsg, err := parseSyslog(frame, now)
+	if err != nil {
+		log.Debugf("Couldn't parse syslog message: %v", err)
+		return message.NewMessageWithSource(frame, message.StatusInfo, t.source, now.UnixNano())
+	}
+
+	content, err := json.Marshal(map[string]interface{}{
+		"message": string(syslogMsg.Message),
+		"syslog":  syslogMsg,
+	})
+	if err != nil {
+		// ensure the message has some content if the json encoding failed
+		content = syslogMsg.Message
+	}
+
+	origin := message.NewOrigin(t.source)
+	var tags []string
+	if syslogMsg.Hostname != "" {
+		tags = append(tags, "syslog_hostname:"+syslogMsg.Hostname)
+	}
+	if syslogMsg.AppName != "" {
+		tags = append(tags, "syslog_appname:"+syslogMsg.AppName)
+		// those values are still overridden by the integration config when defined
+		origin.SetSource(syslogMsg.AppName)
+		origin.SetService(syslogMsg.AppName)
+	}
+	origin.SetTags(tags)
+
+	msg := message.NewMessage(content, origin, syslogMsg.Status(), now.UnixNano())
+	if !syslogMsg.Timestamp.IsZero() {
+		msg.Timestamp = syslogMsg.Timestamp.UTC()
+	}
+	return msg
+}
@@ -6,11 +6,14 @@
 package listener
 
 import (
+	"encoding/json"
 	"errors"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -39,6 +42,38 @@ func TestReadAndForwardShouldSucceedWithSuccessfulRead(t *testing.T) {
 	tailer.Stop()
 }
 
+func TestReadAndForwardSyslogMessages(t *testing.T) {
+	msgChan := make(chan *message.Message)
+	r, w := net.Pipe()
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.TCPType, Format: config.SyslogFormat})
+	tailer := NewTailer(source, r, msgChan, read)
+	tailer.Start()
+
+	go w.Write([]byte("56 <11>1 2021-06-01T10:00:00Z web01 nginx 12 - - multi\nline<13>not syslog\n"))
+
+	msg := <-msgChan
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC), msg.Timestamp)
+	assert.Equal(t, []string{"syslog_hostname:web01", "syslog_appname:nginx"}, msg.Origin.Tags())
+	assert.Equal(t, "nginx", msg.Origin.Source())
+	assert.Equal(t, "nginx", msg.Origin.Service())
+
+	var content struct {
+		Message string                 `json:"message"`
+		Syslog  map[string]interface{} `json:"syslog"`
+	}
+	require.NoError(t, json.Unmarshal(msg.Content, &content))
+	assert.Equal(t, "multi\nline", content.Message)
+	assert.Equal(t, "web01", content.Syslog["hostname"])
+	assert.Equal(t, "12", content.Syslog["procid"])
+	assert.Equal(t, float64(1), content.Syslog["facility"])
+
+	msg = <-msgChan
+	assert.Equal(t, message.StatusNotice, msg.GetStatus())
+
+	tailer.Stop()
+}
+
 func TestReadShouldFailWithError(t *testing.T) {
 	msgChan := make(chan *message.Message)
 	r, w := net.Pipe()
@@ -72,6 +72,11 @@ func (m *Message) GetStatus() string {
 	return m.status
 }
 
+// SetStatus sets the status of the message.
+func (m *Message) SetStatus(status string) {
+	m.status = status
+}
+
 // GetLatency returns the latency delta from ingestion time until now
 func (m *Message) GetLatency() int64 {
 	return time.Now().UnixNano() - m.IngestionTimestamp
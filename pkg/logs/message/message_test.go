@@ -21,6 +21,9 @@ func TestMessage(t *testing.T) {
 	assert.Equal(t, "world", string(message.Content))
 	assert.Equal(t, StatusInfo, message.GetStatus())
 
+	message.SetStatus(StatusError)
+	assert.Equal(t, StatusError, message.GetStatus())
+
 }
 
 func TestGetHostnameLambda(t *testing.T) {
@@ -16,6 +16,7 @@ type Origin struct {
 	Identifier string
 	LogSource  *config.LogSource
 	Offset     string
+	Encoding   string
 	service    string
 	source     string
 	tags       []string
@@ -88,6 +89,14 @@ func (o *Origin) SetTags(tags []string) {
 	o.tags = tags
 }
 
+// AddTags adds tags to the ones of the origin.
+func (o *Origin) AddTags(tags []string) {
+	// copy the tags as they may be shared with other origins
+	newTags := make([]string, 0, len(o.tags)+len(tags))
+	newTags = append(newTags, o.tags...)
+	o.tags = append(newTags, tags...)
+}
+
 // SetSource sets the source of the origin.
 func (o *Origin) SetSource(source string) {
 	o.source = source
@@ -62,6 +62,23 @@ func TestSetTagsWithConfigTags(t *testing.T) {
 	assert.Equal(t, "[dd ddsource=\"a\"][dd ddsourcecategory=\"b\"][dd ddtags=\"c:d,e,foo:bar,baz\"]", string(origin.TagsPayload()))
 }
 
+func TestAddTags(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Tags: []string{"c:d"}})
+	sharedTags := make([]string, 1, 2)
+	sharedTags[0] = "foo:bar"
+
+	origin := NewOrigin(source)
+	origin.SetTags(sharedTags)
+	origin.AddTags([]string{"baz:qux"})
+	assert.Equal(t, []string{"foo:bar", "baz:qux", "c:d"}, origin.Tags())
+
+	otherOrigin := NewOrigin(source)
+	otherOrigin.SetTags(sharedTags)
+	otherOrigin.AddTags([]string{"other"})
+	assert.Equal(t, []string{"foo:bar", "baz:qux", "c:d"}, origin.Tags())
+	assert.Equal(t, []string{"foo:bar", "other", "c:d"}, otherOrigin.Tags())
+}
+
 func TestDefaultSourceValueIsSourceFromConfig(t *testing.T) {
 	var cfg *config.LogsConfig
 	var source *config.LogSource
@@ -56,6 +56,26 @@ var (
 	// TlmSenderLatency a histogram of http sender latency (ms)
 	TlmSenderLatency = telemetry.NewHistogram("logs", "sender_latency",
 		nil, "Histogram of http sender latency in ms", []float64{10, 25, 50, 75, 100, 250, 500, 1000, 10000})
+	// DiskQueuePayloads is the number of payloads waiting in the on-disk queues of the senders
+	DiskQueuePayloads = expvar.Int{}
+	// TlmDiskQueuePayloads is the number of payloads waiting in the on-disk queues of the senders
+	TlmDiskQueuePayloads = telemetry.NewGauge("logs", "disk_queue_payloads",
+		nil, "Number of payloads waiting in the on-disk queues of the senders")
+	// DiskQueueBytes is the disk space used by the on-disk queues of the senders
+	DiskQueueBytes = expvar.Int{}
+	// TlmDiskQueueBytes is the disk space used by the on-disk queues of the senders
+	TlmDiskQueueBytes = telemetry.NewGauge("logs", "disk_queue_bytes",
+		nil, "Disk space used by the on-disk queues of the senders")
+	// DiskQueueDropped is the total number of payloads removed from the on-disk queues without being sent
+	DiskQueueDropped = expvar.Int{}
+	// TlmDiskQueueDropped is the total number of payloads removed from the on-disk queues without being sent
+	TlmDiskQueueDropped = telemetry.NewCounter("logs", "disk_queue_dropped",
+		[]string{"reason"}, "Total number of payloads removed from the on-disk queues without being sent")
+	// LogsDroppedByRule is the total number of logs dropped per sample or rate_limit processing rule
+	LogsDroppedByRule = expvar.Map{}
+	// TlmLogsDroppedByRule is the total number of logs dropped per sample or rate_limit processing rule
+	TlmLogsDroppedByRule = telemetry.NewCounter("logs", "dropped_by_rule",
+		[]string{"rule"}, "Total number of logs dropped per sample or rate_limit processing rule")
 	// TODO: Add LogsCollected for the total number of collected logs.
 
 )
@@ -70,4 +90,8 @@ func init() {
 	LogsExpvars.Set("BytesSent", &BytesSent)
 	LogsExpvars.Set("EncodedBytesSent", &EncodedBytesSent)
 	LogsExpvars.Set("SenderLatency", &SenderLatency)
+	LogsExpvars.Set("DiskQueuePayloads", &DiskQueuePayloads)
+	LogsExpvars.Set("DiskQueueBytes", &DiskQueueBytes)
+	LogsExpvars.Set("DiskQueueDropped", &DiskQueueDropped)
+	LogsExpvars.Set("LogsDroppedByRule", &LogsDroppedByRule)
 }
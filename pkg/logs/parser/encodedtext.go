@@ -2,6 +2,9 @@ package parser
 
 import (
 	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
 )
@@ -14,6 +17,16 @@ const (
 	UTF16LE Encoding = iota
 	// UTF16BE UTF16 big endian
 	UTF16BE
+	// UTF8 UTF8 with a byte order mark to strip
+	UTF8
+	// ShiftJIS Shift JIS, japanese
+	ShiftJIS
+	// Latin1 ISO 8859-1, western european
+	Latin1
+	// Windows1252 Windows code page 1252, western european
+	Windows1252
+	// GB18030 GB 18030, simplified chinese
+	GB18030
 )
 
 // EncodedText a parser for decoding encoded logfiles.  It treats each input
@@ -43,6 +56,16 @@ func NewEncodedText(e Encoding) *EncodedText {
 		enc = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
 	case UTF16BE:
 		enc = unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case UTF8:
+		enc = unicode.UTF8BOM
+	case ShiftJIS:
+		enc = japanese.ShiftJIS
+	case Latin1:
+		enc = charmap.ISO8859_1
+	case Windows1252:
+		enc = charmap.Windows1252
+	case GB18030:
+		enc = simplifiedchinese.GB18030
 	}
 	p.decoder = enc.NewDecoder()
 	return p
@@ -50,3 +50,36 @@ func TestUTF16BEParserHandleMessages(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "Foo", string(msg))
 }
+
+func TestUTF8ParserStripsByteOrderMark(t *testing.T) {
+	parser := NewEncodedText(UTF8)
+	msg, _, _, _, err := parser.Parse([]byte{0xEF, 0xBB, 0xBF, 'F', 'o', 'o'})
+	assert.Nil(t, err)
+	assert.Equal(t, "Foo", string(msg))
+
+	msg, _, _, _, err = parser.Parse([]byte("Foo"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Foo", string(msg))
+}
+
+func TestLegacyEncodingsParserHandleMessages(t *testing.T) {
+	for _, test := range []struct {
+		encoding Encoding
+		input    []byte
+		expected string
+	}{
+		// こんにちは
+		{ShiftJIS, []byte{0x82, 0xb1, 0x82, 0xf1, 0x82, 0xc9, 0x82, 0xbf, 0x82, 0xcd}, "こんにちは"},
+		// café
+		{Latin1, []byte{'c', 'a', 'f', 0xe9}, "café"},
+		// 5€
+		{Windows1252, []byte{'5', 0x80}, "5€"},
+		// 你好
+		{GB18030, []byte{0xc4, 0xe3, 0xba, 0xc3}, "你好"},
+	} {
+		parser := NewEncodedText(test.encoding)
+		msg, _, _, _, err := parser.Parse(test.input)
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, string(msg))
+	}
+}
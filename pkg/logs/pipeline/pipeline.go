@@ -7,9 +7,12 @@ package pipeline
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
+	"github.com/DataDog/datadog-agent/pkg/logs/client/local"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/tcp"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
@@ -33,22 +36,33 @@ func NewPipeline(outputChan chan *message.Message, processingRules []*config.Pro
 
 	senderChan := make(chan *message.Message, config.ChanSize)
 
+	// The routed endpoints are fed by a routing sender in front of the main sender.
+	routes := getRoutes(endpoints, destinationsContext, pipelineID)
+	mainSenderChan := senderChan
+	if len(routes) > 0 {
+		mainSenderChan = make(chan *message.Message, config.ChanSize)
+	}
+
 	var logSender sender.Sender
 
 	// If there is a reliable additional endpoint - we are dual-shipping so we need to spawn an additional sender.
 	if reliableAdditionalDestinations != nil {
-		mainSender := sender.NewSingleSender(make(chan *message.Message, config.ChanSize), outputChan, mainDestinations, getStrategy(endpoints, serverless, pipelineID))
-		additionalSender := sender.NewSingleSender(make(chan *message.Message, config.ChanSize), outputChan, reliableAdditionalDestinations, getStrategy(endpoints, serverless, pipelineID))
+		mainSender := sender.NewSingleSenderWithDiskQueue(make(chan *message.Message, config.ChanSize), outputChan, mainDestinations, getStrategy(endpoints, serverless, pipelineID), getDiskQueue(endpoints, serverless, fmt.Sprintf("main_%d", pipelineID)))
+		additionalSender := sender.NewSingleSenderWithDiskQueue(make(chan *message.Message, config.ChanSize), outputChan, reliableAdditionalDestinations, getStrategy(endpoints, serverless, pipelineID), getDiskQueue(endpoints, serverless, fmt.Sprintf("additional_%d", pipelineID)))
 
-		logSender = sender.NewDualSender(senderChan, mainSender, additionalSender)
+		logSender = sender.NewDualSender(mainSenderChan, mainSender, additionalSender)
 	} else {
-		logSender = sender.NewSingleSender(senderChan, outputChan, mainDestinations, getStrategy(endpoints, serverless, pipelineID))
+		logSender = sender.NewSingleSenderWithDiskQueue(mainSenderChan, outputChan, mainDestinations, getStrategy(endpoints, serverless, pipelineID), getDiskQueue(endpoints, serverless, fmt.Sprintf("main_%d", pipelineID)))
+	}
+
+	if len(routes) > 0 {
+		logSender = sender.NewRoutingSender(senderChan, mainSenderChan, logSender, routes)
 	}
 
 	var encoder processor.Encoder
 	if serverless {
 		encoder = processor.JSONServerlessEncoder
-	} else if endpoints.UseHTTP {
+	} else if endpoints.UseHTTP || endpoints.Main.IsLocal() {
 		encoder = processor.JSONEncoder
 	} else if endpoints.UseProto {
 		encoder = processor.ProtoEncoder
@@ -85,6 +99,9 @@ func (p *Pipeline) Flush(ctx context.Context) {
 }
 
 func getMainDestinations(endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) *client.Destinations {
+	if endpoints.Main.IsLocal() {
+		return client.NewDestinations(local.NewDestination(endpoints.Main, destinationsContext), []client.Destination{})
+	}
 	if endpoints.UseHTTP {
 		main := http.NewDestination(endpoints.Main, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend)
 		additionals := []client.Destination{}
@@ -120,9 +137,51 @@ func getReliableAdditionalDestinations(endpoints *config.Endpoints, destinations
 	return client.NewDestinations(backup, []client.Destination{})
 }
 
+// getRoutes returns a route per routed endpoint, sending the logs encoded for
+// the main endpoint over the protocol of the routed endpoint or writing them locally.
+func getRoutes(endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, pipelineID int) []*sender.Route {
+	var routes []*sender.Route
+	for _, endpoint := range endpoints.GetRoutedAdditionals() {
+		var destination client.Destination
+		var strategy sender.Strategy
+		switch endpoint.Type {
+		case config.EndpointTypeHTTP:
+			destination = http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend)
+			strategy = sender.NewBatchStrategy(sender.ArraySerializer, endpoints.BatchWait, endpoints.BatchMaxConcurrentSend, endpoints.BatchMaxSize, endpoints.BatchMaxContentSize, "logs_route_"+endpoint.Host, pipelineID)
+		case config.EndpointTypeFile, config.EndpointTypeStdout:
+			destination = local.NewDestination(endpoint, destinationsContext)
+			strategy = sender.StreamStrategy
+		default:
+			destination = tcp.NewDestination(endpoint, endpoints.UseProto, destinationsContext)
+			strategy = sender.StreamStrategy
+		}
+		routes = append(routes, sender.NewRoute(endpoint, destination, strategy))
+	}
+	return routes
+}
+
 func getStrategy(endpoints *config.Endpoints, serverless bool, pipelineID int) sender.Strategy {
+	if endpoints.Main.IsLocal() {
+		// one JSON object per line
+		return sender.NewBatchStrategy(sender.LineSerializer, endpoints.BatchWait, endpoints.BatchMaxConcurrentSend, endpoints.BatchMaxSize, endpoints.BatchMaxContentSize, "logs", pipelineID)
+	}
 	if endpoints.UseHTTP || serverless {
 		return sender.NewBatchStrategy(sender.ArraySerializer, endpoints.BatchWait, endpoints.BatchMaxConcurrentSend, endpoints.BatchMaxSize, endpoints.BatchMaxContentSize, "logs", pipelineID)
 	}
 	return sender.StreamStrategy
 }
+
+// getDiskQueue returns the disk queue of a sender, or nil if the disk queues
+// are disabled. Only the batched HTTP payloads can be spilled on disk.
+func getDiskQueue(endpoints *config.Endpoints, serverless bool, name string) *sender.DiskQueue {
+	settings := config.DiskQueue()
+	if settings == nil || !endpoints.UseHTTP || serverless {
+		return nil
+	}
+	diskQueue, err := sender.NewDiskQueue(filepath.Join(settings.Path, name), settings.MaxSizeInBytes, settings.MaxDiskRatio, settings.MaxAge)
+	// If the disk queue cannot be used, log the error and keep buffering the payloads in memory.
+	if err != nil {
+		log.Errorf("Error when creating the logs disk queue: %v", err)
+	}
+	return diskQueue
+}
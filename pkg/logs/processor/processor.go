@@ -8,6 +8,7 @@ package processor
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -86,41 +87,112 @@ func (p *Processor) run() {
 func (p *Processor) processMessage(msg *message.Message) {
 	metrics.LogsDecoded.Add(1)
 	metrics.TlmLogsDecoded.Inc()
-	if shouldProcess, redactedMsg := p.applyRedactingRules(msg); shouldProcess {
-		metrics.LogsProcessed.Add(1)
-		metrics.TlmLogsProcessed.Inc()
+	p.diagnosticMessageReceiver.HandleReceivedMessage(*msg)
 
-		p.diagnosticMessageReceiver.HandleMessage(*msg, redactedMsg)
+	redactedMsg, droppedBy := p.applyProcessingRules(msg)
+	if droppedBy != nil {
+		p.diagnosticMessageReceiver.HandleDroppedMessage(*msg, droppedBy.Name)
+		return
+	}
+	metrics.LogsProcessed.Add(1)
+	metrics.TlmLogsProcessed.Inc()
 
-		// Encode the message to its final format
-		content, err := p.encoder.Encode(msg, redactedMsg)
-		if err != nil {
-			log.Error("unable to encode msg ", err)
-			return
-		}
-		msg.Content = content
-		p.outputChan <- msg
+	p.diagnosticMessageReceiver.HandleMessage(*msg, redactedMsg)
+
+	// Encode the message to its final format
+	content, err := p.encoder.Encode(msg, redactedMsg)
+	if err != nil {
+		log.Error("unable to encode msg ", err)
+		return
 	}
+	msg.Content = content
+	p.outputChan <- msg
 }
 
 // applyRedactingRules returns given a message if we should process it or not,
-// and a copy of the message with some fields redacted, depending on config
+// and a copy of the message with some fields redacted, depending on config.
 func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
+	content, droppedBy := p.applyProcessingRules(msg)
+	if droppedBy != nil {
+		return false, nil
+	}
+	return true, content
+}
+
+// applyProcessingRules returns the content of a message with some fields redacted,
+// depending on config, or the rule dropping the message if any.
+// The structured rules parse the content into attributes, the content returned
+// being then a JSON object of these attributes, and may update the status,
+// the timestamp and the tags of the message. The sampling rules drop a part
+// of the logs they apply to.
+func (p *Processor) applyProcessingRules(msg *message.Message) ([]byte, *config.ProcessingRule) {
 	content := msg.Content
+	var attributes map[string]interface{}
+	// contentIsJSON is set once the content has been parsed as a JSON object
+	contentIsJSON := false
+
 	rules := append(p.processingRules, msg.Origin.LogSource.Config.ProcessingRules...)
 	for _, rule := range rules {
 		switch rule.Type {
 		case config.ExcludeAtMatch:
-			if rule.Regex.Match(content) {
-				return false, nil
+			if ruleMatches(rule, content, attributes) {
+				return nil, rule
 			}
 		case config.IncludeAtMatch:
-			if !rule.Regex.Match(content) {
-				return false, nil
+			if !ruleMatches(rule, content, attributes) {
+				return nil, rule
+			}
+		case config.Sample, config.RateLimit:
+			if !keepSampled(rule, msg.Origin.LogSource.Name, content, attributes, time.Now()) {
+				return nil, rule
 			}
 		case config.MaskSequences:
 			content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+			maskAttributes(attributes, rule.Regex, rule.Placeholder)
+		case config.ParseJSONContent:
+			parsed, err := parseJSONAttributes(content)
+			if err != nil {
+				log.Tracef("Processing rule %s cannot parse the content as JSON: %v", rule.Name, err)
+				continue
+			}
+			attributes = mergeAttributes(attributes, parsed)
+			contentIsJSON = true
+		case config.ParseLogfmt:
+			attributes = mergeAttributes(attributes, parseKeyValueAttributes(content, "=", " ", true))
+		case config.ParseKeyValue:
+			attributes = mergeAttributes(attributes, parseKeyValueAttributes(content, rule.KeyValueSeparator, rule.PairSeparator, false))
+		case config.ExtractTags:
+			input := string(content)
+			if rule.Attribute != "" {
+				value, found := lookupAttribute(attributes, rule.Attribute)
+				if !found {
+					continue
+				}
+				input = attributeString(value)
+			}
+			if tags := extractTags(rule.Regex, input); len(tags) > 0 {
+				msg.Origin.AddTags(tags)
+			}
+		case config.RemapStatus:
+			if value, found := lookupAttribute(attributes, rule.Attribute); found {
+				if status, ok := statusFromAttribute(value); ok {
+					msg.SetStatus(status)
+				}
+			}
+		case config.ExtractTimestamp:
+			if value, found := lookupAttribute(attributes, rule.Attribute); found {
+				timestamp, err := timestampFromAttribute(value, rule.TimestampFormat)
+				if err != nil {
+					log.Tracef("Processing rule %s cannot parse the timestamp %v: %v", rule.Name, value, err)
+					continue
+				}
+				msg.Timestamp = timestamp.UTC()
+			}
 		}
 	}
-	return true, content
+
+	if attributes != nil {
+		content = encodeAttributes(content, attributes, contentIsJSON)
+	}
+	return content, nil
 }
@@ -6,11 +6,15 @@
 package processor
 
 import (
+	"encoding/json"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -138,6 +142,177 @@ func TestTruncate(t *testing.T) {
 	assert.Equal(t, []byte("hello"), redactedMessage)
 }
 
+func TestStructuredRules(t *testing.T) {
+	p := &Processor{}
+
+	rules := []*config.ProcessingRule{
+		{Name: "json", Type: config.ParseJSONContent},
+		{Name: "exclude_health", Type: config.ExcludeAtMatch, Attribute: "http.path", Pattern: "^/health$"},
+		{Name: "status", Type: config.RemapStatus, Attribute: "level"},
+		{Name: "timestamp", Type: config.ExtractTimestamp, Attribute: "ts", TimestampFormat: config.TimestampFormatUnix},
+		{Name: "tags", Type: config.ExtractTags, Attribute: "http.path", Pattern: "^/(?P<api>\\w+)/"},
+		{Name: "mask", Type: config.MaskSequences, Pattern: "token=\\w+", ReplacePlaceholder: "token=[masked]"},
+	}
+	assert.NoError(t, config.ValidateProcessingRules(rules))
+	assert.NoError(t, config.CompileProcessingRules(rules))
+	source := config.NewLogSource("", &config.LogsConfig{ProcessingRules: rules})
+
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte(`{"level":"info","http":{"path":"/health"}}`), source, ""))
+	assert.False(t, shouldProcess)
+
+	msg := newMessage([]byte(`{"level":"error","ts":1622541600,"http":{"path":"/users/42?token=abc"},"message":"not found"}`), source, "")
+	shouldProcess, redactedMessage := p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC), msg.Timestamp)
+	assert.Equal(t, []string{"api:users"}, msg.Origin.Tags())
+
+	var attributes map[string]interface{}
+	assert.NoError(t, json.Unmarshal(redactedMessage, &attributes))
+	assert.Equal(t, "not found", attributes["message"])
+	assert.Equal(t, map[string]interface{}{"path": "/users/42?token=[masked]"}, attributes["http"])
+
+	// the content is sent as it is when no attribute can be parsed
+	msg = newMessage([]byte("not json"), source, "")
+	shouldProcess, redactedMessage = p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, []byte("not json"), redactedMessage)
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+}
+
+func TestLogfmtRules(t *testing.T) {
+	p := &Processor{}
+
+	rules := []*config.ProcessingRule{
+		{Name: "logfmt", Type: config.ParseLogfmt},
+		{Name: "include_api", Type: config.IncludeAtMatch, Attribute: "component", Pattern: "^api$"},
+		{Name: "status", Type: config.RemapStatus, Attribute: "level"},
+	}
+	assert.NoError(t, config.ValidateProcessingRules(rules))
+	assert.NoError(t, config.CompileProcessingRules(rules))
+	source := config.NewLogSource("", &config.LogsConfig{ProcessingRules: rules})
+
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte(`level=warn component=db msg="slow query"`), source, ""))
+	assert.False(t, shouldProcess)
+
+	// a missing attribute does not match the include rule
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`level=warn msg="slow query"`), source, ""))
+	assert.False(t, shouldProcess)
+
+	msg := newMessage([]byte(`level=warn component=api msg="slow request"`), source, "")
+	shouldProcess, redactedMessage := p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, message.StatusWarning, msg.GetStatus())
+
+	var attributes map[string]interface{}
+	assert.NoError(t, json.Unmarshal(redactedMessage, &attributes))
+	assert.Equal(t, map[string]interface{}{
+		"level":     "warn",
+		"component": "api",
+		"msg":       "slow request",
+		"message":   `level=warn component=api msg="slow request"`,
+	}, attributes)
+}
+
+func TestSamplingRules(t *testing.T) {
+	p := &Processor{}
+
+	rules := []*config.ProcessingRule{
+		{Name: "sample_requests", Type: config.Sample, KeepOneIn: 2, Pattern: `GET (/\w+)`},
+		{Name: "rate_limit_errors", Type: config.RateLimit, LinesPerSecond: 1, Pattern: "^ERROR"},
+	}
+	assert.NoError(t, config.ValidateProcessingRules(rules))
+	assert.NoError(t, config.CompileProcessingRules(rules))
+	source := config.NewLogSource("", &config.LogsConfig{ProcessingRules: rules})
+	defer metrics.LogsDroppedByRule.Init()
+
+	// each request path keeps its own rate
+	var kept []bool
+	for _, content := range []string{"GET /users", "GET /users", "GET /orders", "GET /users", "GET /users", "POST /users"} {
+		shouldProcess, _ := p.applyRedactingRules(newMessage([]byte(content), source, ""))
+		kept = append(kept, shouldProcess)
+	}
+	assert.Equal(t, []bool{true, false, true, true, false, true}, kept)
+
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("ERROR first"), source, ""))
+	assert.True(t, shouldProcess)
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte("ERROR second"), source, ""))
+	assert.False(t, shouldProcess)
+
+	assert.Equal(t, "2", metrics.LogsDroppedByRule.Get("sample_requests").String())
+	assert.Equal(t, "1", metrics.LogsDroppedByRule.Get("rate_limit_errors").String())
+}
+
+func TestSamplingKey(t *testing.T) {
+	rule := &config.ProcessingRule{Type: config.RateLimit, Attribute: "http.path", Regex: regexp.MustCompile(`^/(\w+)/(\w+)`)}
+	attributes := map[string]interface{}{"http": map[string]interface{}{"path": "/api/users/42"}}
+
+	key, applies := samplingKey(rule, "source", nil, attributes)
+	assert.True(t, applies)
+	assert.Equal(t, "api\x00users", key)
+
+	_, applies = samplingKey(rule, "source", nil, map[string]interface{}{"http": map[string]interface{}{"path": "/"}})
+	assert.False(t, applies)
+	_, applies = samplingKey(rule, "source", nil, nil)
+	assert.False(t, applies)
+
+	// without capture group, the rule keeps a rate per source
+	rule.Attribute = ""
+	rule.Regex = regexp.MustCompile("^GET")
+	key, applies = samplingKey(rule, "source", []byte("GET /"), nil)
+	assert.True(t, applies)
+	assert.Equal(t, "source", key)
+
+	rule.Regex = nil
+	key, applies = samplingKey(rule, "other", []byte("POST /"), nil)
+	assert.True(t, applies)
+	assert.Equal(t, "other", key)
+}
+
+func TestProcessMessageReportsStages(t *testing.T) {
+	receiver := diagnostic.NewBufferedMessageReceiver()
+	receiver.SetEnabled(true)
+	done := make(chan struct{})
+	defer close(done)
+	lines := receiver.Stream(&diagnostic.StreamOptions{
+		Filters: diagnostic.Filters{Stages: []diagnostic.Stage{diagnostic.Received, diagnostic.Processed, diagnostic.Dropped}},
+		JSON:    true,
+	}, done)
+
+	rules := []*config.ProcessingRule{
+		newProcessingRule(config.ExcludeAtMatch, "", "debug"),
+		newProcessingRule(config.MaskSequences, "[masked]", "secret"),
+	}
+	rules[0].Name = "exclude_debug"
+	outputChan := make(chan *message.Message, 2)
+	p := New(nil, outputChan, rules, RawEncoder, receiver)
+	source := config.LogSource{Config: &config.LogsConfig{}}
+
+	p.processMessage(newMessage([]byte("my secret"), &source, ""))
+	p.processMessage(newMessage([]byte("debug line"), &source, ""))
+	assert.Len(t, outputChan, 1)
+
+	expected := []struct {
+		stage   diagnostic.Stage
+		rule    string
+		message string
+	}{
+		{diagnostic.Received, "", "my secret"},
+		{diagnostic.Processed, "", "my [masked]"},
+		{diagnostic.Received, "", "debug line"},
+		{diagnostic.Dropped, "exclude_debug", "debug line"},
+	}
+	for _, e := range expected {
+		var line map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(<-lines), &line))
+		assert.Equal(t, string(e.stage), line["stage"])
+		assert.Equal(t, e.message, line["message"])
+		if e.rule != "" {
+			assert.Equal(t, e.rule, line["rule"])
+		}
+	}
+}
+
 func newProcessingRule(ruleType, replacePlaceholder, pattern string) *config.ProcessingRule {
 	return &config.ProcessingRule{
 		Type:               ruleType,
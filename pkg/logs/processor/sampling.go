@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+// samplingKeySeparator separates the capture groups of a sampling key
+const samplingKeySeparator = "\x00"
+
+// samplingKey returns the key a sample or a rate_limit rule keeps its own rate
+// for: the capture groups of its pattern when it has some, or the source name
+// otherwise. ok is false when the pattern does not match, the rule not
+// applying to the log.
+func samplingKey(rule *config.ProcessingRule, sourceName string, content []byte, attributes map[string]interface{}) (string, bool) {
+	if rule.Regex == nil {
+		return sourceName, true
+	}
+
+	input := content
+	if rule.Attribute != "" {
+		value, found := lookupAttribute(attributes, rule.Attribute)
+		if !found {
+			return "", false
+		}
+		input = []byte(attributeString(value))
+	}
+	match := rule.Regex.FindSubmatch(input)
+	if match == nil {
+		return "", false
+	}
+	if len(match) == 1 {
+		return sourceName, true
+	}
+	groups := make([]string, len(match)-1)
+	for i, group := range match[1:] {
+		groups[i] = string(group)
+	}
+	return strings.Join(groups, samplingKeySeparator), true
+}
+
+// keepSampled returns true if a sample or a rate_limit rule keeps the log,
+// counting the logs it drops.
+func keepSampled(rule *config.ProcessingRule, sourceName string, content []byte, attributes map[string]interface{}, now time.Time) bool {
+	key, applies := samplingKey(rule, sourceName, content, attributes)
+	if !applies || rule.Sampler.Keep(key, now) {
+		return true
+	}
+	metrics.LogsDroppedByRule.Add(rule.Name, 1)
+	metrics.TlmLogsDroppedByRule.Inc(rule.Name)
+	return false
+}
@@ -0,0 +1,270 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// messageAttribute is the attribute holding the raw content of a message
+// parsed with logfmt or key-value rules
+const messageAttribute = "message"
+
+// parseJSONAttributes parses a content holding a JSON object
+func parseJSONAttributes(content []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	// keep the numbers as they are written, e.g. for big integers
+	decoder.UseNumber()
+	var attributes map[string]interface{}
+	if err := decoder.Decode(&attributes); err != nil {
+		return nil, err
+	}
+	if attributes == nil {
+		return nil, fmt.Errorf("the content is not a JSON object")
+	}
+	return attributes, nil
+}
+
+// parseKeyValueAttributes parses the key-value pairs of a content, e.g.
+// `level=info msg="user logged in" user_id=42`. The values may be double-quoted
+// to contain separators, and the words that are not pairs are considered as
+// keys with a true value when bareKeys is set, as in logfmt, or ignored.
+func parseKeyValueAttributes(content []byte, keyValueSeparator string, pairSeparator string, bareKeys bool) map[string]interface{} {
+	attributes := make(map[string]interface{})
+	s := string(content)
+	for len(s) > 0 {
+		if strings.HasPrefix(s, pairSeparator) {
+			s = s[len(pairSeparator):]
+			continue
+		}
+
+		var pair string
+		pair, s = nextPair(s, keyValueSeparator, pairSeparator)
+		i := strings.Index(pair, keyValueSeparator)
+		switch {
+		case i > 0:
+			attributes[pair[:i]] = unquote(pair[i+len(keyValueSeparator):])
+		case i < 0 && bareKeys:
+			attributes[pair] = true
+		}
+	}
+	return attributes
+}
+
+// nextPair returns the first pair of s, up to the next separator outside of
+// a quoted value, and what follows it.
+func nextPair(s string, keyValueSeparator string, pairSeparator string) (string, string) {
+	inQuotes := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case inQuotes && s[i] == '\\':
+			i++
+		case s[i] == '"' && (inQuotes || strings.HasSuffix(s[:i], keyValueSeparator)):
+			inQuotes = !inQuotes
+		case !inQuotes && strings.HasPrefix(s[i:], pairSeparator):
+			return s[:i], s[i+len(pairSeparator):]
+		}
+	}
+	return s, ""
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// mergeAttributes adds the parsed attributes to the ones already parsed
+func mergeAttributes(attributes map[string]interface{}, parsed map[string]interface{}) map[string]interface{} {
+	if attributes == nil {
+		return parsed
+	}
+	for key, value := range parsed {
+		attributes[key] = value
+	}
+	return attributes
+}
+
+// lookupAttribute returns the value of an attribute, nested attributes being
+// separated with dots, e.g. "http.status_code".
+func lookupAttribute(attributes map[string]interface{}, path string) (interface{}, bool) {
+	if value, found := attributes[path]; found {
+		return value, true
+	}
+	i := strings.IndexByte(path, '.')
+	if i < 0 {
+		return nil, false
+	}
+	nested, ok := attributes[path[:i]].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupAttribute(nested, path[i+1:])
+}
+
+// attributeString returns the string representation of an attribute value
+func attributeString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ruleMatches returns true if the regex of the rule matches the content, or
+// the attribute of the rule when set. A missing attribute never matches.
+func ruleMatches(rule *config.ProcessingRule, content []byte, attributes map[string]interface{}) bool {
+	if rule.Attribute == "" {
+		return rule.Regex.Match(content)
+	}
+	value, found := lookupAttribute(attributes, rule.Attribute)
+	if !found {
+		return false
+	}
+	return rule.Regex.MatchString(attributeString(value))
+}
+
+// extractTags returns a tag per named capture group of the regex matching
+// the input, e.g. `user=(?P<user>\w+)` returns the tag `user:<name>`.
+func extractTags(re *regexp.Regexp, input string) []string {
+	match := re.FindStringSubmatch(input)
+	if match == nil {
+		return nil
+	}
+	var tags []string
+	for i, name := range re.SubexpNames() {
+		if name != "" && match[i] != "" {
+			tags = append(tags, name+":"+match[i])
+		}
+	}
+	return tags
+}
+
+// severityStatuses maps the syslog severities to statuses
+var severityStatuses = []string{
+	message.StatusEmergency,
+	message.StatusAlert,
+	message.StatusCritical,
+	message.StatusError,
+	message.StatusWarning,
+	message.StatusNotice,
+	message.StatusInfo,
+	message.StatusDebug,
+}
+
+// statusFromAttribute returns the status matching an attribute value, either
+// a syslog severity between 0 and 7 or a level name matched on its prefix,
+// e.g. "ERROR", "err" or "Fatal".
+func statusFromAttribute(value interface{}) (string, bool) {
+	s := strings.ToLower(strings.TrimSpace(attributeString(value)))
+	if severity, err := strconv.Atoi(s); err == nil {
+		if severity < 0 || severity >= len(severityStatuses) {
+			return "", false
+		}
+		return severityStatuses[severity], true
+	}
+
+	switch {
+	case s == "":
+		return "", false
+	case strings.HasPrefix(s, "emerg"), strings.HasPrefix(s, "f"), strings.HasPrefix(s, "panic"):
+		return message.StatusEmergency, true
+	case strings.HasPrefix(s, "a"):
+		return message.StatusAlert, true
+	case strings.HasPrefix(s, "c"):
+		return message.StatusCritical, true
+	case strings.HasPrefix(s, "e"):
+		return message.StatusError, true
+	case strings.HasPrefix(s, "w"):
+		return message.StatusWarning, true
+	case strings.HasPrefix(s, "n"):
+		return message.StatusNotice, true
+	case strings.HasPrefix(s, "i"), strings.HasPrefix(s, "o"), strings.HasPrefix(s, "s"):
+		return message.StatusInfo, true
+	case strings.HasPrefix(s, "d"), strings.HasPrefix(s, "t"), strings.HasPrefix(s, "v"):
+		return message.StatusDebug, true
+	}
+	return "", false
+}
+
+// timestampFromAttribute parses an attribute value with the timestamp format
+// of an extract_timestamp rule.
+func timestampFromAttribute(value interface{}, format string) (time.Time, error) {
+	s := strings.TrimSpace(attributeString(value))
+	switch format {
+	case "", config.TimestampFormatRFC3339:
+		return time.Parse(time.RFC3339Nano, s)
+	case config.TimestampFormatUnix, config.TimestampFormatUnixMs:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if format == config.TimestampFormatUnixMs {
+			f /= 1000
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	default:
+		return time.Parse(format, s)
+	}
+}
+
+// maskAttributes applies a mask_sequences rule to the string attributes
+func maskAttributes(attributes map[string]interface{}, re *regexp.Regexp, placeholder []byte) {
+	for key, value := range attributes {
+		attributes[key] = maskValue(value, re, placeholder)
+	}
+}
+
+func maskValue(value interface{}, re *regexp.Regexp, placeholder []byte) interface{} {
+	switch v := value.(type) {
+	case string:
+		return string(re.ReplaceAll([]byte(v), placeholder))
+	case map[string]interface{}:
+		maskAttributes(v, re, placeholder)
+	case []interface{}:
+		for i := range v {
+			v[i] = maskValue(v[i], re, placeholder)
+		}
+	}
+	return value
+}
+
+// encodeAttributes returns the content of a message with parsed attributes,
+// a JSON object holding the attributes and the content in the "message"
+// attribute unless the content is the JSON object parsed.
+func encodeAttributes(content []byte, attributes map[string]interface{}, contentIsJSON bool) []byte {
+	if _, found := attributes[messageAttribute]; !found && !contentIsJSON {
+		attributes[messageAttribute] = string(content)
+	}
+	encoded, err := json.Marshal(attributes)
+	if err != nil {
+		return content
+	}
+	return encoded
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestParseJSONAttributes(t *testing.T) {
+	attributes, err := parseJSONAttributes([]byte(`{"level":"warn","id":12345678901234567890,"http":{"status":500}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "warn", attributes["level"])
+	assert.Equal(t, json.Number("12345678901234567890"), attributes["id"])
+
+	value, found := lookupAttribute(attributes, "http.status")
+	assert.True(t, found)
+	assert.Equal(t, "500", attributeString(value))
+	_, found = lookupAttribute(attributes, "http.method")
+	assert.False(t, found)
+
+	for _, content := range []string{"not json", `["an", "array"]`, "null", ""} {
+		_, err = parseJSONAttributes([]byte(content))
+		assert.Error(t, err, content)
+	}
+}
+
+func TestParseKeyValueAttributes(t *testing.T) {
+	attributes := parseKeyValueAttributes([]byte(`level=info msg="user \"bob\" logged in" user_id=42 debug empty=`), "=", " ", true)
+	assert.Equal(t, map[string]interface{}{
+		"level":   "info",
+		"msg":     `user "bob" logged in`,
+		"user_id": "42",
+		"debug":   true,
+		"empty":   "",
+	}, attributes)
+
+	attributes = parseKeyValueAttributes([]byte(`method:GET, path:"/a, b", status:200, done`), ":", ", ", false)
+	assert.Equal(t, map[string]interface{}{
+		"method": "GET",
+		"path":   "/a, b",
+		"status": "200",
+	}, attributes)
+}
+
+func TestExtractTags(t *testing.T) {
+	re := regexp.MustCompile(`user=(?P<user>\w+) (?:team=(?P<team>\w+))?`)
+	assert.Equal(t, []string{"user:bob", "team:core"}, extractTags(re, "login user=bob team=core"))
+	assert.Equal(t, []string{"user:bob"}, extractTags(re, "login user=bob "))
+	assert.Nil(t, extractTags(re, "logout"))
+}
+
+func TestStatusFromAttribute(t *testing.T) {
+	for value, expected := range map[interface{}]string{
+		"ERROR":          message.StatusError,
+		"err":            message.StatusError,
+		"Fatal":          message.StatusEmergency,
+		"emerg":          message.StatusEmergency,
+		"crit":           message.StatusCritical,
+		"WARNING":        message.StatusWarning,
+		"notice":         message.StatusNotice,
+		"ok":             message.StatusInfo,
+		"trace":          message.StatusDebug,
+		json.Number("3"): message.StatusError,
+		"7":              message.StatusDebug,
+	} {
+		status, ok := statusFromAttribute(value)
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, status, value)
+	}
+
+	for _, value := range []interface{}{"", "8", json.Number("-1"), "?"} {
+		_, ok := statusFromAttribute(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestTimestampFromAttribute(t *testing.T) {
+	expected := time.Date(2021, 6, 1, 10, 0, 0, 500000000, time.UTC)
+
+	ts, err := timestampFromAttribute("2021-06-01T10:00:00.5Z", "")
+	require.NoError(t, err)
+	assert.True(t, expected.Equal(ts))
+
+	ts, err = timestampFromAttribute(json.Number("1622541600.5"), config.TimestampFormatUnix)
+	require.NoError(t, err)
+	assert.True(t, expected.Equal(ts))
+
+	ts, err = timestampFromAttribute(json.Number("1622541600500"), config.TimestampFormatUnixMs)
+	require.NoError(t, err)
+	assert.True(t, expected.Equal(ts))
+
+	ts, err = timestampFromAttribute("01/Jun/2021:10:00:00.5 +0000", "02/Jan/2006:15:04:05 -0700")
+	require.NoError(t, err)
+	assert.True(t, expected.Equal(ts))
+
+	_, err = timestampFromAttribute("yesterday", config.TimestampFormatRFC3339)
+	assert.Error(t, err)
+}
@@ -0,0 +1,260 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	diskQueueFileExtension = ".logs"
+	// the nanoseconds keep the lexical order of the files identical to their creation order
+	diskQueueFileFormat = "2006_01_02__15_04_05.000000000_"
+)
+
+// diskUsageRetriever returns the usage of the disk hosting a path.
+type diskUsageRetriever interface {
+	GetUsage(path string) (*filesystem.DiskUsage, error)
+}
+
+// diskQueueEntry is a payload stored on disk
+type diskQueueEntry struct {
+	filename  string
+	size      int64
+	createdAt time.Time
+}
+
+// DiskQueue stores the payloads that could not be sent to a destination on
+// disk, one file per payload, until they can be replayed in order.
+// The space used is bounded by maxSizeInBytes and by the ratio of the disk
+// that can be used, like the retry queue of the forwarder. When there is no
+// more room, the oldest payloads are removed first. Payloads older than
+// maxAge are removed without being replayed.
+type DiskQueue struct {
+	mu                 sync.Mutex
+	storagePath        string
+	maxSizeInBytes     int64
+	maxDiskRatio       float64
+	maxAge             time.Duration
+	disk               diskUsageRetriever
+	entries            []diskQueueEntry
+	currentSizeInBytes int64
+}
+
+// NewDiskQueue returns a new DiskQueue storing its payloads in storagePath.
+// The payloads left by a previous run are reloaded.
+func NewDiskQueue(storagePath string, maxSizeInBytes int64, maxDiskRatio float64, maxAge time.Duration) (*DiskQueue, error) {
+	return newDiskQueue(storagePath, maxSizeInBytes, maxDiskRatio, maxAge, filesystem.NewDisk())
+}
+
+func newDiskQueue(storagePath string, maxSizeInBytes int64, maxDiskRatio float64, maxAge time.Duration, disk diskUsageRetriever) (*DiskQueue, error) {
+	if err := os.MkdirAll(storagePath, 0700); err != nil {
+		return nil, err
+	}
+
+	q := &DiskQueue{
+		storagePath:    storagePath,
+		maxSizeInBytes: maxSizeInBytes,
+		maxDiskRatio:   maxDiskRatio,
+		maxAge:         maxAge,
+		disk:           disk,
+	}
+
+	if err := q.reloadExistingFiles(); err != nil {
+		return nil, err
+	}
+
+	// Check if there is an error when computing the available space
+	// in this function to warn the user sooner (and not when there is an outage)
+	_, err := q.computeAvailableSpace()
+	return q, err
+}
+
+// Add stores a payload at the end of the queue.
+func (q *DiskQueue) Add(payload []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	size := int64(len(payload))
+	if err := q.makeRoomFor(size); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	file, err := ioutil.TempFile(q.storagePath, now.UTC().Format(diskQueueFileFormat)+"*"+diskQueueFileExtension)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err = file.Write(payload); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return err
+	}
+
+	q.entries = append(q.entries, diskQueueEntry{filename: file.Name(), size: size, createdAt: now})
+	q.currentSizeInBytes += size
+	metrics.DiskQueuePayloads.Add(1)
+	metrics.TlmDiskQueuePayloads.Inc()
+	metrics.DiskQueueBytes.Add(size)
+	metrics.TlmDiskQueueBytes.Add(float64(size))
+	return nil
+}
+
+// Peek returns the oldest payload of the queue and its identifier, without
+// removing it from the queue. The payloads older than maxAge are removed.
+// It returns a nil payload when the queue is empty.
+func (q *DiskQueue) Peek() ([]byte, string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.entries) > 0 {
+		entry := q.entries[0]
+		if q.maxAge > 0 && time.Since(entry.createdAt) > q.maxAge {
+			log.Warnf("Removing %s from the logs disk queue: older than %v", entry.filename, q.maxAge)
+			q.drop(0, "outdated")
+			continue
+		}
+
+		payload, err := ioutil.ReadFile(entry.filename)
+		if err != nil {
+			// Remove the file even in case of a read failure to not fail on the next call.
+			q.drop(0, "read_error")
+			return nil, "", err
+		}
+		return payload, entry.filename, nil
+	}
+	return nil, "", nil
+}
+
+// Remove removes the payload returned by Peek from the queue, if it is still
+// there.
+func (q *DiskQueue) Remove(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, entry := range q.entries {
+		if entry.filename == id {
+			q.removeAt(i)
+			return
+		}
+	}
+}
+
+// Len returns the number of payloads in the queue.
+func (q *DiskQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// SizeInBytes returns the disk space used by the queue.
+func (q *DiskQueue) SizeInBytes() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.currentSizeInBytes
+}
+
+func (q *DiskQueue) makeRoomFor(size int64) error {
+	if size > q.maxSizeInBytes {
+		return fmt.Errorf("the payload is too big. Current:%v Maximum:%v", size, q.maxSizeInBytes)
+	}
+
+	maxStorageInBytes, err := q.computeAvailableSpace()
+	if err != nil {
+		return err
+	}
+	for len(q.entries) > 0 && q.currentSizeInBytes+size > maxStorageInBytes {
+		log.Errorf("Maximum disk space for the logs disk queue is reached. Removing %s", q.entries[0].filename)
+		q.drop(0, "disk_full")
+	}
+	if q.currentSizeInBytes+size > maxStorageInBytes {
+		return fmt.Errorf("not enough disk space to store the payload. Current:%v Available:%v", size, maxStorageInBytes)
+	}
+	return nil
+}
+
+// computeAvailableSpace returns the maximum size the queue can use: the
+// minimum between maxSizeInBytes and the disk space available while keeping
+// the disk usage under maxDiskRatio.
+func (q *DiskQueue) computeAvailableSpace() (int64, error) {
+	usage, err := q.disk.GetUsage(q.storagePath)
+	if err != nil {
+		return 0, err
+	}
+	diskReserved := float64(usage.Total) * (1 - q.maxDiskRatio)
+	availableDiskUsage := int64(usage.Available) - int64(math.Ceil(diskReserved))
+
+	if available := q.currentSizeInBytes + availableDiskUsage; available < q.maxSizeInBytes {
+		return available, nil
+	}
+	return q.maxSizeInBytes, nil
+}
+
+// drop removes a payload that will never be sent.
+func (q *DiskQueue) drop(index int, reason string) {
+	q.removeAt(index)
+	metrics.DiskQueueDropped.Add(1)
+	metrics.TlmDiskQueueDropped.Inc(reason)
+}
+
+func (q *DiskQueue) removeAt(index int) {
+	entry := q.entries[index]
+
+	// Remove the entry also in case of error to not fail on the next call.
+	q.entries = append(q.entries[:index], q.entries[index+1:]...)
+	q.currentSizeInBytes -= entry.size
+	metrics.DiskQueuePayloads.Add(-1)
+	metrics.TlmDiskQueuePayloads.Dec()
+	metrics.DiskQueueBytes.Add(-entry.size)
+	metrics.TlmDiskQueueBytes.Sub(float64(entry.size))
+
+	if err := os.Remove(entry.filename); err != nil && !os.IsNotExist(err) {
+		log.Warnf("Could not remove %s from the logs disk queue: %v", entry.filename, err)
+	}
+}
+
+func (q *DiskQueue) reloadExistingFiles() error {
+	files, err := ioutil.ReadDir(q.storagePath)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if !file.Mode().IsRegular() || filepath.Ext(file.Name()) != diskQueueFileExtension {
+			continue
+		}
+		q.entries = append(q.entries, diskQueueEntry{
+			filename:  filepath.Join(q.storagePath, file.Name()),
+			size:      file.Size(),
+			createdAt: file.ModTime(),
+		})
+		q.currentSizeInBytes += file.Size()
+		metrics.DiskQueuePayloads.Add(1)
+		metrics.TlmDiskQueuePayloads.Inc()
+		metrics.DiskQueueBytes.Add(file.Size())
+		metrics.TlmDiskQueueBytes.Add(float64(file.Size()))
+	}
+	sort.Slice(q.entries, func(i, j int) bool {
+		return q.entries[i].filename < q.entries[j].filename
+	})
+
+	if len(q.entries) > 0 {
+		log.Infof("Reloaded %d payloads from the logs disk queue %s", len(q.entries), q.storagePath)
+	}
+	return nil
+}
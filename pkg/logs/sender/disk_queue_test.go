@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+)
+
+type diskUsageRetrieverMock struct {
+	diskUsage *filesystem.DiskUsage
+}
+
+func (m diskUsageRetrieverMock) GetUsage(path string) (*filesystem.DiskUsage, error) {
+	return m.diskUsage, nil
+}
+
+func newTestDiskQueue(t *testing.T, path string, maxSizeInBytes int64, maxAge time.Duration) *DiskQueue {
+	disk := diskUsageRetrieverMock{
+		diskUsage: &filesystem.DiskUsage{
+			Available: 10000,
+			Total:     10000,
+		}}
+	q, err := newDiskQueue(path, maxSizeInBytes, 1, maxAge, disk)
+	require.NoError(t, err)
+	return q
+}
+
+// peekAndRemove returns the oldest payload of the queue and removes it.
+func peekAndRemove(t *testing.T, q *DiskQueue) string {
+	payload, id, err := q.Peek()
+	require.NoError(t, err)
+	q.Remove(id)
+	return string(payload)
+}
+
+func TestDiskQueueOrder(t *testing.T) {
+	q := newTestDiskQueue(t, t.TempDir(), 1000, 0)
+
+	for _, payload := range []string{"a", "bb", "ccc"} {
+		require.NoError(t, q.Add([]byte(payload)))
+	}
+	assert.Equal(t, 3, q.Len())
+	assert.Equal(t, int64(6), q.SizeInBytes())
+
+	// peek does not remove the payload
+	payload, _, err := q.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(payload))
+	assert.Equal(t, 3, q.Len())
+
+	assert.Equal(t, "a", peekAndRemove(t, q))
+	assert.Equal(t, "bb", peekAndRemove(t, q))
+	assert.Equal(t, "ccc", peekAndRemove(t, q))
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, int64(0), q.SizeInBytes())
+
+	payload, _, err = q.Peek()
+	assert.NoError(t, err)
+	assert.Nil(t, payload)
+}
+
+func TestDiskQueueReload(t *testing.T) {
+	path := t.TempDir()
+	q := newTestDiskQueue(t, path, 1000, 0)
+	require.NoError(t, q.Add([]byte("first")))
+	require.NoError(t, q.Add([]byte("second")))
+	// files with another extension are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(path, "other.retry"), []byte("other"), 0600))
+
+	q = newTestDiskQueue(t, path, 1000, 0)
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, int64(11), q.SizeInBytes())
+	assert.Equal(t, "first", peekAndRemove(t, q))
+	assert.Equal(t, "second", peekAndRemove(t, q))
+}
+
+func TestDiskQueueMaxSize(t *testing.T) {
+	q := newTestDiskQueue(t, t.TempDir(), 10, 0)
+
+	require.NoError(t, q.Add([]byte("aaaa")))
+	require.NoError(t, q.Add([]byte("bbbb")))
+	// the oldest payload is removed to make room for the new one
+	require.NoError(t, q.Add([]byte("cccc")))
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, int64(8), q.SizeInBytes())
+
+	// a payload bigger than the queue is refused
+	assert.Error(t, q.Add([]byte("ddddddddddd")))
+
+	assert.Equal(t, "bbbb", peekAndRemove(t, q))
+	assert.Equal(t, "cccc", peekAndRemove(t, q))
+}
+
+func TestDiskQueueMaxDiskRatio(t *testing.T) {
+	disk := diskUsageRetrieverMock{
+		diskUsage: &filesystem.DiskUsage{
+			Available: 30,
+			Total:     100,
+		}}
+	// 10 bytes of the disk must remain available
+	q, err := newDiskQueue(t.TempDir(), 1000, 0.9, 0, disk)
+	require.NoError(t, err)
+
+	require.NoError(t, q.Add(make([]byte, 15)))
+	disk.diskUsage.Available = 15
+	// the first payload is removed, leaving 20 bytes
+	require.NoError(t, q.Add(make([]byte, 10)))
+	assert.Equal(t, 1, q.Len())
+	assert.Equal(t, int64(10), q.SizeInBytes())
+}
+
+func TestDiskQueueMaxAge(t *testing.T) {
+	q := newTestDiskQueue(t, t.TempDir(), 1000, time.Hour)
+
+	require.NoError(t, q.Add([]byte("old")))
+	require.NoError(t, q.Add([]byte("new")))
+	q.entries[0].createdAt = time.Now().Add(-2 * time.Hour)
+
+	assert.Equal(t, "new", peekAndRemove(t, q))
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestDiskQueueRemoveAfterEviction(t *testing.T) {
+	q := newTestDiskQueue(t, t.TempDir(), 8, 0)
+
+	require.NoError(t, q.Add([]byte("aaaa")))
+	_, id, err := q.Peek()
+	require.NoError(t, err)
+
+	// the peeked payload is evicted before being removed
+	require.NoError(t, q.Add([]byte("bbbb")))
+	require.NoError(t, q.Add([]byte("cccc")))
+	q.Remove(id)
+
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, "bbbb", peekAndRemove(t, q))
+}
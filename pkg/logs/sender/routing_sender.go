@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"context"
+	"expvar"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// routeWarningPeriod is the number of logs dropped by a route between two warnings
+const routeWarningPeriod = 1000
+
+// Route sends the logs accepted by a routed endpoint to its own destination.
+type Route struct {
+	endpoint   config.Endpoint
+	sender     *SingleSender
+	outputChan chan *message.Message
+}
+
+// NewRoute returns a route sending the logs accepted by the endpoint to the destination.
+func NewRoute(endpoint config.Endpoint, destination client.Destination, strategy Strategy) *Route {
+	outputChan := make(chan *message.Message, config.ChanSize)
+	return &Route{
+		endpoint:   endpoint,
+		sender:     NewSingleSender(make(chan *message.Message, config.ChanSize), outputChan, client.NewDestinations(destination, nil), strategy),
+		outputChan: outputChan,
+	}
+}
+
+// accepts returns true if the route receives the message
+func (r *Route) accepts(msg *message.Message) bool {
+	origin := msg.Origin
+	if origin == nil || origin.LogSource == nil {
+		return r.endpoint.Accepts("", "", msg.GetStatus(), nil)
+	}
+	return r.endpoint.Accepts(origin.Source(), origin.Service(), msg.GetStatus(), origin.Tags())
+}
+
+// send sends the message to the route without blocking, the message is dropped
+// when the route falls behind.
+func (r *Route) send(msg *message.Message) {
+	select {
+	case r.sender.inputChan <- msg:
+	default:
+		name := r.name()
+		if dropped, ok := metrics.DestinationLogsDropped.Get(name).(*expvar.Int); !ok || dropped.Value()%routeWarningPeriod == 0 {
+			log.Warnf("Some logs routed to the additional destination %v were dropped", name)
+		}
+		metrics.DestinationLogsDropped.Add(name, 1)
+		metrics.TlmLogsDropped.Inc(name)
+	}
+}
+
+// name returns the name of the destination of the route in the metrics
+func (r *Route) name() string {
+	switch r.endpoint.Type {
+	case config.EndpointTypeFile:
+		return r.endpoint.Path
+	case config.EndpointTypeStdout:
+		return config.EndpointTypeStdout
+	}
+	return r.endpoint.Host
+}
+
+func (r *Route) start() {
+	// the messages sent by a route are not audited
+	go func() {
+		for range r.outputChan {
+		}
+	}()
+	r.sender.Start()
+}
+
+func (r *Route) stop() {
+	r.sender.Stop()
+	close(r.outputChan)
+}
+
+// RoutingSender sends all the logs to a main sender, and the logs accepted by
+// each route to its destination. The routes never block the main sender.
+type RoutingSender struct {
+	inputChan     chan *message.Message
+	mainInputChan chan *message.Message
+	mainSender    Sender
+	routes        []*Route
+	done          chan struct{}
+}
+
+// NewRoutingSender returns a new routing sender, mainInputChan being the input channel of the main sender.
+func NewRoutingSender(inputChan chan *message.Message, mainInputChan chan *message.Message, mainSender Sender, routes []*Route) *RoutingSender {
+	return &RoutingSender{
+		inputChan:     inputChan,
+		mainInputChan: mainInputChan,
+		mainSender:    mainSender,
+		routes:        routes,
+		done:          make(chan struct{}),
+	}
+}
+
+// Start starts the main sender and the routes.
+func (s *RoutingSender) Start() {
+	for _, route := range s.routes {
+		route.start()
+	}
+	s.mainSender.Start()
+
+	go func() {
+		for msg := range s.inputChan {
+			for _, route := range s.routes {
+				if route.accepts(msg) {
+					route.send(msg)
+				}
+			}
+			s.mainInputChan <- msg
+		}
+		s.done <- struct{}{}
+	}()
+}
+
+// Stop stops the sender,
+// this call blocks until inputChan is flushed
+func (s *RoutingSender) Stop() {
+	close(s.inputChan)
+	<-s.done
+	s.mainSender.Stop()
+	for _, route := range s.routes {
+		route.stop()
+	}
+}
+
+// Flush sends synchronously the messages that the main sender and the routes have to send
+func (s *RoutingSender) Flush(ctx context.Context) {
+	s.mainSender.Flush(ctx)
+	for _, route := range s.routes {
+		route.sender.Flush(ctx)
+	}
+}
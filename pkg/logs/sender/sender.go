@@ -7,12 +7,18 @@ package sender
 
 import (
 	"context"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+// diskQueueReplayInterval is the interval at which a sender tries to replay
+// the payloads of its disk queue
+var diskQueueReplayInterval = time.Second
+
 // Strategy should contain all logic to send logs to a remote destination
 // and forward them the next stage of the pipeline.
 type Strategy interface {
@@ -37,10 +43,20 @@ type SingleSender struct {
 	done         chan struct{}
 	lastError    error
 	trackErrors  bool
+	diskQueue    *DiskQueue
+	stopReplay   chan struct{}
+	replayDone   chan struct{}
 }
 
 // NewSingleSender returns a new sender.
 func NewSingleSender(inputChan chan *message.Message, outputChan chan *message.Message, destinations *client.Destinations, strategy Strategy) *SingleSender {
+	return NewSingleSenderWithDiskQueue(inputChan, outputChan, destinations, strategy, nil)
+}
+
+// NewSingleSenderWithDiskQueue returns a new sender that stores on disk the
+// payloads the main destination cannot receive, and replays them in order
+// once it recovers.
+func NewSingleSenderWithDiskQueue(inputChan chan *message.Message, outputChan chan *message.Message, destinations *client.Destinations, strategy Strategy, diskQueue *DiskQueue) *SingleSender {
 	return &SingleSender{
 		inputChan:    inputChan,
 		outputChan:   outputChan,
@@ -49,12 +65,18 @@ func NewSingleSender(inputChan chan *message.Message, outputChan chan *message.M
 		strategy:     strategy,
 		done:         make(chan struct{}),
 		trackErrors:  false,
+		diskQueue:    diskQueue,
+		stopReplay:   make(chan struct{}),
+		replayDone:   make(chan struct{}),
 	}
 }
 
 // Start starts the sender.
 func (s *SingleSender) Start() {
 	go s.run()
+	if s.diskQueue != nil {
+		go s.replay()
+	}
 }
 
 // Stop stops the sender,
@@ -62,6 +84,10 @@ func (s *SingleSender) Start() {
 func (s *SingleSender) Stop() {
 	close(s.inputChan)
 	<-s.done
+	if s.diskQueue != nil {
+		close(s.stopReplay)
+		<-s.replayDone
+	}
 }
 
 // Flush sends synchronously the messages that this sender has to send.
@@ -79,7 +105,14 @@ func (s *SingleSender) run() {
 // send sends a payload to multiple destinations,
 // it will forever retry for the main destination unless the error is not retryable
 // and only try once for additional destinations.
+// When the sender has a disk queue, the payloads the main destination cannot
+// receive are stored in it instead of being retried, and so are the following
+// payloads until the queue is replayed, to preserve their order.
 func (s *SingleSender) send(payload []byte) error {
+	if s.diskQueue != nil && s.diskQueue.Len() > 0 && s.spill(payload) {
+		return nil
+	}
+
 	for {
 		err := s.destinations.Main.Send(payload)
 		if err != nil {
@@ -91,6 +124,9 @@ func (s *SingleSender) send(payload []byte) error {
 			metrics.DestinationErrors.Add(1)
 			metrics.TlmDestinationErrors.Inc()
 			if _, ok := err.(*client.RetryableError); ok {
+				if s.diskQueue != nil && s.spill(payload) {
+					return nil
+				}
 
 				// could not send the payload because of a client issue,
 				// let's retry
@@ -114,6 +150,67 @@ func (s *SingleSender) send(payload []byte) error {
 	return nil
 }
 
+// spill stores the payload in the disk queue, the additional destinations
+// still receive it right away. It returns false if the payload could not be
+// stored, in which case it is retried from memory.
+func (s *SingleSender) spill(payload []byte) bool {
+	if err := s.diskQueue.Add(payload); err != nil {
+		log.Warnf("Could not store payload in the disk queue: %v", err)
+		return false
+	}
+	for _, destination := range s.destinations.Additionals {
+		destination.SendAsync(payload)
+	}
+	return true
+}
+
+// replay periodically sends the payloads of the disk queue to the main
+// destination, until the sender is stopped.
+func (s *SingleSender) replay() {
+	defer close(s.replayDone)
+	ticker := time.NewTicker(diskQueueReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopReplay:
+			return
+		case <-ticker.C:
+			s.replayDiskQueue()
+		}
+	}
+}
+
+// replayDiskQueue sends the payloads of the disk queue in order, it returns
+// when the queue is empty or when the main destination is still failing.
+func (s *SingleSender) replayDiskQueue() {
+	for {
+		select {
+		case <-s.stopReplay:
+			return
+		default:
+		}
+
+		payload, id, err := s.diskQueue.Peek()
+		if err != nil {
+			log.Warnf("Could not read payload from the disk queue: %v", err)
+			continue
+		}
+		if payload == nil {
+			return
+		}
+
+		if err := s.destinations.Main.Send(payload); err != nil {
+			metrics.DestinationErrors.Add(1)
+			metrics.TlmDestinationErrors.Inc()
+			if _, ok := err.(*client.RetryableError); ok || shouldStopSending(err) {
+				return
+			}
+			log.Warnf("Could not send payload from the disk queue, dropping it: %v", err)
+		}
+		s.diskQueue.Remove(id)
+	}
+}
+
 // shouldStopSending returns true if a component should stop sending logs.
 func shouldStopSending(err error) bool {
 	return err == context.Canceled
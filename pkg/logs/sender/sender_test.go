@@ -8,7 +8,9 @@ package sender
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -46,6 +48,39 @@ func (m *mockDestination) Send(payload []byte) error {
 func (m *mockDestination) SendAsync(payload []byte) {
 }
 
+// mockRecordingDestination records the payloads it receives and fails with
+// retryable errors while it is unavailable.
+type mockRecordingDestination struct {
+	sync.Mutex
+	unavailable bool
+	payloads    []string
+}
+
+func (m *mockRecordingDestination) Send(payload []byte) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.unavailable {
+		return client.NewRetryableError(errors.New("Test error"))
+	}
+	m.payloads = append(m.payloads, string(payload))
+	return nil
+}
+
+func (m *mockRecordingDestination) SendAsync(payload []byte) {
+}
+
+func (m *mockRecordingDestination) setUnavailable(unavailable bool) {
+	m.Lock()
+	defer m.Unlock()
+	m.unavailable = unavailable
+}
+
+func (m *mockRecordingDestination) getPayloads() []string {
+	m.Lock()
+	defer m.Unlock()
+	return append([]string{}, m.payloads...)
+}
+
 type mockStrategy struct {
 	sendFailed chan bool
 }
@@ -242,3 +277,103 @@ func TestSingleFailsThenRecovers(t *testing.T) {
 	input <- newMessage([]byte("fake line"), source, "")
 	<-mainOutput
 }
+
+func TestSenderDiskQueueReplaysInOrder(t *testing.T) {
+	defer func(interval time.Duration) { diskQueueReplayInterval = interval }(diskQueueReplayInterval)
+	diskQueueReplayInterval = 10 * time.Millisecond
+
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	input := make(chan *message.Message, 1)
+	output := make(chan *message.Message)
+
+	mainDest := &mockRecordingDestination{}
+	mainDests := client.NewDestinations(mainDest, []client.Destination{})
+	diskQueue := newTestDiskQueue(t, t.TempDir(), 1000, 0)
+
+	sender := NewSingleSenderWithDiskQueue(input, output, mainDests, newMockStrategy(), diskQueue)
+	sender.Start()
+	defer sender.Stop()
+
+	// the payloads are stored on disk during the outage and the pipeline is not blocked
+	mainDest.setUnavailable(true)
+	input <- newMessage([]byte("1"), source, "")
+	<-output
+	input <- newMessage([]byte("2"), source, "")
+	<-output
+	assert.Equal(t, 2, diskQueue.Len())
+	assert.Empty(t, mainDest.getPayloads())
+
+	// the payloads are replayed in order once the destination recovers
+	mainDest.setUnavailable(false)
+	assert.Eventually(t, func() bool { return diskQueue.Len() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"1", "2"}, mainDest.getPayloads())
+
+	input <- newMessage([]byte("3"), source, "")
+	<-output
+	assert.Equal(t, []string{"1", "2", "3"}, mainDest.getPayloads())
+}
+
+func TestRoutingSender(t *testing.T) {
+	security := config.NewLogSource("", &config.LogsConfig{Source: "auditd"})
+	application := config.NewLogSource("", &config.LogsConfig{Source: "nginx"})
+
+	input := make(chan *message.Message, 1)
+	mainInput := make(chan *message.Message, 1)
+	mainOutput := make(chan *message.Message)
+
+	mainDest := &mockRecordingDestination{}
+	mainSender := NewSingleSender(mainInput, mainOutput, client.NewDestinations(mainDest, []client.Destination{}), newMockStrategy())
+
+	routeDest := &mockRecordingDestination{}
+	route := NewRoute(config.Endpoint{
+		Host:    "siem",
+		Include: &config.LogsFilter{Sources: []string{"auditd"}},
+		Exclude: &config.LogsFilter{Statuses: []string{message.StatusDebug}},
+	}, routeDest, newMockStrategy())
+
+	sender := NewRoutingSender(input, mainInput, mainSender, []*Route{route})
+	sender.Start()
+
+	// the main destination receives all the logs, the route only the ones it accepts
+	input <- newMessage([]byte("1"), security, message.StatusInfo)
+	<-mainOutput
+	input <- newMessage([]byte("2"), application, message.StatusInfo)
+	<-mainOutput
+	input <- newMessage([]byte("3"), security, message.StatusDebug)
+	<-mainOutput
+	input <- newMessage([]byte("4"), security, message.StatusError)
+	<-mainOutput
+
+	assert.Equal(t, []string{"1", "2", "3", "4"}, mainDest.getPayloads())
+	assert.Eventually(t, func() bool { return len(routeDest.getPayloads()) == 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"1", "4"}, routeDest.getPayloads())
+
+	sender.Stop()
+}
+
+func TestRoutingSenderNotBlockedByRoute(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Source: "auditd"})
+
+	input := make(chan *message.Message, 1)
+	mainInput := make(chan *message.Message, 1)
+	mainOutput := make(chan *message.Message)
+
+	mainDest := &mockRecordingDestination{}
+	mainSender := NewSingleSender(mainInput, mainOutput, client.NewDestinations(mainDest, []client.Destination{}), newMockStrategy())
+
+	// the route destination is down, its sender retries forever
+	routeDest := &mockRecordingDestination{}
+	routeDest.setUnavailable(true)
+	route := NewRoute(config.Endpoint{Host: "siem", Type: config.EndpointTypeTCP}, routeDest, newMockStrategy())
+
+	sender := NewRoutingSender(input, mainInput, mainSender, []*Route{route})
+	sender.Start()
+
+	for i := 0; i < 2*config.ChanSize; i++ {
+		input <- newMessage([]byte("line"), source, message.StatusInfo)
+		<-mainOutput
+	}
+	assert.Len(t, mainDest.getPayloads(), 2*config.ChanSize)
+	assert.Empty(t, routeDest.getPayloads())
+}
@@ -169,5 +169,13 @@ func (b *Builder) getMetricsStatus() map[string]int64 {
 	metrics["LogsSent"] = b.logsExpVars.Get("LogsSent").(*expvar.Int).Value()
 	metrics["BytesSent"] = b.logsExpVars.Get("BytesSent").(*expvar.Int).Value()
 	metrics["EncodedBytesSent"] = b.logsExpVars.Get("EncodedBytesSent").(*expvar.Int).Value()
+	// the backlog of the on-disk queues is only relevant during an outage
+	if diskQueuePayloads := b.logsExpVars.Get("DiskQueuePayloads").(*expvar.Int).Value(); diskQueuePayloads > 0 {
+		metrics["DiskQueuePayloads"] = diskQueuePayloads
+		metrics["DiskQueueBytes"] = b.logsExpVars.Get("DiskQueueBytes").(*expvar.Int).Value()
+	}
+	b.logsExpVars.Get("LogsDroppedByRule").(*expvar.Map).Do(func(kv expvar.KeyValue) {
+		metrics["LogsDroppedByRule."+kv.Key] = kv.Value.(*expvar.Int).Value()
+	})
 	return metrics
 }
@@ -92,13 +92,13 @@ func TestStatusDeduplicateErrorsAndWarnings(t *testing.T) {
 func TestMetrics(t *testing.T) {
 	defer Clear()
 	Clear()
-	var expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "EncodedBytesSent": 0, "Errors": "", "IsRunning": false, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSent": 0, "SenderLatency": 0, "Warnings": ""}`
+	var expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "DiskQueueBytes": 0, "DiskQueueDropped": 0, "DiskQueuePayloads": 0, "EncodedBytesSent": 0, "Errors": "", "IsRunning": false, "LogsDecoded": 0, "LogsDroppedByRule": {}, "LogsProcessed": 0, "LogsSent": 0, "SenderLatency": 0, "Warnings": ""}`
 	assert.Equal(t, expected, metrics.LogsExpvars.String())
 
 	initStatus()
 	AddGlobalWarning("bar", "Unique Warning")
 	AddGlobalError("bar", "I am an error")
-	expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "EncodedBytesSent": 0, "Errors": "I am an error", "IsRunning": true, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSent": 0, "SenderLatency": 0, "Warnings": "Unique Warning"}`
+	expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "DiskQueueBytes": 0, "DiskQueueDropped": 0, "DiskQueuePayloads": 0, "EncodedBytesSent": 0, "Errors": "I am an error", "IsRunning": true, "LogsDecoded": 0, "LogsDroppedByRule": {}, "LogsProcessed": 0, "LogsSent": 0, "SenderLatency": 0, "Warnings": "Unique Warning"}`
 	assert.Equal(t, expected, metrics.LogsExpvars.String())
 }
 
@@ -123,6 +123,22 @@ func TestStatusMetrics(t *testing.T) {
 	assert.Equal(t, int64(42), status.StatusMetrics["BytesSent"])
 	assert.Equal(t, int64(21), status.StatusMetrics["EncodedBytesSent"])
 
+	// the on-disk queue backlog is only reported when not empty
+	_, found := status.StatusMetrics["DiskQueuePayloads"]
+	assert.False(t, found)
+	metrics.DiskQueuePayloads.Set(2)
+	metrics.DiskQueueBytes.Set(1024)
+	status = Get()
+	assert.Equal(t, int64(2), status.StatusMetrics["DiskQueuePayloads"])
+	assert.Equal(t, int64(1024), status.StatusMetrics["DiskQueueBytes"])
+	metrics.DiskQueuePayloads.Set(0)
+	metrics.DiskQueueBytes.Set(0)
+
+	metrics.LogsDroppedByRule.Add("noisy_health_checks", 12)
+	status = Get()
+	assert.Equal(t, int64(12), status.StatusMetrics["LogsDroppedByRule.noisy_health_checks"])
+	metrics.LogsDroppedByRule.Init()
+
 	metrics.LogsProcessed.Set(math.MaxInt64)
 	metrics.LogsProcessed.Add(1)
 	status = Get()
@@ -11,6 +11,7 @@ import (
 	"strconv"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/quantile"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -32,6 +33,7 @@ type Histogram struct {
 	percentiles []int    // percentiles configured on this histogram, each in the 1-100 range
 	interval    int64    // interval over which the `count` value is normalized (bucket interval for Dogstatsd, 1 otherwise)
 	samples     weightSamples
+	sketch      *quantile.Agent // when set, samples are inserted in this sketch instead of being kept in `samples`
 	sum         float64
 	count       int64
 }
@@ -48,6 +50,12 @@ const (
 var (
 	defaultAggregates  = []string(nil)
 	defaultPercentiles = []int(nil)
+	defaultUseSketch   = false
+	useSketchLoaded    = false
+
+	// sketchConfig is the configuration used to read quantiles from the
+	// histogram sketches
+	sketchConfig = quantile.Default()
 )
 
 type histogramPercentilesConfig struct {
@@ -90,12 +98,20 @@ func NewHistogram(interval int64) *Histogram {
 			sort.Ints(defaultPercentiles)
 		}
 	}
+	if !useSketchLoaded {
+		defaultUseSketch = config.Datadog.GetBool("histogram_use_sketch")
+		useSketchLoaded = true
+	}
 
-	return &Histogram{
+	h := &Histogram{
 		interval:    interval,
 		aggregates:  defaultAggregates,
 		percentiles: defaultPercentiles,
 	}
+	if defaultUseSketch {
+		h.enableSketch()
+	}
+	return h
 }
 
 func (h *Histogram) configure(aggregates []string, percentiles []int) {
@@ -104,18 +120,32 @@ func (h *Histogram) configure(aggregates []string, percentiles []int) {
 	h.percentiles = percentiles
 }
 
+// enableSketch makes the histogram store its samples in a sketch, trading
+// exact values for a bounded memory usage.
+func (h *Histogram) enableSketch() {
+	h.sketch = &quantile.Agent{}
+}
+
 func (h *Histogram) addSample(sample *MetricSample, timestamp float64) {
 	rate := sample.SampleRate
 	if rate == 0 {
 		rate = 1
 	}
 
-	h.samples = append(h.samples, weightSample{sample.Value, int64(1 / rate)}) // add value and its weight
+	if h.sketch != nil {
+		h.sketch.Insert(sample.Value, rate)
+	} else {
+		h.samples = append(h.samples, weightSample{sample.Value, int64(1 / rate)}) // add value and its weight
+	}
 	h.sum += sample.Value * (1 / rate)
 	h.count += int64(1 / rate)
 }
 
 func (h *Histogram) flush(timestamp float64) ([]*Serie, error) {
+	if h.sketch != nil {
+		return h.flushSketch(timestamp)
+	}
+
 	if len(h.samples) == 0 {
 		return []*Serie{}, NoSerieError{}
 	}
@@ -195,6 +225,64 @@ func (h *Histogram) flush(timestamp float64) ([]*Serie, error) {
 	return series, nil
 }
 
+// flushSketch computes the aggregates and percentiles from the sketch. Apart
+// from sum, avg and count, the values are approximated within the sketch
+// relative accuracy.
+func (h *Histogram) flushSketch(timestamp float64) ([]*Serie, error) {
+	sketch := h.sketch.Finish()
+	if sketch == nil {
+		return []*Serie{}, NoSerieError{}
+	}
+
+	series := make([]*Serie, 0, len(h.aggregates)+len(h.percentiles))
+
+	// Compute aggregates
+	for _, aggregate := range h.aggregates {
+		var value float64
+		mType := APIGaugeType
+		switch aggregate {
+		case maxAgg:
+			value = sketch.Basic.Max
+		case minAgg:
+			value = sketch.Basic.Min
+		case medianAgg:
+			value = sketch.Quantile(sketchConfig, 0.5)
+		case avgAgg:
+			value = h.sum / float64(h.count)
+		case sumAgg:
+			value = h.sum
+		case countAgg:
+			value = float64(h.count) / float64(h.interval)
+			mType = APIRateType
+		default:
+			log.Infof("Configured aggregate '%s' is not implemented, skipping", aggregate)
+			continue
+		}
+
+		series = append(series, &Serie{
+			Points:     []Point{{Ts: timestamp, Value: value}},
+			MType:      mType,
+			NameSuffix: "." + aggregate,
+		})
+	}
+
+	// Compute percentiles
+	for _, percentile := range h.percentiles {
+		series = append(series, &Serie{
+			Points:     []Point{{Ts: timestamp, Value: sketch.Quantile(sketchConfig, float64(percentile)/100)}},
+			MType:      APIGaugeType,
+			NameSuffix: fmt.Sprintf(".%dpercentile", percentile),
+		})
+	}
+
+	// reset histogram
+	h.sketch.Reset()
+	h.sum = 0
+	h.count = 0
+
+	return series, nil
+}
+
 func (h *Histogram) isStateful() bool {
 	return false
 }
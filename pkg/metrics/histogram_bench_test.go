@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"testing"
+)
+
+func benchmarkHistogramAddAndFlush(samples int, useSketch bool, b *testing.B) {
+	h := NewHistogram(10)
+	h.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []int{50, 95, 99})
+	if useSketch {
+		h.enableSketch()
+	}
+	m := MetricSample{Value: 0, SampleRate: 1}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < samples; i++ {
+			m.Value = float64(i % 1000)
+			h.addSample(&m, 10)
+		}
+		h.flush(10)
+	}
+}
+
+func BenchmarkHistogramExact10(b *testing.B)     { benchmarkHistogramAddAndFlush(10, false, b) }
+func BenchmarkHistogramExact100(b *testing.B)    { benchmarkHistogramAddAndFlush(100, false, b) }
+func BenchmarkHistogramExact1000(b *testing.B)   { benchmarkHistogramAddAndFlush(1000, false, b) }
+func BenchmarkHistogramExact10000(b *testing.B)  { benchmarkHistogramAddAndFlush(10000, false, b) }
+func BenchmarkHistogramExact100000(b *testing.B) { benchmarkHistogramAddAndFlush(100000, false, b) }
+
+func BenchmarkHistogramSketch10(b *testing.B)     { benchmarkHistogramAddAndFlush(10, true, b) }
+func BenchmarkHistogramSketch100(b *testing.B)    { benchmarkHistogramAddAndFlush(100, true, b) }
+func BenchmarkHistogramSketch1000(b *testing.B)   { benchmarkHistogramAddAndFlush(1000, true, b) }
+func BenchmarkHistogramSketch10000(b *testing.B)  { benchmarkHistogramAddAndFlush(10000, true, b) }
+func BenchmarkHistogramSketch100000(b *testing.B) { benchmarkHistogramAddAndFlush(100000, true, b) }
@@ -276,6 +276,71 @@ func TestHistogramReset(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestHistogramSketch(t *testing.T) {
+	const sketchEpsilon = 0.02
+
+	mHistogram := NewHistogram(10)
+	mHistogram.enableSketch()
+	mHistogram.configure([]string{"max", "median", "avg", "sum", "count", "min"}, []int{99, 95, 50})
+
+	// Empty flush
+	_, err := mHistogram.flush(50)
+	assert.NotNil(t, err)
+
+	var values []float64
+	for i := 1; i <= 1000; i++ {
+		values = append(values, float64(i))
+	}
+	shuffle(values) // in place
+	for _, v := range values {
+		mHistogram.addSample(&MetricSample{Value: v}, 50)
+	}
+	mHistogram.addSample(&MetricSample{Value: 500, SampleRate: 0.5}, 50)
+
+	// no raw sample is kept in memory
+	assert.Len(t, mHistogram.samples, 0)
+
+	series, err := mHistogram.flush(60)
+	assert.Nil(t, err)
+	require.Len(t, series, 9)
+
+	for _, serie := range series {
+		assert.Len(t, serie.Points, 1)
+		assert.EqualValues(t, 60, serie.Points[0].Ts)
+	}
+	// values read from the sketch are approximated, sum, avg and count are exact
+	assert.InEpsilon(t, 1000, series[0].Points[0].Value, sketchEpsilon) // max
+	assert.Equal(t, ".max", series[0].NameSuffix)                       // max
+	assert.InEpsilon(t, 500, series[1].Points[0].Value, sketchEpsilon)  // median
+	assert.Equal(t, ".median", series[1].NameSuffix)                    // median
+	assert.InEpsilon(t, 501500./1002, series[2].Points[0].Value, 1e-9)  // avg
+	assert.Equal(t, ".avg", series[2].NameSuffix)                       // avg
+	assert.InEpsilon(t, 501500, series[3].Points[0].Value, 1e-9)        // sum
+	assert.Equal(t, ".sum", series[3].NameSuffix)                       // sum
+	assert.InEpsilon(t, 100.2, series[4].Points[0].Value, 1e-9)         // count
+	assert.Equal(t, ".count", series[4].NameSuffix)                     // count
+	assert.InEpsilon(t, 1, series[5].Points[0].Value, sketchEpsilon)    // min
+	assert.Equal(t, ".min", series[5].NameSuffix)                       // min
+	assert.InEpsilon(t, 500, series[6].Points[0].Value, sketchEpsilon)  // 0.50
+	assert.Equal(t, ".50percentile", series[6].NameSuffix)              // 0.50
+	assert.InEpsilon(t, 950, series[7].Points[0].Value, sketchEpsilon)  // 0.95
+	assert.Equal(t, ".95percentile", series[7].NameSuffix)              // 0.95
+	assert.InEpsilon(t, 990, series[8].Points[0].Value, sketchEpsilon)  // 0.99
+	assert.Equal(t, ".99percentile", series[8].NameSuffix)              // 0.99
+
+	_, err = mHistogram.flush(61)
+	assert.NotNil(t, err)
+
+	// the sketch is reset between flushes
+	mHistogram.addSample(&MetricSample{Value: 10}, 70)
+	series, err = mHistogram.flush(80)
+	assert.Nil(t, err)
+	require.Len(t, series, 9)
+	assert.InEpsilon(t, 10, series[0].Points[0].Value, sketchEpsilon) // max
+	assert.InEpsilon(t, 10, series[5].Points[0].Value, sketchEpsilon) // min
+	assert.InEpsilon(t, 10, series[8].Points[0].Value, sketchEpsilon) // 0.99
+}
+
 //
 // Benchmark
 //
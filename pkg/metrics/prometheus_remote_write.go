@@ -0,0 +1,273 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/richardartoul/molecule"
+
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+)
+
+// remoteWriteSketchQuantiles are the quantiles of the sketches exported as
+// samples of a Prometheus summary
+var remoteWriteSketchQuantiles = []float64{0.5, 0.75, 0.95, 0.99}
+
+type remoteWriteLabel struct {
+	name  string
+	value string
+}
+
+type remoteWriteSample struct {
+	value float64
+	// timestamp is in milliseconds
+	timestamp int64
+}
+
+// remoteWriteEncoder encodes time series into snappy-compressed Prometheus
+// remote-write WriteRequest payloads, each holding at most
+// maxSamplesPerPayload samples.
+type remoteWriteEncoder struct {
+	maxSamplesPerPayload int
+	buf                  *bytes.Buffer
+	ps                   *molecule.ProtoStream
+	samples              int
+	payloads             []*[]byte
+}
+
+func newRemoteWriteEncoder(maxSamplesPerPayload int) *remoteWriteEncoder {
+	if maxSamplesPerPayload <= 0 {
+		maxSamplesPerPayload = 1
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	return &remoteWriteEncoder{
+		maxSamplesPerPayload: maxSamplesPerPayload,
+		buf:                  buf,
+		ps:                   molecule.NewProtoStream(buf),
+	}
+}
+
+func (e *remoteWriteEncoder) addTimeSeries(labels []remoteWriteLabel, samples []remoteWriteSample) error {
+	// constants for the protobuf data we will be writing, taken from WriteRequest in
+	// https://github.com/prometheus/prometheus/blob/main/prompb/remote.proto
+	const writeRequestTimeseries = 1
+	const timeseriesLabels = 1
+	const timeseriesSamples = 2
+	const labelName = 1
+	const labelValue = 2
+	const sampleValue = 1
+	const sampleTimestamp = 2
+
+	// A time series is never split so a payload may exceed the maximum
+	// when a single series has more samples.
+	if e.samples > 0 && e.samples+len(samples) > e.maxSamplesPerPayload {
+		e.flush()
+	}
+
+	err := e.ps.Embedded(writeRequestTimeseries, func(ps *molecule.ProtoStream) error {
+		for _, l := range labels {
+			err := ps.Embedded(timeseriesLabels, func(ps *molecule.ProtoStream) error {
+				if err := ps.String(labelName, l.name); err != nil {
+					return err
+				}
+				return ps.String(labelValue, l.value)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, s := range samples {
+			err := ps.Embedded(timeseriesSamples, func(ps *molecule.ProtoStream) error {
+				if err := ps.Double(sampleValue, s.value); err != nil {
+					return err
+				}
+				return ps.Int64(sampleTimestamp, s.timestamp)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	e.samples += len(samples)
+	return nil
+}
+
+func (e *remoteWriteEncoder) flush() {
+	if e.buf.Len() == 0 {
+		return
+	}
+	payload := snappy.Encode(nil, e.buf.Bytes())
+	e.payloads = append(e.payloads, &payload)
+	e.buf.Reset()
+	e.samples = 0
+}
+
+// finish returns the payloads encoded
+func (e *remoteWriteEncoder) finish() []*[]byte {
+	e.flush()
+	return e.payloads
+}
+
+// remoteWriteLabels returns the labels of a time series, sorted by name as
+// required by Prometheus. The tags are converted to labels, the values of the
+// tags sharing the same name being joined with a comma.
+func remoteWriteLabels(name string, host string, device string, tags []string, extra ...remoteWriteLabel) []remoteWriteLabel {
+	values := make(map[string][]string, len(tags)+3)
+	for _, tag := range tags {
+		labelName, value := tag, "true"
+		if i := strings.IndexByte(tag, ':'); i >= 0 {
+			labelName, value = tag[:i], tag[i+1:]
+		}
+		labelName = remoteWriteLabelName(labelName)
+		values[labelName] = append(values[labelName], value)
+	}
+	if host != "" && values["host"] == nil {
+		values["host"] = []string{host}
+	}
+	if device != "" && values["device"] == nil {
+		values["device"] = []string{device}
+	}
+	for _, l := range extra {
+		values[l.name] = []string{l.value}
+	}
+
+	labels := make([]remoteWriteLabel, 0, len(values)+1)
+	labels = append(labels, remoteWriteLabel{name: "__name__", value: remoteWriteMetricName(name)})
+	for labelName, labelValues := range values {
+		sort.Strings(labelValues)
+		labels = append(labels, remoteWriteLabel{name: labelName, value: strings.Join(labelValues, ",")})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+	return labels
+}
+
+// remoteWriteMetricName converts a metric name to a valid Prometheus metric
+// name, matching [a-zA-Z_:][a-zA-Z0-9_:]*
+func remoteWriteMetricName(name string) string {
+	return sanitizePrometheusName(name, true)
+}
+
+// remoteWriteLabelName converts a tag name to a valid Prometheus label name,
+// matching [a-zA-Z_][a-zA-Z0-9_]* and not starting with the reserved `__`
+func remoteWriteLabelName(name string) string {
+	name = sanitizePrometheusName(name, false)
+	if strings.HasPrefix(name, "__") {
+		name = "tag" + name
+	}
+	return name
+}
+
+func sanitizePrometheusName(name string, allowColon bool) string {
+	if name == "" {
+		return "_"
+	}
+	var b strings.Builder
+	b.Grow(len(name) + 1)
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':' && allowColon:
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// MarshalPrometheusRemoteWrite encodes the series into snappy-compressed
+// Prometheus remote-write payloads holding at most maxSamplesPerPayload
+// samples each. The series keep their Datadog semantics: counts and rates
+// are sent as the value of each flush interval.
+func (series Series) MarshalPrometheusRemoteWrite(maxSamplesPerPayload int) ([]*[]byte, error) {
+	encoder := newRemoteWriteEncoder(maxSamplesPerPayload)
+
+	for _, serie := range series {
+		if len(serie.Points) == 0 {
+			continue
+		}
+		samples := make([]remoteWriteSample, 0, len(serie.Points))
+		for _, p := range serie.Points {
+			samples = append(samples, remoteWriteSample{value: p.Value, timestamp: int64(p.Ts * 1000)})
+		}
+		labels := remoteWriteLabels(serie.Name, serie.Host, serie.Device, serie.Tags)
+		if err := encoder.addTimeSeries(labels, samples); err != nil {
+			return nil, err
+		}
+	}
+	return encoder.finish(), nil
+}
+
+// MarshalPrometheusRemoteWrite encodes the sketches into snappy-compressed
+// Prometheus remote-write payloads holding at most maxSamplesPerPayload
+// samples each. Each sketch is sent as a Prometheus summary: `<name>` with
+// a `quantile` label, `<name>_sum`, `<name>_count`, `<name>_min` and `<name>_max`.
+func (sl SketchSeriesList) MarshalPrometheusRemoteWrite(maxSamplesPerPayload int) ([]*[]byte, error) {
+	encoder := newRemoteWriteEncoder(maxSamplesPerPayload)
+	quantileConfig := quantile.Default()
+
+	for _, ss := range sl {
+		if len(ss.Points) == 0 {
+			continue
+		}
+
+		quantiles := make([][]remoteWriteSample, len(remoteWriteSketchQuantiles))
+		var sum, count, minimum, maximum []remoteWriteSample
+		for _, p := range ss.Points {
+			if p.Sketch == nil {
+				continue
+			}
+			ts := p.Ts * 1000
+			for i, q := range remoteWriteSketchQuantiles {
+				quantiles[i] = append(quantiles[i], remoteWriteSample{value: p.Sketch.Quantile(quantileConfig, q), timestamp: ts})
+			}
+			basic := p.Sketch.Basic
+			sum = append(sum, remoteWriteSample{value: basic.Sum, timestamp: ts})
+			count = append(count, remoteWriteSample{value: float64(basic.Cnt), timestamp: ts})
+			minimum = append(minimum, remoteWriteSample{value: basic.Min, timestamp: ts})
+			maximum = append(maximum, remoteWriteSample{value: basic.Max, timestamp: ts})
+		}
+		if len(count) == 0 {
+			continue
+		}
+
+		for i, q := range remoteWriteSketchQuantiles {
+			quantileLabel := remoteWriteLabel{name: "quantile", value: strconv.FormatFloat(q, 'f', -1, 64)}
+			labels := remoteWriteLabels(ss.Name, ss.Host, "", ss.Tags, quantileLabel)
+			if err := encoder.addTimeSeries(labels, quantiles[i]); err != nil {
+				return nil, err
+			}
+		}
+		aggregates := []struct {
+			suffix  string
+			samples []remoteWriteSample
+		}{{"_sum", sum}, {"_count", count}, {"_min", minimum}, {"_max", maximum}}
+		for _, aggregate := range aggregates {
+			labels := remoteWriteLabels(ss.Name+aggregate.suffix, ss.Host, "", ss.Tags)
+			if err := encoder.addTimeSeries(labels, aggregate.samples); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return encoder.finish(), nil
+}
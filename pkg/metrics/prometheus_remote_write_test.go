@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build test
+
+package metrics
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decodedTimeSeries struct {
+	labels  map[string]string
+	samples []remoteWriteSample
+}
+
+// protoFields decodes the fields of a protobuf message, calling fn with the
+// field number and either the raw bytes of a length-delimited field or the
+// value of a varint or fixed64 field.
+func protoFields(t *testing.T, b []byte, fn func(field uint64, data []byte, value uint64)) {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		require.True(t, n > 0)
+		b = b[n:]
+		field, wireType := key>>3, key&7
+		switch wireType {
+		case 0: // varint
+			value, n := binary.Uvarint(b)
+			require.True(t, n > 0)
+			b = b[n:]
+			fn(field, nil, value)
+		case 1: // fixed64
+			require.True(t, len(b) >= 8)
+			fn(field, nil, binary.LittleEndian.Uint64(b))
+			b = b[8:]
+		case 2: // length-delimited
+			length, n := binary.Uvarint(b)
+			require.True(t, n > 0)
+			b = b[n:]
+			require.True(t, uint64(len(b)) >= length)
+			fn(field, b[:length], 0)
+			b = b[length:]
+		default:
+			t.Fatalf("unexpected wire type %d", wireType)
+		}
+	}
+}
+
+func decodeRemoteWrite(t *testing.T, payload []byte) []decodedTimeSeries {
+	decompressed, err := snappy.Decode(nil, payload)
+	require.NoError(t, err)
+
+	var series []decodedTimeSeries
+	protoFields(t, decompressed, func(field uint64, data []byte, _ uint64) {
+		require.Equal(t, uint64(1), field)
+		ts := decodedTimeSeries{labels: map[string]string{}}
+		protoFields(t, data, func(field uint64, data []byte, _ uint64) {
+			switch field {
+			case 1:
+				var name, value string
+				protoFields(t, data, func(field uint64, data []byte, _ uint64) {
+					if field == 1 {
+						name = string(data)
+					} else {
+						value = string(data)
+					}
+				})
+				ts.labels[name] = value
+			case 2:
+				var s remoteWriteSample
+				protoFields(t, data, func(field uint64, _ []byte, value uint64) {
+					if field == 1 {
+						s.value = math.Float64frombits(value)
+					} else {
+						s.timestamp = int64(value)
+					}
+				})
+				ts.samples = append(ts.samples, s)
+			}
+		})
+		series = append(series, ts)
+	})
+	return series
+}
+
+func TestRemoteWriteLabels(t *testing.T) {
+	labels := remoteWriteLabels("my.metric-name", "myhost", "sda1",
+		[]string{"env:prod", "role:web", "role:db", "team:a:b", "standalone", "__internal:x", "1st:y", "host:other"})
+
+	assert.Equal(t, []remoteWriteLabel{
+		{name: "_1st", value: "y"},
+		{name: "__name__", value: "my_metric_name"},
+		{name: "device", value: "sda1"},
+		{name: "env", value: "prod"},
+		{name: "host", value: "other"},
+		{name: "role", value: "db,web"},
+		{name: "standalone", value: "true"},
+		{name: "tag__internal", value: "x"},
+		{name: "team", value: "a:b"},
+	}, labels)
+}
+
+func TestSanitizePrometheusName(t *testing.T) {
+	assert.Equal(t, "a_b:c", remoteWriteMetricName("a.b:c"))
+	assert.Equal(t, "a_b_c", remoteWriteLabelName("a.b:c"))
+	assert.Equal(t, "_9lives", remoteWriteMetricName("9lives"))
+	assert.Equal(t, "_", remoteWriteLabelName(""))
+	assert.Equal(t, "caf_", remoteWriteMetricName("café"))
+}
+
+func TestSeriesMarshalPrometheusRemoteWrite(t *testing.T) {
+	series := Series{
+		{
+			Name:   "system.load.1",
+			Host:   "myhost",
+			Tags:   []string{"env:prod"},
+			Points: []Point{{Ts: 1600000000, Value: 1.5}, {Ts: 1600000015, Value: 2}},
+		},
+		{
+			Name:   "empty",
+			Points: nil,
+		},
+		{
+			Name:   "system.load.5",
+			Points: []Point{{Ts: 1600000000, Value: 3}},
+		},
+	}
+
+	payloads, err := series.MarshalPrometheusRemoteWrite(100)
+	require.NoError(t, err)
+	require.Len(t, payloads, 1)
+
+	decoded := decodeRemoteWrite(t, *payloads[0])
+	require.Len(t, decoded, 2)
+	assert.Equal(t, map[string]string{"__name__": "system_load_1", "host": "myhost", "env": "prod"}, decoded[0].labels)
+	assert.Equal(t, []remoteWriteSample{{value: 1.5, timestamp: 1600000000000}, {value: 2, timestamp: 1600000015000}}, decoded[0].samples)
+	assert.Equal(t, map[string]string{"__name__": "system_load_5"}, decoded[1].labels)
+	assert.Equal(t, []remoteWriteSample{{value: 3, timestamp: 1600000000000}}, decoded[1].samples)
+}
+
+func TestSeriesMarshalPrometheusRemoteWriteSplit(t *testing.T) {
+	var series Series
+	for i := 0; i < 5; i++ {
+		series = append(series, &Serie{
+			Name:   fmt.Sprintf("metric.%d", i),
+			Points: []Point{{Ts: 10, Value: 1}, {Ts: 20, Value: 2}},
+		})
+	}
+
+	payloads, err := series.MarshalPrometheusRemoteWrite(4)
+	require.NoError(t, err)
+	require.Len(t, payloads, 3)
+
+	var names []string
+	for _, payload := range payloads {
+		for _, ts := range decodeRemoteWrite(t, *payload) {
+			names = append(names, ts.labels["__name__"])
+		}
+	}
+	assert.Equal(t, []string{"metric_0", "metric_1", "metric_2", "metric_3", "metric_4"}, names)
+
+	payloads, err = Series{}.MarshalPrometheusRemoteWrite(4)
+	require.NoError(t, err)
+	assert.Len(t, payloads, 0)
+}
+
+func TestSketchSeriesMarshalPrometheusRemoteWrite(t *testing.T) {
+	sl := SketchSeriesList{Makeseries(0)}
+
+	payloads, err := sl.MarshalPrometheusRemoteWrite(1000)
+	require.NoError(t, err)
+	require.Len(t, payloads, 1)
+
+	decoded := decodeRemoteWrite(t, *payloads[0])
+	require.Len(t, decoded, len(remoteWriteSketchQuantiles)+4)
+
+	for i, q := range remoteWriteSketchQuantiles {
+		assert.Equal(t, "name_0", decoded[i].labels["__name__"])
+		assert.Equal(t, fmt.Sprint(q), decoded[i].labels["quantile"])
+		assert.Equal(t, "host.0", decoded[i].labels["host"])
+		assert.Equal(t, "0", decoded[i].labels["a"])
+		assert.Len(t, decoded[i].samples, 5)
+	}
+
+	aggregates := decoded[len(remoteWriteSketchQuantiles):]
+	for i, name := range []string{"name_0_sum", "name_0_count", "name_0_min", "name_0_max"} {
+		assert.Equal(t, name, aggregates[i].labels["__name__"])
+		assert.NotContains(t, aggregates[i].labels, "quantile")
+	}
+
+	// the last point is a sketch of 0, 1, 2 and 3
+	count := aggregates[1].samples[4]
+	assert.Equal(t, remoteWriteSample{value: 4, timestamp: 40000}, count)
+	assert.Equal(t, 6.0, aggregates[0].samples[4].value)
+	assert.Equal(t, 3.0, aggregates[3].samples[4].value)
+}
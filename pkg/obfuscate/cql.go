@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"regexp"
+)
+
+// ObfuscateCQLString quantizes and obfuscates the given Cassandra CQL query. It applies
+// the same rules as ObfuscateSQLString, with a tokenizer aware of the CQL syntax:
+// UUID, blob and duration constants and collection literals are obfuscated, double-quoted
+// strings are identifiers and backslashes are never escape characters.
+func (o *Obfuscator) ObfuscateCQLString(in string) (*ObfuscatedQuery, error) {
+	tok := NewSQLTokenizer(in, true, &o.opts.SQL)
+	tok.cql = true
+	return attemptObfuscation(tok)
+}
+
+// cqlUUID matches the UUID constants of CQL.
+var cqlUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// scanCQLLiteral scans the tokens whose syntax is specific to CQL. It returns false
+// if the next token should be scanned as a SQL token.
+func (tkn *SQLTokenizer) scanCQLLiteral() (TokenKind, []byte, bool) {
+	ch := tkn.lastChar
+	switch {
+	case ch == '"':
+		// double-quoted strings are case-sensitive identifiers
+		tkn.advance()
+		kind, tok := tkn.scanString(ch, ID)
+		return kind, tok, true
+	case ch == '{':
+		// set and map literals
+		return tkn.scanCQLCollection(), tkn.bytes(), true
+	case digitVal(ch) < 16:
+		if loc := cqlUUID.FindIndex(tkn.unread()); loc != nil && !tkn.isIdentifierAt(loc[1]) {
+			tkn.advanceBytes(loc[1])
+			return Number, tkn.bytes(), true
+		}
+		if !isDigit(ch) {
+			return 0, nil, false
+		}
+		kind, tok := tkn.scanNumber(false)
+		if kind == Number && isLetter(tkn.lastChar) {
+			// the units of a duration constant, e.g. 1h30m
+			for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) {
+				tkn.advance()
+			}
+			tkn.bytes()
+		}
+		return kind, tok, true
+	}
+	return 0, nil, false
+}
+
+// scanCQLCollection scans a set or map literal, including the nested ones.
+func (tkn *SQLTokenizer) scanCQLCollection() TokenKind {
+	depth := 0
+	for {
+		switch tkn.lastChar {
+		case EndChar:
+			tkn.setErr("unexpected EOF in collection literal")
+			return LexError
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				tkn.advance()
+				return String
+			}
+		case '\'':
+			// skip the strings, in which the quotes are escaped by doubling them
+			tkn.advance()
+			for {
+				ch := tkn.lastChar
+				if ch == EndChar {
+					tkn.setErr("unexpected EOF in string")
+					return LexError
+				}
+				tkn.advance()
+				if ch == '\'' {
+					if tkn.lastChar != '\'' {
+						break
+					}
+					tkn.advance()
+				}
+			}
+			continue
+		}
+		tkn.advance()
+	}
+}
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateCQLString(t *testing.T) {
+	o := NewObfuscator(Config{})
+	for _, tt := range []sqlTestCase{
+		{
+			"select key, status, modified from org_check_run where org_id = %s and check in (%s, %s, %s)",
+			"select key, status, modified from org_check_run where org_id = ? and check in ( ? )",
+		},
+		{
+			"SELECT * FROM ks.users WHERE id = 123e4567-e89b-12d3-a456-426655440000",
+			"SELECT * FROM ks.users WHERE id = ?",
+		},
+		{
+			"SELECT * FROM users WHERE id = f47ac10b-58cc-4372-a567-0e02b2c3d479 AND name = 'O''Brien'",
+			"SELECT * FROM users WHERE id = ? AND name = ?",
+		},
+		{
+			`SELECT "userId", name FROM users WHERE "userId" = 'C:\Users'`,
+			"SELECT userId, name FROM users WHERE userId = ?",
+		},
+		{
+			"INSERT INTO users (id, tags, props) VALUES (?, {'a', 'b'}, {'k': {'nested': 1}}) USING TTL 86400",
+			"INSERT INTO users ( id, tags, props ) VALUES ( ? ) USING TTL ?",
+		},
+		{
+			"UPDATE users SET payload = 0xcafebabe, timeout = 1h30m, scores = scores + [1, 2] WHERE id = :id",
+			"UPDATE users SET payload = ? timeout = ? scores = scores + [ ? ] WHERE id = :id",
+		},
+		{
+			"SELECT * FROM events WHERE day = '2021-01-01' AND deleted = false -- the active events",
+			"SELECT * FROM events WHERE day = ? AND deleted = ?",
+		},
+		{
+			"SELECT body FROM scripts WHERE code = $$ it's a 'script' $$ LIMIT 10",
+			"SELECT body FROM scripts WHERE code = ? LIMIT ?",
+		},
+		{
+			"SELECT deadbeef FROM t WHERE c = 1",
+			"SELECT deadbeef FROM t WHERE c = ?",
+		},
+	} {
+		oq, err := o.ObfuscateCQLString(tt.query)
+		if assert.NoError(t, err, tt.query) {
+			assert.Equal(t, tt.expected, oq.Query, tt.query)
+		}
+	}
+
+	_, err := o.ObfuscateCQLString("INSERT INTO t (m) VALUES ({'k': 1)")
+	assert.Error(t, err)
+}
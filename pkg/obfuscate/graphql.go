@@ -0,0 +1,242 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"strings"
+)
+
+// graphqlContext is the kind of a block opened in a GraphQL document.
+type graphqlContext uint8
+
+const (
+	graphqlSelection graphqlContext = iota // a selection set: { ... }
+	graphqlArguments                       // arguments or variable definitions: ( ... )
+	graphqlObject                          // an input object value: { ... }
+	graphqlList                            // a list value: [ ... ]
+	graphqlListType                        // a list type: [ ... ]
+)
+
+// ObfuscateGraphQLString obfuscates the given GraphQL document: the string, number,
+// boolean, null and enum values are replaced with "?" while the operation, fragment,
+// field, argument and variable names are kept. The comments are removed and the
+// whitespaces are compacted.
+func (*Obfuscator) ObfuscateGraphQLString(query string) string {
+	g := graphqlObfuscator{in: query}
+	return g.obfuscate()
+}
+
+// graphqlObfuscator holds the state of the obfuscation of a GraphQL document.
+type graphqlObfuscator struct {
+	in  string
+	pos int
+	out strings.Builder
+
+	contexts    []graphqlContext
+	expectValue bool // true when the next token is a value, e.g. after "name:"
+	variable    bool // true when the last token is a variable, e.g. "$name"
+	separated   bool // true when a separator was skipped since the last token
+}
+
+func (g *graphqlObfuscator) obfuscate() string {
+	for {
+		g.skipIgnored()
+		if g.pos >= len(g.in) {
+			break
+		}
+		g.scan()
+	}
+	return g.out.String()
+}
+
+// skipIgnored skips the whitespaces, the line terminators and the comments.
+func (g *graphqlObfuscator) skipIgnored() {
+	for g.pos < len(g.in) {
+		switch c := g.in[g.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			g.pos++
+		case c == '#':
+			for g.pos < len(g.in) && g.in[g.pos] != '\n' && g.in[g.pos] != '\r' {
+				g.pos++
+			}
+		case strings.HasPrefix(g.in[g.pos:], "\uFEFF"):
+			g.pos += len("\uFEFF")
+		default:
+			return
+		}
+		g.separated = true
+	}
+}
+
+// scan scans the next token and writes it, or its replacement, to the output.
+func (g *graphqlObfuscator) scan() {
+	start := g.pos
+	c := g.in[g.pos]
+	switch {
+	case c == '"':
+		g.scanString()
+		g.writeValue("?")
+	case c == '-' || isDigit(rune(c)):
+		g.scanNumber()
+		g.writeValue("?")
+	case c == '$':
+		g.pos++
+		g.scanName()
+		g.writeValue(g.in[start:g.pos])
+		g.variable = true
+		return
+	case c == '_' || isASCIILetter(c):
+		g.scanName()
+		if g.inValue() {
+			// true, false, null and the enum values
+			g.writeValue("?")
+		} else {
+			g.write(g.in[start:g.pos])
+		}
+	case strings.HasPrefix(g.in[g.pos:], "..."):
+		g.pos += 3
+		g.write("...")
+	default:
+		g.pos++
+		g.scanPunctuator(c)
+	}
+	g.variable = false
+}
+
+// scanPunctuator updates the state of the obfuscator with the punctuator c and writes it.
+func (g *graphqlObfuscator) scanPunctuator(c byte) {
+	switch c {
+	case ':':
+		// the variable definitions are followed by types, the arguments and fields by values
+		top, ok := g.top()
+		g.expectValue = ok && !g.variable && (top == graphqlArguments || top == graphqlObject)
+	case '=':
+		// default values
+		g.expectValue = true
+	case '(':
+		g.push(graphqlArguments)
+	case '{':
+		if g.inValue() {
+			g.push(graphqlObject)
+		} else {
+			g.push(graphqlSelection)
+		}
+	case '[':
+		if g.inValue() {
+			g.push(graphqlList)
+		} else {
+			g.push(graphqlListType)
+		}
+	case ')', '}', ']':
+		g.pop()
+	}
+	g.write(string(c))
+}
+
+// inValue reports whether the next token is a value.
+func (g *graphqlObfuscator) inValue() bool {
+	top, ok := g.top()
+	return g.expectValue || (ok && top == graphqlList)
+}
+
+func (g *graphqlObfuscator) top() (graphqlContext, bool) {
+	if len(g.contexts) == 0 {
+		return 0, false
+	}
+	return g.contexts[len(g.contexts)-1], true
+}
+
+func (g *graphqlObfuscator) push(c graphqlContext) {
+	g.contexts = append(g.contexts, c)
+	g.expectValue = false
+}
+
+func (g *graphqlObfuscator) pop() {
+	if len(g.contexts) > 0 {
+		g.contexts = g.contexts[:len(g.contexts)-1]
+	}
+	g.expectValue = false
+}
+
+// writeValue writes a value token, ending the value expected after ":" or "=".
+func (g *graphqlObfuscator) writeValue(s string) {
+	g.write(s)
+	g.expectValue = false
+}
+
+// write writes a token, separated from the previous one by a space if they were separated
+// in the input.
+func (g *graphqlObfuscator) write(s string) {
+	if g.separated && g.out.Len() > 0 {
+		g.out.WriteByte(' ')
+	}
+	g.separated = false
+	g.out.WriteString(s)
+}
+
+// scanString scans a string or a block string. An unterminated string ends the document.
+func (g *graphqlObfuscator) scanString() {
+	if strings.HasPrefix(g.in[g.pos:], `"""`) {
+		g.pos += 3
+		for g.pos < len(g.in) {
+			switch {
+			case strings.HasPrefix(g.in[g.pos:], `\"""`):
+				g.pos += 4
+			case strings.HasPrefix(g.in[g.pos:], `"""`):
+				g.pos += 3
+				return
+			default:
+				g.pos++
+			}
+		}
+		return
+	}
+	g.pos++
+	for g.pos < len(g.in) {
+		switch g.in[g.pos] {
+		case '\\':
+			g.pos += 2
+		case '"':
+			g.pos++
+			return
+		case '\n', '\r':
+			// strings can't span lines
+			return
+		default:
+			g.pos++
+		}
+	}
+	if g.pos > len(g.in) {
+		g.pos = len(g.in)
+	}
+}
+
+// scanNumber scans an integer or a float value.
+func (g *graphqlObfuscator) scanNumber() {
+	g.pos++
+	for g.pos < len(g.in) {
+		c := g.in[g.pos]
+		if !isDigit(rune(c)) && c != '.' && c != 'e' && c != 'E' && c != '+' && c != '-' {
+			return
+		}
+		g.pos++
+	}
+}
+
+// scanName scans a name.
+func (g *graphqlObfuscator) scanName() {
+	for g.pos < len(g.in) {
+		c := g.in[g.pos]
+		if c != '_' && !isASCIILetter(c) && !isDigit(rune(c)) {
+			return
+		}
+		g.pos++
+	}
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateGraphQLString(t *testing.T) {
+	o := NewObfuscator(Config{})
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{
+			"{ user { name } }",
+			"{ user { name } }",
+		},
+		{
+			`query GetUser($id: ID!, $first: Int = 10) {
+				user(id: $id) {
+					# the user's friends
+					friends(first: $first, after: "Y3Vyc29y") { edges { node { name } } }
+				}
+			}`,
+			"query GetUser($id: ID!, $first: Int = ?) { user(id: $id) { friends(first: $first, after: ?) { edges { node { name } } } } }",
+		},
+		{
+			`mutation CreateUser { createUser(input: {name: "Jane", age: 42, score: -1.5e3, role: ADMIN, active: true, ref: null}) { id } }`,
+			"mutation CreateUser { createUser(input: {name: ?, age: ?, score: ?, role: ?, active: ?, ref: ?}) { id } }",
+		},
+		{
+			`query Search($tags: [String!]! = ["a", "b"]) { search(ids: [1, 2, 3], filter: {tags: $tags, nested: [{id: 4}]}) @include(if: false) { ...Result } }`,
+			"query Search($tags: [String!]! = [?, ?]) { search(ids: [?, ?, ?], filter: {tags: $tags, nested: [{id: ?}]}) @include(if: ?) { ...Result } }",
+		},
+		{
+			`fragment Result on Item { id ... on Book { title(lang: EN) } }`,
+			"fragment Result on Item { id ... on Book { title(lang: ?) } }",
+		},
+		{
+			`{ doc(body: """a "block"
+string \""" ending""") { id } }`,
+			"{ doc(body: ?) { id } }",
+		},
+		{
+			`{ alias: user(id: 1) { name } }`,
+			"{ alias: user(id: ?) { name } }",
+		},
+		{
+			`{ user(name: "unterminated`,
+			"{ user(name: ?",
+		},
+	} {
+		assert.Equal(t, tt.out, o.ObfuscateGraphQLString(tt.in))
+	}
+}
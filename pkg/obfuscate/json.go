@@ -20,6 +20,44 @@ func (o *Obfuscator) ObfuscateElasticSearchString(cmd string) string {
 	return obfuscateJSONString(cmd, o.es)
 }
 
+// ObfuscateOpenSearchString obfuscates the given OpenSearch JSON query.
+func (o *Obfuscator) ObfuscateOpenSearchString(cmd string) string {
+	return obfuscateJSONString(cmd, o.openSearch)
+}
+
+// ObfuscateDynamoDBString obfuscates the given DynamoDB JSON request: the attribute values
+// are obfuscated while the table names, the expressions and their attribute names are kept.
+// The PartiQL statements are passed through SQL obfuscation.
+func (o *Obfuscator) ObfuscateDynamoDBString(cmd string) string {
+	return obfuscateJSONString(cmd, o.dynamoDB)
+}
+
+// dynamoDBKeepValues holds the keys of the DynamoDB request parameters which never hold
+// attribute values. The expressions only reference the values through placeholders
+// (e.g. ":id") which are defined in ExpressionAttributeValues.
+var dynamoDBKeepValues = []string{
+	"TableName",
+	"IndexName",
+	"KeyConditionExpression",
+	"FilterExpression",
+	"ProjectionExpression",
+	"UpdateExpression",
+	"ConditionExpression",
+	"ExpressionAttributeNames",
+	"Select",
+	"ReturnValues",
+	"ReturnConsumedCapacity",
+	"ReturnItemCollectionMetrics",
+	"ConsistentRead",
+	"ScanIndexForward",
+	"Limit",
+	"Segment",
+	"TotalSegments",
+}
+
+// dynamoDBSQLValues holds the keys of the DynamoDB PartiQL statements.
+var dynamoDBSQLValues = []string{"Statement"}
+
 // obfuscateJSONString obfuscates the given span's tag using the given obfuscator. If the obfuscator is
 // nil it is considered disabled.
 func obfuscateJSONString(cmd string, obfuscator *jsonObfuscator) string {
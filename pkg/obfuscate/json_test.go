@@ -140,3 +140,45 @@ func BenchmarkObfuscateJSON(b *testing.B) {
 		})
 	}
 }
+
+func TestObfuscateDynamoDBString(t *testing.T) {
+	o := NewObfuscator(Config{DynamoDB: JSONConfig{Enabled: true}})
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{
+			`{"TableName": "users", "IndexName": "by_email", "KeyConditionExpression": "email = :email AND #ts > :since",
+			  "ExpressionAttributeNames": {"#ts": "timestamp"},
+			  "ExpressionAttributeValues": {":email": {"S": "jane@example.com"}, ":since": {"N": "1650000000"}}, "Limit": 10}`,
+			`{"TableName": "users", "IndexName": "by_email", "KeyConditionExpression": "email = :email AND #ts > :since",
+			  "ExpressionAttributeNames": {"#ts": "timestamp"},
+			  "ExpressionAttributeValues": {":email": {"S": "?"}, ":since": {"N": "?"}}, "Limit": 10}`,
+		},
+		{
+			`{"TableName": "users", "Item": {"id": {"S": "42"}, "tags": {"SS": ["a", "b"]}}, "ConditionExpression": "attribute_not_exists(id)"}`,
+			`{"TableName": "users", "Item": {"id": {"S": "?"}, "tags": {"SS": ["?", "?"]}}, "ConditionExpression": "attribute_not_exists(id)"}`,
+		},
+		{
+			`{"Statement": "SELECT * FROM users WHERE id = '42'", "Parameters": [{"S": "42"}]}`,
+			`{"Statement": "SELECT * FROM users WHERE id = ?", "Parameters": [{"S": "?"}]}`,
+		},
+	} {
+		assertEqualJSON(t, tt.out, o.ObfuscateDynamoDBString(tt.in))
+	}
+
+	// disabled
+	in := `{"TableName": "users", "Key": {"id": {"S": "42"}}}`
+	assert.Equal(t, in, NewObfuscator(Config{}).ObfuscateDynamoDBString(in))
+}
+
+func TestObfuscateOpenSearchString(t *testing.T) {
+	o := NewObfuscator(Config{OpenSearch: JSONConfig{Enabled: true, KeepValues: []string{"size"}}})
+	assertEqualJSON(t,
+		`{"query": {"match": {"title": "?"}}, "size": 10}`,
+		o.ObfuscateOpenSearchString(`{"query": {"match": {"title": "secret"}}, "size": 10}`),
+	)
+
+	// disabled
+	in := `{"query": {"match": {"title": "secret"}}}`
+	assert.Equal(t, in, NewObfuscator(Config{}).ObfuscateOpenSearchString(in))
+}
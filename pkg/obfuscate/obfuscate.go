@@ -27,6 +27,8 @@ type Obfuscator struct {
 	opts                 *Config
 	es                   *jsonObfuscator // nil if disabled
 	mongo                *jsonObfuscator // nil if disabled
+	openSearch           *jsonObfuscator // nil if disabled
+	dynamoDB             *jsonObfuscator // nil if disabled
 	sqlExecPlan          *jsonObfuscator // nil if disabled
 	sqlExecPlanNormalize *jsonObfuscator // nil if disabled
 	// sqlLiteralEscapes reports whether we should treat escape characters literally or as escape characters.
@@ -76,6 +78,14 @@ type Config struct {
 	// Mongo holds the obfuscation configuration for MongoDB queries.
 	Mongo JSONConfig
 
+	// OpenSearch holds the obfuscation configuration for OpenSearch bodies.
+	OpenSearch JSONConfig
+
+	// DynamoDB holds the obfuscation configuration for DynamoDB requests. The table and
+	// index names, the expressions and the other request parameters are always kept
+	// in addition to KeepValues (see dynamoDBKeepValues).
+	DynamoDB JSONConfig
+
 	// SQLExecPlan holds the obfuscation configuration for SQL Exec Plans. This is strictly for safety related obfuscation,
 	// not normalization. Normalization of exec plans is configured in SQLExecPlanNormalize.
 	SQLExecPlan JSONConfig
@@ -122,8 +132,51 @@ type SQLConfig struct {
 
 	// Cache reports whether the obfuscator should use a LRU look-up cache for SQL obfuscations.
 	Cache bool
+
+	// DBMS identifies the database management system which the queries are addressed to, as found
+	// in the "db.system" tag (e.g. "mysql", "postgresql", "mssql" or "oracle"). It enables the
+	// handling of the syntax specific to its dialect. If empty or unknown, a generic dialect is used.
+	DBMS string `json:"dbms"`
+
+	// ObfuscationMode specifies how the queries are obfuscated. By default, their literals are
+	// replaced and they are normalized.
+	ObfuscationMode ObfuscationMode `json:"obfuscation_mode"`
+
+	// KeepComments reports whether the block comments (/* ... */) should be kept in the queries.
+	// The line comments are always removed as the obfuscated queries fit on a single line.
+	KeepComments bool `json:"keep_comments"`
+
+	// CollectOperations reports whether the obfuscator should also extract the operations that
+	// a query executes (e.g. SELECT or INSERT).
+	CollectOperations bool `json:"collect_operations"`
+
+	// CollectProcedures reports whether the obfuscator should also extract the stored procedures
+	// that a query calls.
+	CollectProcedures bool `json:"collect_procedures"`
 }
 
+// ObfuscationMode specifies how the SQL queries are obfuscated.
+type ObfuscationMode string
+
+const (
+	// ObfuscateAndNormalize replaces the literals of the queries and normalizes them. It is the default.
+	ObfuscateAndNormalize ObfuscationMode = ""
+
+	// NormalizeOnly normalizes the queries (whitespaces, comments, aliases and quoted identifiers)
+	// without replacing their literals, for the environments where the literals are not sensitive.
+	NormalizeOnly ObfuscationMode = "normalize_only"
+)
+
+// The database management systems whose SQL dialect is known to the obfuscator, as found in the
+// "db.system" tag.
+const (
+	DBMSMySQL     = "mysql"
+	DBMSMariaDB   = "mariadb"
+	DBMSPostgres  = "postgresql"
+	DBMSSQLServer = "mssql"
+	DBMSOracle    = "oracle"
+)
+
 // HTTPConfig holds the configuration settings for HTTP obfuscation.
 type HTTPConfig struct {
 	// RemoveQueryStrings determines query strings to be removed from HTTP URLs.
@@ -163,6 +216,15 @@ func NewObfuscator(cfg Config) *Obfuscator {
 	if cfg.Mongo.Enabled {
 		o.mongo = newJSONObfuscator(&cfg.Mongo, &o)
 	}
+	if cfg.OpenSearch.Enabled {
+		o.openSearch = newJSONObfuscator(&cfg.OpenSearch, &o)
+	}
+	if cfg.DynamoDB.Enabled {
+		dynamoDB := cfg.DynamoDB
+		dynamoDB.KeepValues = append(append([]string{}, dynamoDBKeepValues...), dynamoDB.KeepValues...)
+		dynamoDB.ObfuscateSQLValues = append(append([]string{}, dynamoDBSQLValues...), dynamoDB.ObfuscateSQLValues...)
+		o.dynamoDB = newJSONObfuscator(&dynamoDB, &o)
+	}
 	if cfg.SQLExecPlan.Enabled {
 		o.sqlExecPlan = newJSONObfuscator(&cfg.SQLExecPlan, &o)
 	}
@@ -121,7 +121,14 @@ func TestReplaceDigits(t *testing.T) {
 // TestSQLObfuscationOptionsDeserializationMethod checks if the use of easyjson results in the same deserialization
 // output as encoding/json.
 func TestSQLObfuscationOptionsDeserializationMethod(t *testing.T) {
-	opts, err := json.Marshal(SQLConfig{ReplaceDigits: true})
+	opts, err := json.Marshal(SQLConfig{
+		ReplaceDigits:     true,
+		DBMS:              DBMSPostgres,
+		ObfuscationMode:   NormalizeOnly,
+		KeepComments:      true,
+		CollectOperations: true,
+		CollectProcedures: true,
+	})
 	require.NoError(t, err)
 
 	var in, out SQLConfig
@@ -134,6 +141,8 @@ func TestSQLObfuscationOptionsDeserializationMethod(t *testing.T) {
 	require.NoError(t, jl.Error())
 
 	assert.Equal(t, in, out)
+	assert.Equal(t, NormalizeOnly, out.ObfuscationMode)
+	assert.True(t, out.CollectProcedures)
 }
 
 func BenchmarkSQLObfuscationOptionsEasyJSONDeserialization(b *testing.B) {
@@ -18,7 +18,10 @@ var questionMark = []byte("?")
 
 // discardFilter is a token filter which discards certain elements from a query, such as
 // comments and AS aliases by returning a nil buffer.
-type discardFilter struct{ keepSQLAlias bool }
+type discardFilter struct {
+	keepSQLAlias bool
+	keepComments bool
+}
 
 // Filter the given token so that a `nil` slice is returned if the token is in the token filtered list.
 func (f *discardFilter) Filter(token, lastToken TokenKind, buffer []byte) (TokenKind, []byte, error) {
@@ -52,6 +55,9 @@ func (f *discardFilter) Filter(token, lastToken TokenKind, buffer []byte) (Token
 	// return the same token value (not FilteredGroupable) and nil
 	switch token {
 	case Comment:
+		if f.keepComments && bytes.HasPrefix(buffer, []byte("/*")) {
+			return token, buffer, nil
+		}
 		return Filtered, nil, nil
 	case ';':
 		return markFilteredGroupable(token), nil, nil
@@ -191,17 +197,41 @@ func (o *Obfuscator) ObfuscateSQLString(in string) (*ObfuscatedQuery, error) {
 // to quantize and obfuscate the given input SQL query string. Quantization removes some elements such as comments
 // and aliases and obfuscation attempts to hide sensitive information in strings and numbers by redacting them.
 func (o *Obfuscator) ObfuscateSQLStringWithOptions(in string, opts *SQLConfig) (*ObfuscatedQuery, error) {
-	if v, ok := o.queryCache.Get(in); ok {
+	key := opts.cacheKey(in)
+	if v, ok := o.queryCache.Get(key); ok {
 		return v.(*ObfuscatedQuery), nil
 	}
 	oq, err := o.obfuscateSQLString(in, opts)
 	if err != nil {
 		return oq, err
 	}
-	o.queryCache.Set(in, oq, oq.Cost())
+	o.queryCache.Set(key, oq, oq.Cost())
 	return oq, nil
 }
 
+// ObfuscateSQLStringForDBMS quantizes and obfuscates the given input SQL query string like ObfuscateSQLString,
+// handling the syntax specific to the dialect of the given database management system, as found in the
+// "db.system" tag. An empty or unknown DBMS selects the configured dialect.
+func (o *Obfuscator) ObfuscateSQLStringForDBMS(in, dbms string) (*ObfuscatedQuery, error) {
+	if _, ok := sqlDialects[dbms]; !ok || dbms == o.opts.SQL.DBMS {
+		return o.ObfuscateSQLString(in)
+	}
+	opts := o.opts.SQL
+	opts.DBMS = dbms
+	return o.ObfuscateSQLStringWithOptions(in, &opts)
+}
+
+// cacheKey returns the key of the obfuscation of the query in in the query cache. The options
+// selecting the dialect, the mode and the extracted metadata are part of the key when set.
+func (opts *SQLConfig) cacheKey(in string) string {
+	if opts.DBMS == "" && opts.ObfuscationMode == ObfuscateAndNormalize && !opts.KeepComments &&
+		!opts.CollectOperations && !opts.CollectProcedures {
+		return in
+	}
+	return fmt.Sprintf("%s|%s|%t|%t|%t|%s", opts.DBMS, opts.ObfuscationMode, opts.KeepComments,
+		opts.CollectOperations, opts.CollectProcedures, in)
+}
+
 func (o *Obfuscator) obfuscateSQLString(in string, opts *SQLConfig) (*ObfuscatedQuery, error) {
 	lesc := o.useSQLLiteralEscapes()
 	tok := NewSQLTokenizer(in, lesc, opts)
@@ -280,29 +310,148 @@ func (f *tableFinderFilter) Reset() {
 	f.csv.Reset()
 }
 
+// sqlOperations holds the keywords starting the operations extracted by the operationFinderFilter.
+var sqlOperations = map[string]bool{
+	"SELECT":   true,
+	"INSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"MERGE":    true,
+	"UPSERT":   true,
+	"CREATE":   true,
+	"ALTER":    true,
+	"DROP":     true,
+	"TRUNCATE": true,
+	"GRANT":    true,
+	"REVOKE":   true,
+	"BEGIN":    true,
+	"COMMIT":   true,
+	"ROLLBACK": true,
+	"CALL":     true,
+	"EXEC":     true,
+	"EXECUTE":  true,
+}
+
+// sqlOperationQualifiers holds the keywords after which the operation keywords are clauses of
+// another operation, e.g. ON DELETE CASCADE, ON DUPLICATE KEY UPDATE or SELECT ... FOR UPDATE.
+var sqlOperationQualifiers = map[string]bool{
+	"ON":  true,
+	"KEY": true,
+	"FOR": true,
+	"DO":  true,
+}
+
+// sqlProcedureCalls holds the keywords followed by the name of a stored procedure, e.g.
+// CALL procedure(...), EXEC procedure ... or {call procedure(...)}.
+var sqlProcedureCalls = map[string]bool{
+	"CALL":    true,
+	"EXEC":    true,
+	"EXECUTE": true,
+}
+
+// operationFinderFilter is a filter which extracts the operations that a query executes and the stored
+// procedures that it calls.
+type operationFinderFilter struct {
+	storeOperations bool
+	storeProcedures bool
+	operations      []string
+	procedures      []string
+	// qualified reports whether the last keyword is one of sqlOperationQualifiers.
+	qualified bool
+	// procedureNext reports whether the next identifier may be the name of a stored procedure.
+	procedureNext bool
+}
+
+// Filter implements tokenFilter.
+func (f *operationFinderFilter) Filter(token, lastToken TokenKind, buffer []byte) (TokenKind, []byte, error) {
+	switch token {
+	case ID, Update, Insert:
+	default:
+		if token != '=' {
+			// the assignment of the return value precedes the procedure, e.g. EXEC @ret = procedure
+			f.procedureNext = false
+		}
+		f.qualified = false
+		return token, buffer, nil
+	}
+	// see scanIdentifier for the rationale of space
+	var space [256]byte
+	upper := toUpper(buffer, space[:0])
+	if f.procedureNext {
+		switch {
+		case buffer[0] == '@' || string(upper) == "IMMEDIATE":
+			// EXEC @ret = procedure or EXECUTE IMMEDIATE '...'
+			return token, buffer, nil
+		case f.storeProcedures:
+			f.procedures = appendUnique(f.procedures, string(buffer))
+		}
+		f.procedureNext = false
+		return token, buffer, nil
+	}
+	if f.storeOperations && !f.qualified && sqlOperations[string(upper)] {
+		f.operations = appendUnique(f.operations, string(upper))
+	}
+	f.qualified = sqlOperationQualifiers[string(upper)]
+	f.procedureNext = sqlProcedureCalls[string(upper)]
+	return token, buffer, nil
+}
+
+// appendUnique appends s to list if it isn't already part of it.
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// Reset implements tokenFilter.
+func (f *operationFinderFilter) Reset() {
+	f.operations = f.operations[:0]
+	f.procedures = f.procedures[:0]
+	f.qualified = false
+	f.procedureNext = false
+}
+
 // ObfuscatedQuery specifies information about an obfuscated SQL query.
 type ObfuscatedQuery struct {
-	Query     string // the obfuscated SQL query
-	TablesCSV string // comma-separated list of tables that the query addresses
+	Query      string   // the obfuscated SQL query
+	TablesCSV  string   // comma-separated list of tables that the query addresses
+	Operations []string // the operations that the query executes (e.g. SELECT or INSERT), if collected
+	Procedures []string // the stored procedures that the query calls, if collected
 }
 
 // Cost returns the number of bytes needed to store all the fields
 // of this ObfuscatedQuery.
 func (oq *ObfuscatedQuery) Cost() int64 {
-	return int64(len(oq.Query) + len(oq.TablesCSV))
+	c := len(oq.Query) + len(oq.TablesCSV)
+	for _, v := range oq.Operations {
+		c += len(v)
+	}
+	for _, v := range oq.Procedures {
+		c += len(v)
+	}
+	return int64(c)
 }
 
 // attemptObfuscation attempts to obfuscate the SQL query loaded into the tokenizer, using the given set of filters.
 func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 	var (
 		storeTableNames = tokenizer.cfg.TableNames
+		storeOperations = tokenizer.cfg.CollectOperations || tokenizer.cfg.CollectProcedures
+		replaceLiterals = tokenizer.cfg.ObfuscationMode != NormalizeOnly
 		out             = bytes.NewBuffer(make([]byte, 0, len(tokenizer.buf)))
 		err             error
 		lastToken       TokenKind
-		discard         = discardFilter{tokenizer.cfg.KeepSQLAlias}
+		discard         = discardFilter{keepSQLAlias: tokenizer.cfg.KeepSQLAlias, keepComments: tokenizer.cfg.KeepComments}
 		replace         = replaceFilter{replaceDigits: tokenizer.cfg.ReplaceDigits}
 		grouping        groupingFilter
 		tableFinder     = tableFinderFilter{storeTableNames: storeTableNames}
+		operationFinder = operationFinderFilter{
+			storeOperations: tokenizer.cfg.CollectOperations,
+			storeProcedures: tokenizer.cfg.CollectProcedures,
+		}
 	)
 	// call Scan() function until tokens are available or if a LEX_ERROR is raised. After
 	// retrieving a token, send it to the tokenFilter chains so that the token is discarded
@@ -324,11 +473,18 @@ func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 				return nil, err
 			}
 		}
-		if token, buff, err = replace.Filter(token, lastToken, buff); err != nil {
-			return nil, err
+		if storeOperations && buff != nil {
+			if token, buff, err = operationFinder.Filter(token, lastToken, buff); err != nil {
+				return nil, err
+			}
 		}
-		if token, buff, err = grouping.Filter(token, lastToken, buff); err != nil {
-			return nil, err
+		if replaceLiterals {
+			if token, buff, err = replace.Filter(token, lastToken, buff); err != nil {
+				return nil, err
+			}
+			if token, buff, err = grouping.Filter(token, lastToken, buff); err != nil {
+				return nil, err
+			}
 		}
 		if buff != nil {
 			if out.Len() != 0 {
@@ -353,8 +509,10 @@ func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 		return nil, errors.New("result is empty")
 	}
 	return &ObfuscatedQuery{
-		Query:     out.String(),
-		TablesCSV: tableFinder.CSV(),
+		Query:      out.String(),
+		TablesCSV:  tableFinder.CSV(),
+		Operations: operationFinder.operations,
+		Procedures: operationFinder.procedures,
 	}, nil
 }
 
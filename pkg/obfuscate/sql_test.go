@@ -497,6 +497,173 @@ func TestSQLTableFinderAndReplaceDigits(t *testing.T) {
 	})
 }
 
+func TestSQLDialects(t *testing.T) {
+	for _, tt := range []struct {
+		dbms     string
+		query    string
+		expected string
+	}{
+		{
+			"",
+			`SELECT "name" FROM users WHERE "id" = "admin"`,
+			`SELECT name FROM users WHERE id = ?`,
+		},
+		{
+			DBMSMySQL,
+			"SELECT `name` FROM users WHERE name = \"admin\" AND pa$$word = 'x'",
+			"SELECT name FROM users WHERE name = ? AND pa$$word = ?",
+		},
+		{
+			DBMSMariaDB,
+			`SELECT * FROM users WHERE name = "admin"`,
+			"SELECT * FROM users WHERE name = ?",
+		},
+		{
+			DBMSPostgres,
+			`SELECT "Name" FROM "Users" WHERE "Id" = $1 AND body = $tag$it's$tag$`,
+			"SELECT Name FROM Users WHERE Id = ? AND body = ?",
+		},
+		{
+			DBMSSQLServer,
+			"SELECT [order id], [Name] FROM [dbo].[order details] WHERE [Name] = N'x'",
+			"SELECT order id, Name FROM dbo . order details WHERE Name = N ?",
+		},
+		{
+			DBMSSQLServer,
+			"SELECT * INTO #orders FROM orders; SELECT * FROM ##orders WHERE id = 1",
+			"SELECT * INTO #orders FROM orders SELECT * FROM ##orders WHERE id = ?",
+		},
+		{
+			DBMSOracle,
+			`SELECT "Name" FROM users WHERE name = q'[it's]' OR name = nQ'{a'b}' OR name = q'!x!'`,
+			"SELECT Name FROM users WHERE name = ? OR name = ? OR name = ?",
+		},
+		{
+			DBMSOracle,
+			"SELECT quantity, v$session.sid FROM v$session WHERE q = 'x'",
+			"SELECT quantity, v$session.sid FROM v$session WHERE q = ?",
+		},
+	} {
+		t.Run(tt.dbms, func(t *testing.T) {
+			oq, err := NewObfuscator(Config{}).ObfuscateSQLStringForDBMS(tt.query, tt.dbms)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, oq.Query)
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewObfuscator(Config{}).ObfuscateSQLStringForDBMS("SELECT q'[unterminated' FROM dual", DBMSOracle)
+		assert.Error(t, err)
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		o := NewObfuscator(Config{SQL: SQLConfig{Cache: true}})
+		defer o.Stop()
+		q := `SELECT "name" FROM users WHERE id = "admin"`
+		oq, err := o.ObfuscateSQLString(q)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT name FROM users WHERE id = ?", oq.Query)
+		o.queryCache.Wait()
+		oq, err = o.ObfuscateSQLStringForDBMS(q, DBMSPostgres)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT name FROM users WHERE id = admin", oq.Query)
+	})
+}
+
+func TestSQLNormalizeOnly(t *testing.T) {
+	for _, tt := range []struct {
+		dbms     string
+		query    string
+		expected string
+	}{
+		{
+			"",
+			"SELECT username AS person, 'it''s' /* comment */ FROM   users\nWHERE id IN (1, 2, 3) -- end",
+			"SELECT username, 'it''s' FROM users WHERE id IN ( 1, 2, 3 )",
+		},
+		{
+			"",
+			`UPDATE users SET name = "Jim", flag = TRUE, deleted_at = NULL WHERE id = $1 AND role = $$admin$$`,
+			`UPDATE users SET name = "Jim", flag = TRUE, deleted_at = NULL WHERE id = $1 AND role = $$admin$$`,
+		},
+		{
+			DBMSPostgres,
+			`SELECT "id" FROM t WHERE body = $body$it's$body$ AND x = 'a\'b'`,
+			`SELECT id FROM t WHERE body = $body$it's$body$ AND x = 'a\'b'`,
+		},
+		{
+			DBMSOracle,
+			"SELECT * FROM t WHERE name = q'[it's]'",
+			"SELECT * FROM t WHERE name = q'[it's]'",
+		},
+		{
+			"",
+			"SELECT * FROM t WHERE name = ''",
+			"SELECT * FROM t WHERE name = ''",
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			o := NewObfuscator(Config{SQL: SQLConfig{ObfuscationMode: NormalizeOnly}})
+			oq, err := o.ObfuscateSQLStringForDBMS(tt.query, tt.dbms)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, oq.Query)
+		})
+	}
+}
+
+func TestSQLKeepComments(t *testing.T) {
+	q := "/* controller='users',action='show' */ SELECT * FROM users -- by id\nWHERE id = 1 # mysql"
+	t.Run("off", func(t *testing.T) {
+		oq, err := NewObfuscator(Config{}).ObfuscateSQLString(q)
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM users WHERE id = ?", oq.Query)
+	})
+
+	t.Run("on", func(t *testing.T) {
+		oq, err := NewObfuscator(Config{SQL: SQLConfig{KeepComments: true}}).ObfuscateSQLString(q)
+		assert.NoError(t, err)
+		assert.Equal(t, "/* controller='users',action='show' */ SELECT * FROM users WHERE id = ?", oq.Query)
+	})
+}
+
+func TestSQLOperationsAndProcedures(t *testing.T) {
+	for _, tt := range []struct {
+		query      string
+		operations []string
+		procedures []string
+	}{
+		{"SELECT * FROM users WHERE id = 1", []string{"SELECT"}, nil},
+		{"select * from users for update", []string{"SELECT"}, nil},
+		{"INSERT INTO users (id) SELECT id FROM guests ON DUPLICATE KEY UPDATE id = 1", []string{"INSERT", "SELECT"}, nil},
+		{"INSERT INTO t VALUES (1) ON CONFLICT (id) DO UPDATE SET x = 1", []string{"INSERT"}, nil},
+		{"BEGIN; UPDATE accounts SET balance = 0; DELETE FROM logs; COMMIT", []string{"BEGIN", "UPDATE", "DELETE", "COMMIT"}, nil},
+		{"CREATE TABLE t (id INT REFERENCES u ON DELETE CASCADE)", []string{"CREATE"}, nil},
+		{"CALL sp_refresh(1, 'x')", []string{"CALL"}, []string{"sp_refresh"}},
+		{"EXEC @ret = dbo.GetUser @id = 1; EXEC dbo.GetUser 2", []string{"EXEC"}, []string{"dbo.GetUser"}},
+		{"{call get_orders(?, ?)}", []string{"CALL"}, []string{"get_orders"}},
+		{"{? = call get_total(?)}", []string{"CALL"}, []string{"get_total"}},
+		{"EXECUTE IMMEDIATE 'DROP TABLE t'", []string{"EXECUTE"}, nil},
+		{"SELECT 'DELETE' AS op /* UPDATE */ FROM t", []string{"SELECT"}, nil},
+	} {
+		t.Run("", func(t *testing.T) {
+			oq, err := NewObfuscator(Config{SQL: SQLConfig{
+				CollectOperations: true,
+				CollectProcedures: true,
+			}}).ObfuscateSQLString(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.operations, oq.Operations)
+			assert.Equal(t, tt.procedures, oq.Procedures)
+		})
+	}
+
+	t.Run("off", func(t *testing.T) {
+		oq, err := NewObfuscator(Config{}).ObfuscateSQLString("CALL sp_refresh(1)")
+		assert.NoError(t, err)
+		assert.Empty(t, oq.Operations)
+		assert.Empty(t, oq.Procedures)
+	})
+}
+
 func TestSQLQuantizer(t *testing.T) {
 	cases := []sqlTestCase{
 		{
@@ -143,8 +143,10 @@ type SQLTokenizer struct {
 
 	curlys uint32 // number of active open curly braces in top-level SQL escape sequences.
 
-	literalEscapes bool // indicates we should not treat backslashes as escape characters
-	seenEscape     bool // indicates whether this tokenizer has seen an escape character within a string
+	literalEscapes bool   // indicates we should not treat backslashes as escape characters
+	seenEscape     bool   // indicates whether this tokenizer has seen an escape character within a string
+	cql            bool   // indicates the query is a Cassandra CQL query (see cql.go)
+	dialect        string // the SQL dialect of the query (one of the DBMS constants), empty if generic
 
 	cfg *SQLConfig
 }
@@ -159,9 +161,19 @@ func NewSQLTokenizer(sql string, literalEscapes bool, cfg *SQLConfig) *SQLTokeni
 		buf:            []byte(sql),
 		cfg:            cfg,
 		literalEscapes: literalEscapes,
+		dialect:        sqlDialects[cfg.DBMS],
 	}
 }
 
+// sqlDialects maps the database management systems to the SQL dialect of their queries.
+var sqlDialects = map[string]string{
+	DBMSMySQL:     DBMSMySQL,
+	DBMSMariaDB:   DBMSMySQL,
+	DBMSPostgres:  DBMSPostgres,
+	DBMSSQLServer: DBMSSQLServer,
+	DBMSOracle:    DBMSOracle,
+}
+
 // Reset the underlying buffer and positions
 func (tkn *SQLTokenizer) Reset(in string) {
 	tkn.pos = 0
@@ -207,8 +219,19 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 	}
 	tkn.skipBlank()
 
+	if tkn.cql {
+		if kind, tok, ok := tkn.scanCQLLiteral(); ok {
+			return kind, tok
+		}
+	}
+
 	switch ch := tkn.lastChar; {
 	case isLeadingLetter(ch):
+		if tkn.dialect == DBMSOracle {
+			if kind, tok, ok := tkn.scanOracleQuotedString(); ok {
+				return kind, tok
+			}
+		}
 		return tkn.scanIdentifier()
 	case isDigit(ch):
 		return tkn.scanNumber(false)
@@ -245,7 +268,13 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			default:
 				return TokenKind(ch), tkn.bytes()
 			}
-		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', '[', ']', '?':
+		case '[':
+			if tkn.dialect == DBMSSQLServer {
+				// bracketed identifier, e.g. [order details]
+				return tkn.scanString(']', ID)
+			}
+			return TokenKind(ch), tkn.bytes()
+		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', ']', '?':
 			return TokenKind(ch), tkn.bytes()
 		case '.':
 			if isDigit(tkn.lastChar) {
@@ -276,6 +305,13 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 				return TokenKind(ch), tkn.bytes()
 			}
 		case '#':
+			if tkn.dialect == DBMSSQLServer {
+				// temporary table, e.g. #orders or ##orders
+				for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) {
+					tkn.advance()
+				}
+				return ID, tkn.bytes()
+			}
 			tkn.advance()
 			return tkn.scanCommentType1("#")
 		case '<':
@@ -325,7 +361,7 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 		case '\'':
 			return tkn.scanString(ch, String)
 		case '"':
-			return tkn.scanString(ch, DoubleQuotedString)
+			return tkn.scanString(ch, tkn.doubleQuotedKind())
 		case '`':
 			return tkn.scanString(ch, ID)
 		case '%':
@@ -382,6 +418,24 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 	}
 }
 
+// doubleQuotedKind returns the kind of the double-quoted tokens in the dialect of the query.
+func (tkn *SQLTokenizer) doubleQuotedKind() TokenKind {
+	switch tkn.dialect {
+	case DBMSMySQL:
+		return String
+	case DBMSPostgres, DBMSSQLServer, DBMSOracle:
+		return ID
+	default:
+		return DoubleQuotedString
+	}
+}
+
+// keepsLiterals reports whether the literals should be returned as they are written in the
+// query rather than unquoted, for the queries which are normalized without being obfuscated.
+func (tkn *SQLTokenizer) keepsLiterals() bool {
+	return tkn.cfg.ObfuscationMode == NormalizeOnly
+}
+
 func (tkn *SQLTokenizer) skipBlank() {
 	for unicode.IsSpace(tkn.lastChar) {
 		tkn.advance()
@@ -431,7 +485,8 @@ func toUpper(src, dst []byte) []byte {
 
 func (tkn *SQLTokenizer) scanIdentifier() (TokenKind, []byte) {
 	tkn.advance()
-	for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || tkn.lastChar == '.' || tkn.lastChar == '*' {
+	for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || tkn.lastChar == '.' || tkn.lastChar == '*' ||
+		tkn.lastChar == '$' && tkn.dialect != "" {
 		tkn.advance()
 	}
 
@@ -476,8 +531,8 @@ func (tkn *SQLTokenizer) scanDollarQuotedString() (TokenKind, []byte) {
 		buf bytes.Buffer
 	)
 	delim := tag
-	// on empty strings, tkn.scanString returns the delimiters
-	if string(delim) != "$$" {
+	// on empty strings and when keeping the literals, tkn.scanString returns the delimiters
+	if string(delim) != "$$" && !tkn.keepsLiterals() {
 		// on non-empty strings, the delimiter is $tag$
 		delim = append([]byte{'$'}, delim...)
 		delim = append(delim, '$')
@@ -509,6 +564,10 @@ func (tkn *SQLTokenizer) scanDollarQuotedString() (TokenKind, []byte) {
 	if tkn.cfg.DollarQuotedFunc && string(delim) == "$func$" {
 		return DollarQuotedFunc, buf.Bytes()
 	}
+	if tkn.keepsLiterals() {
+		// the opening delimiter was returned by tkn.scanString
+		return DollarQuotedString, append(append([]byte{}, tag...), tkn.bytes()...)
+	}
 	return DollarQuotedString, buf.Bytes()
 }
 
@@ -628,6 +687,8 @@ exit:
 }
 
 func (tkn *SQLTokenizer) scanString(delim rune, kind TokenKind) (TokenKind, []byte) {
+	// the unquoted string is written over the query as it is scanned, unless the literal is kept
+	raw := kind != ID && tkn.keepsLiterals()
 	buf := bytes.NewBuffer(tkn.buf[:0])
 	for {
 		ch := tkn.lastChar
@@ -653,7 +714,12 @@ func (tkn *SQLTokenizer) scanString(delim rune, kind TokenKind) (TokenKind, []by
 			tkn.setErr("unexpected EOF in string")
 			return LexError, buf.Bytes()
 		}
-		buf.WriteRune(ch)
+		if !raw {
+			buf.WriteRune(ch)
+		}
+	}
+	if raw {
+		return kind, tkn.bytes()
 	}
 	if kind == ID && buf.Len() == 0 || bytes.IndexFunc(buf.Bytes(), func(r rune) bool { return !unicode.IsSpace(r) }) == -1 {
 		// This string is an empty or white-space only identifier.
@@ -695,6 +761,39 @@ func (tkn *SQLTokenizer) scanCommentType2() (TokenKind, []byte) {
 	return Comment, tkn.bytes()
 }
 
+// scanOracleQuotedString scans an Oracle alternative quoting string literal, e.g. q'[it's]' or
+// nq'{...}'. It returns false if the next token is not such a literal.
+// See: https://docs.oracle.com/en/database/oracle/oracle-database/19/sqlrf/Literals.html#GUID-1824CBAA-6E16-4921-B2A6-112FB02248DA
+func (tkn *SQLTokenizer) scanOracleQuotedString() (TokenKind, []byte, bool) {
+	rest := tkn.unread()
+	i := 0
+	if len(rest) > 0 && (rest[0] == 'n' || rest[0] == 'N') {
+		i++
+	}
+	if len(rest) < i+3 || (rest[i] != 'q' && rest[i] != 'Q') || rest[i+1] != '\'' {
+		return 0, nil, false
+	}
+	closing := rest[i+2]
+	switch closing {
+	case '[':
+		closing = ']'
+	case '{':
+		closing = '}'
+	case '(':
+		closing = ')'
+	case '<':
+		closing = '>'
+	}
+	end := bytes.Index(rest[i+3:], []byte{closing, '\''})
+	if end == -1 {
+		tkn.advanceBytes(len(rest))
+		tkn.setErr("unexpected EOF in quoted string")
+		return LexError, tkn.bytes(), true
+	}
+	tkn.advanceBytes(i + 3 + end + 2)
+	return String, tkn.bytes(), true
+}
+
 // advance advances the tokenizer to the next rune. If the decoder encounters an error decoding, or
 // the end of the buffer is reached, tkn.lastChar will be set to EndChar. In case of a decoding
 // error, tkn.err will also be set.
@@ -732,6 +831,32 @@ func (tkn *SQLTokenizer) bytes() []byte {
 	return ret
 }
 
+// advanceBytes advances the tokenizer over the next n bytes of the unread query.
+func (tkn *SQLTokenizer) advanceBytes(n int) {
+	end := len(tkn.unread()) - n
+	for tkn.lastChar != EndChar && len(tkn.unread()) > end {
+		tkn.advance()
+	}
+}
+
+// unread returns the part of the query starting with tkn.lastChar.
+func (tkn *SQLTokenizer) unread() []byte {
+	if tkn.lastChar == EndChar {
+		return nil
+	}
+	return tkn.buf[tkn.off-utf8.RuneLen(tkn.lastChar):]
+}
+
+// isIdentifierAt reports whether the byte at offset n of the unread query is part of an identifier.
+func (tkn *SQLTokenizer) isIdentifierAt(n int) bool {
+	rest := tkn.unread()
+	if n >= len(rest) {
+		return false
+	}
+	r, _ := utf8.DecodeRune(rest[n:])
+	return isLetter(r) || isDigit(r)
+}
+
 func isLeadingLetter(ch rune) bool {
 	return unicode.IsLetter(ch) || ch == '_' || ch == '@'
 }
@@ -52,6 +52,14 @@ type StreamJSONMarshaler interface {
 	DescribeItem(i int) string
 }
 
+// PrometheusRemoteWriteMarshaler is an interface for metrics that are able to serialize themselves to
+// Prometheus remote-write payloads
+type PrometheusRemoteWriteMarshaler interface {
+	// MarshalPrometheusRemoteWrite serializes to snappy-compressed remote-write payloads of at most
+	// maxSamplesPerPayload samples each
+	MarshalPrometheusRemoteWrite(maxSamplesPerPayload int) ([]*[]byte, error)
+}
+
 // BufferContext contains the buffers used for MarshalSplitCompress so they can be shared between invocations
 type BufferContext struct {
 	CompressorInput   *bytes.Buffer
@@ -16,6 +16,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/endpoints"
 	"github.com/DataDog/datadog-agent/pkg/process/util/api/headers"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
@@ -27,6 +28,7 @@ import (
 const (
 	protobufContentType                         = "application/x-protobuf"
 	jsonContentType                             = "application/json"
+	prometheusRemoteWriteVersion                = "0.1.0"
 	payloadVersionHTTPHeader                    = "DD-Agent-Payload"
 	maxItemCountForCreateMarshalersBySourceType = 100
 )
@@ -40,6 +42,7 @@ var (
 	protobufExtraHeaders                http.Header
 	jsonExtraHeadersWithCompression     http.Header
 	protobufExtraHeadersWithCompression http.Header
+	prometheusRemoteWriteExtraHeaders   http.Header
 
 	expvars                                 = expvar.NewMap("serializer")
 	expvarsSendEventsErrItemTooBigs         = expvar.Int{}
@@ -72,12 +75,48 @@ func initExtraHeaders() {
 		protobufExtraHeadersWithCompression.Set(k, protobufExtraHeaders.Get(k))
 	}
 
+	prometheusRemoteWriteExtraHeaders = make(http.Header)
+	prometheusRemoteWriteExtraHeaders.Set("Content-Type", protobufContentType)
+	prometheusRemoteWriteExtraHeaders.Set("Content-Encoding", "snappy")
+	prometheusRemoteWriteExtraHeaders.Set("X-Prometheus-Remote-Write-Version", prometheusRemoteWriteVersion)
+
 	if compression.ContentEncoding != "" {
 		jsonExtraHeadersWithCompression.Set("Content-Encoding", compression.ContentEncoding)
 		protobufExtraHeadersWithCompression.Set("Content-Encoding", compression.ContentEncoding)
 	}
 }
 
+// streamCodec is the compression codec of the JSON stream payloads sent to an
+// endpoint, along with the HTTP headers matching it.
+type streamCodec struct {
+	codec        compression.Codec
+	extraHeaders http.Header
+}
+
+// newStreamCodec returns the codec configured for the given endpoint,
+// falling back to zlib when the configuration is invalid.
+func newStreamCodec(endpointName string) streamCodec {
+	kind := config.Datadog.GetString("serializer_compressor_kind")
+	if endpointKind := config.Datadog.GetStringMapString("serializer_compressor_kind_per_endpoint")[endpointName]; endpointKind != "" {
+		kind = endpointKind
+	}
+
+	codec, err := compression.NewCodec(kind, config.Datadog.GetInt("serializer_zstd_compressor_level"))
+	if err != nil {
+		log.Errorf("Invalid compression for the %s endpoint, using %s instead: %s", endpointName, compression.ZlibKind, err)
+		codec = compression.ZlibCodec
+	}
+
+	extraHeaders := make(http.Header)
+	for k := range jsonExtraHeaders {
+		extraHeaders.Set(k, jsonExtraHeaders.Get(k))
+	}
+	if contentEncoding := codec.ContentEncoding(); contentEncoding != "" {
+		extraHeaders.Set("Content-Encoding", contentEncoding)
+	}
+	return streamCodec{codec: codec, extraHeaders: extraHeaders}
+}
+
 // EventsStreamJSONMarshaler handles two serialization logics.
 type EventsStreamJSONMarshaler interface {
 	marshaler.Marshaler
@@ -108,6 +147,11 @@ type Serializer struct {
 
 	seriesJSONPayloadBuilder *stream.JSONPayloadBuilder
 
+	// Compression of the JSON stream payloads, per endpoint
+	seriesStreamCodec        streamCodec
+	serviceChecksStreamCodec streamCodec
+	eventsStreamCodec        streamCodec
+
 	// Those variables allow users to blacklist any kind of payload
 	// from being sent by the agent. This was introduced for
 	// environment where, for example, events or serviceChecks
@@ -123,23 +167,33 @@ type Serializer struct {
 	enableServiceChecksJSONStream bool
 	enableEventsJSONStream        bool
 	enableSketchProtobufStream    bool
+
+	// The series and sketches are also sent to a Prometheus remote-write
+	// endpoint when it is configured
+	enablePrometheusRemoteWrite     bool
+	prometheusRemoteWriteMaxSamples int
 }
 
 // NewSerializer returns a new Serializer initialized
 func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder forwarder.Forwarder) *Serializer {
 	s := &Serializer{
-		Forwarder:                     forwarder,
-		orchestratorForwarder:         orchestratorForwarder,
-		seriesJSONPayloadBuilder:      stream.NewJSONPayloadBuilder(config.Datadog.GetBool("enable_json_stream_shared_compressor_buffers")),
-		enableEvents:                  config.Datadog.GetBool("enable_payloads.events"),
-		enableSeries:                  config.Datadog.GetBool("enable_payloads.series"),
-		enableServiceChecks:           config.Datadog.GetBool("enable_payloads.service_checks"),
-		enableSketches:                config.Datadog.GetBool("enable_payloads.sketches"),
-		enableJSONToV1Intake:          config.Datadog.GetBool("enable_payloads.json_to_v1_intake"),
-		enableJSONStream:              stream.Available && config.Datadog.GetBool("enable_stream_payload_serialization"),
-		enableServiceChecksJSONStream: stream.Available && config.Datadog.GetBool("enable_service_checks_stream_payload_serialization"),
-		enableEventsJSONStream:        stream.Available && config.Datadog.GetBool("enable_events_stream_payload_serialization"),
-		enableSketchProtobufStream:    stream.Available && config.Datadog.GetBool("enable_sketch_stream_payload_serialization"),
+		Forwarder:                       forwarder,
+		orchestratorForwarder:           orchestratorForwarder,
+		seriesJSONPayloadBuilder:        stream.NewJSONPayloadBuilder(config.Datadog.GetBool("enable_json_stream_shared_compressor_buffers")),
+		seriesStreamCodec:               newStreamCodec(endpoints.V1SeriesEndpoint.Name),
+		serviceChecksStreamCodec:        newStreamCodec(endpoints.V1CheckRunsEndpoint.Name),
+		eventsStreamCodec:               newStreamCodec(endpoints.V1IntakeEndpoint.Name),
+		enableEvents:                    config.Datadog.GetBool("enable_payloads.events"),
+		enableSeries:                    config.Datadog.GetBool("enable_payloads.series"),
+		enableServiceChecks:             config.Datadog.GetBool("enable_payloads.service_checks"),
+		enableSketches:                  config.Datadog.GetBool("enable_payloads.sketches"),
+		enableJSONToV1Intake:            config.Datadog.GetBool("enable_payloads.json_to_v1_intake"),
+		enableJSONStream:                stream.Available && config.Datadog.GetBool("enable_stream_payload_serialization"),
+		enableServiceChecksJSONStream:   stream.Available && config.Datadog.GetBool("enable_service_checks_stream_payload_serialization"),
+		enableEventsJSONStream:          stream.Available && config.Datadog.GetBool("enable_events_stream_payload_serialization"),
+		enableSketchProtobufStream:      stream.Available && config.Datadog.GetBool("enable_sketch_stream_payload_serialization"),
+		enablePrometheusRemoteWrite:     config.Datadog.GetString("prometheus_remote_write.url") != "",
+		prometheusRemoteWriteMaxSamples: config.Datadog.GetInt("prometheus_remote_write.max_samples_per_payload"),
 	}
 
 	if !s.enableEvents {
@@ -200,9 +254,9 @@ func (s Serializer) serializePayloadInternal(payload marshaler.AbstractMarshaler
 	return payloads, extraHeaders, nil
 }
 
-func (s Serializer) serializeStreamablePayload(payload marshaler.StreamJSONMarshaler, policy stream.OnErrItemTooBigPolicy) (forwarder.Payloads, http.Header, error) {
-	payloads, err := s.seriesJSONPayloadBuilder.BuildWithOnErrItemTooBigPolicy(payload, policy)
-	return payloads, jsonExtraHeadersWithCompression, err
+func (s Serializer) serializeStreamablePayload(payload marshaler.StreamJSONMarshaler, policy stream.OnErrItemTooBigPolicy, codec streamCodec) (forwarder.Payloads, http.Header, error) {
+	payloads, err := s.seriesJSONPayloadBuilder.BuildWithCodec(payload, policy, codec.codec)
+	return payloads, codec.extraHeaders, err
 }
 
 // As events are gathered by SourceType, the serialization logic is more complex than for the other serializations.
@@ -217,7 +271,7 @@ func (s Serializer) serializeStreamablePayload(payload marshaler.StreamJSONMarsh
 func (s Serializer) serializeEventsStreamJSONMarshalerPayload(
 	eventsStreamJSONMarshaler EventsStreamJSONMarshaler, useV1API bool) (forwarder.Payloads, http.Header, error) {
 	marshaler := eventsStreamJSONMarshaler.CreateSingleMarshaler()
-	eventPayloads, extraHeaders, err := s.serializeStreamablePayload(marshaler, stream.FailOnErrItemTooBig)
+	eventPayloads, extraHeaders, err := s.serializeStreamablePayload(marshaler, stream.FailOnErrItemTooBig, s.eventsStreamCodec)
 
 	if err == stream.ErrItemTooBig {
 		expvarsSendEventsErrItemTooBigs.Add(1)
@@ -230,7 +284,7 @@ func (s Serializer) serializeEventsStreamJSONMarshalerPayload(
 			eventPayloads = nil
 			for _, v := range eventsStreamJSONMarshaler.CreateMarshalersBySourceType() {
 				var eventPayloadsForSourceType forwarder.Payloads
-				eventPayloadsForSourceType, extraHeaders, err = s.serializeStreamablePayload(v, stream.DropItemOnErrItemTooBig)
+				eventPayloadsForSourceType, extraHeaders, err = s.serializeStreamablePayload(v, stream.DropItemOnErrItemTooBig, s.eventsStreamCodec)
 				if err != nil {
 					return nil, nil, err
 				}
@@ -276,7 +330,7 @@ func (s *Serializer) SendServiceChecks(sc marshaler.StreamJSONMarshaler) error {
 	var err error
 
 	if s.enableServiceChecksJSONStream {
-		serviceCheckPayloads, extraHeaders, err = s.serializeStreamablePayload(sc, stream.DropItemOnErrItemTooBig)
+		serviceCheckPayloads, extraHeaders, err = s.serializeStreamablePayload(sc, stream.DropItemOnErrItemTooBig, s.serviceChecksStreamCodec)
 	} else {
 		serviceCheckPayloads, extraHeaders, err = s.serializePayloadJSON(sc, true)
 	}
@@ -294,6 +348,8 @@ func (s *Serializer) SendSeries(series marshaler.StreamJSONMarshaler) error {
 		return nil
 	}
 
+	s.sendPrometheusRemoteWrite(series)
+
 	useV1API := !config.Datadog.GetBool("use_v2_api.series")
 
 	var seriesPayloads forwarder.Payloads
@@ -301,7 +357,7 @@ func (s *Serializer) SendSeries(series marshaler.StreamJSONMarshaler) error {
 	var err error
 
 	if useV1API && s.enableJSONStream {
-		seriesPayloads, extraHeaders, err = s.serializeStreamablePayload(series, stream.DropItemOnErrItemTooBig)
+		seriesPayloads, extraHeaders, err = s.serializeStreamablePayload(series, stream.DropItemOnErrItemTooBig, s.seriesStreamCodec)
 	} else if useV1API && !s.enableJSONStream {
 		seriesPayloads, extraHeaders, err = s.serializePayloadJSON(series, true)
 	} else {
@@ -319,6 +375,32 @@ func (s *Serializer) SendSeries(series marshaler.StreamJSONMarshaler) error {
 	return s.Forwarder.SubmitSeries(seriesPayloads, extraHeaders)
 }
 
+// sendPrometheusRemoteWrite sends the series or sketches to the Prometheus
+// remote-write endpoint, when it is configured. The errors are logged so that
+// they do not prevent sending the payloads to Datadog.
+func (s *Serializer) sendPrometheusRemoteWrite(m interface{}) {
+	if !s.enablePrometheusRemoteWrite {
+		return
+	}
+	remoteWriteMarshaler, ok := m.(marshaler.PrometheusRemoteWriteMarshaler)
+	if !ok {
+		log.Debugf("%T cannot be sent to the Prometheus remote-write endpoint", m)
+		return
+	}
+
+	payloads, err := remoteWriteMarshaler.MarshalPrometheusRemoteWrite(s.prometheusRemoteWriteMaxSamples)
+	if err != nil {
+		log.Errorf("dropping Prometheus remote-write payload: %s", err)
+		return
+	}
+	if len(payloads) == 0 {
+		return
+	}
+	if err := s.Forwarder.SubmitPrometheusRemoteWrite(payloads, prometheusRemoteWriteExtraHeaders); err != nil {
+		log.Errorf("dropping Prometheus remote-write payload: %s", err)
+	}
+}
+
 // SendSketch serializes a list of SketSeriesList and sends the payload to the forwarder
 func (s *Serializer) SendSketch(sketches marshaler.Marshaler) error {
 	if !s.enableSketches {
@@ -326,6 +408,8 @@ func (s *Serializer) SendSketch(sketches marshaler.Marshaler) error {
 		return nil
 	}
 
+	s.sendPrometheusRemoteWrite(sketches)
+
 	if s.enableSketchProtobufStream {
 		payloads, err := sketches.MarshalSplitCompress(marshaler.DefaultBufferContext())
 		if err == nil {
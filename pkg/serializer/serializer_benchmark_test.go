@@ -16,6 +16,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
 	"github.com/DataDog/datadog-agent/pkg/serializer/stream"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 func buildSeries(numberOfSeries int) metrics.Series {
@@ -50,6 +51,22 @@ func benchmarkJSONStream(b *testing.B, passes int, sharedBuffers bool, numberOfS
 	}
 }
 
+func benchmarkJSONStreamCodec(b *testing.B, codec compression.Codec, numberOfSeries int) {
+	series := buildSeries(numberOfSeries)
+	payloadBuilder := stream.NewJSONPayloadBuilder(true)
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		results, _ = payloadBuilder.BuildWithCodec(series, stream.DropItemOnErrItemTooBig, codec)
+	}
+
+	size := 0
+	for _, payload := range results {
+		size += len(*payload)
+	}
+	b.ReportMetric(float64(size), "bytes/payloads")
+}
+
 func benchmarkSplit(b *testing.B, numberOfSeries int) {
 	series := buildSeries(numberOfSeries)
 	b.ResetTimer()
@@ -121,3 +138,29 @@ func BenchmarkSplit10000(b *testing.B)    { benchmarkSplit(b, 10000) }
 func BenchmarkSplit100000(b *testing.B)   { benchmarkSplit(b, 100000) }
 func BenchmarkSplit1000000(b *testing.B)  { benchmarkSplit(b, 1000000) }
 func BenchmarkSplit10000000(b *testing.B) { benchmarkSplit(b, 10000000) }
+
+// Compression codecs
+func BenchmarkJSONStreamZlib1000(b *testing.B) {
+	benchmarkJSONStreamCodec(b, compression.ZlibCodec, 1000)
+}
+func BenchmarkJSONStreamZlib100000(b *testing.B) {
+	benchmarkJSONStreamCodec(b, compression.ZlibCodec, 100000)
+}
+func BenchmarkJSONStreamZstd1Level1000(b *testing.B) {
+	benchmarkJSONStreamCodec(b, compression.NewZstdCodec(1), 1000)
+}
+func BenchmarkJSONStreamZstd1Level100000(b *testing.B) {
+	benchmarkJSONStreamCodec(b, compression.NewZstdCodec(1), 100000)
+}
+func BenchmarkJSONStreamZstd5Level1000(b *testing.B) {
+	benchmarkJSONStreamCodec(b, compression.NewZstdCodec(5), 1000)
+}
+func BenchmarkJSONStreamZstd5Level100000(b *testing.B) {
+	benchmarkJSONStreamCodec(b, compression.NewZstdCodec(5), 100000)
+}
+func BenchmarkJSONStreamNone1000(b *testing.B) {
+	benchmarkJSONStreamCodec(b, compression.NoneCodec, 1000)
+}
+func BenchmarkJSONStreamNone100000(b *testing.B) {
+	benchmarkJSONStreamCodec(b, compression.NoneCodec, 100000)
+}
@@ -310,6 +310,88 @@ func TestSendSeries(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+type testRemoteWritePayload struct {
+	testPayload
+}
+
+func (p *testRemoteWritePayload) MarshalPrometheusRemoteWrite(maxSamplesPerPayload int) ([]*[]byte, error) {
+	payload := []byte(fmt.Sprintf("remote write payload of %d samples", maxSamplesPerPayload))
+	return []*[]byte{&payload}, nil
+}
+
+func TestSendSeriesPrometheusRemoteWrite(t *testing.T) {
+	config.Datadog.Set("use_v2_api.series", true)
+	defer config.Datadog.Set("use_v2_api.series", false)
+	config.Datadog.Set("prometheus_remote_write.url", "http://localhost:9090/api/v1/write")
+	defer config.Datadog.Set("prometheus_remote_write.url", nil)
+	config.Datadog.Set("prometheus_remote_write.max_samples_per_payload", 10)
+	defer config.Datadog.Set("prometheus_remote_write.max_samples_per_payload", nil)
+
+	remoteWritePayload := []byte("remote write payload of 10 samples")
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitSeries", protobufPayloads, protobufExtraHeadersWithCompression).Return(nil).Times(2)
+	f.On("SubmitPrometheusRemoteWrite", forwarder.Payloads{&remoteWritePayload}, prometheusRemoteWriteExtraHeaders).Return(nil).Times(1)
+
+	s := NewSerializer(f, nil)
+	err := s.SendSeries(&testRemoteWritePayload{})
+	require.Nil(t, err)
+
+	// the payloads which cannot be encoded for Prometheus are only sent to Datadog
+	err = s.SendSeries(&testPayload{})
+	require.Nil(t, err)
+	f.AssertExpectations(t)
+
+	// the remote-write errors do not prevent sending the series to Datadog
+	f = &forwarder.MockedForwarder{}
+	f.On("SubmitSeries", protobufPayloads, protobufExtraHeadersWithCompression).Return(nil).Times(1)
+	f.On("SubmitPrometheusRemoteWrite", mock.Anything, mock.Anything).Return(fmt.Errorf("some error")).Times(1)
+
+	s = NewSerializer(f, nil)
+	err = s.SendSeries(&testRemoteWritePayload{})
+	require.Nil(t, err)
+	f.AssertExpectations(t)
+}
+
+func TestSendSeriesPrometheusRemoteWriteDisabled(t *testing.T) {
+	config.Datadog.Set("use_v2_api.series", true)
+	defer config.Datadog.Set("use_v2_api.series", false)
+
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitSeries", protobufPayloads, protobufExtraHeadersWithCompression).Return(nil).Times(1)
+
+	s := NewSerializer(f, nil)
+	err := s.SendSeries(&testRemoteWritePayload{})
+	require.Nil(t, err)
+	f.AssertExpectations(t)
+	f.AssertNotCalled(t, "SubmitPrometheusRemoteWrite", mock.Anything, mock.Anything)
+}
+
+func TestNewStreamCodec(t *testing.T) {
+	config.Datadog.Set("serializer_compressor_kind", compression.ZstdKind)
+	defer config.Datadog.Set("serializer_compressor_kind", nil)
+	config.Datadog.Set("serializer_compressor_kind_per_endpoint", map[string]string{
+		"check_run_v1": compression.NoneKind,
+		"intake":       "unknown",
+	})
+	defer config.Datadog.Set("serializer_compressor_kind_per_endpoint", nil)
+
+	s := NewSerializer(&forwarder.MockedForwarder{}, nil)
+
+	expected := make(http.Header)
+	expected.Set("Content-Type", jsonContentType)
+	expected.Set("Content-Encoding", "zstd")
+	assert.Equal(t, expected, s.seriesStreamCodec.extraHeaders)
+	assert.Equal(t, compression.ZstdKind, s.seriesStreamCodec.codec.ContentEncoding())
+
+	// no "Content-Encoding" header for uncompressed payloads
+	assert.Equal(t, jsonExtraHeaders, s.serviceChecksStreamCodec.extraHeaders)
+	assert.Equal(t, compression.NoneCodec, s.serviceChecksStreamCodec.codec)
+
+	// invalid kinds fall back to zlib
+	assert.Equal(t, compression.ZlibCodec, s.eventsStreamCodec.codec)
+	assert.Equal(t, "deflate", s.eventsStreamCodec.extraHeaders.Get("Content-Encoding"))
+}
+
 func TestSendSketch(t *testing.T) {
 	f := &forwarder.MockedForwarder{}
 	payloads, _ := mkPayloads(protobufString, true)
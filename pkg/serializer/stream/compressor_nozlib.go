@@ -11,6 +11,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 const (
@@ -34,6 +36,11 @@ func NewCompressor(input, output *bytes.Buffer, header, footer []byte, separator
 	return nil, fmt.Errorf("not implemented")
 }
 
+// NewCompressorWithCodec not implemented
+func NewCompressorWithCodec(input, output *bytes.Buffer, header, footer []byte, separator []byte, codec compression.Codec) (*Compressor, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 // AddItem not implemented
 func (c *Compressor) AddItem(data []byte) error {
 	return fmt.Errorf("not implemented")
@@ -13,6 +13,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	jsoniter "github.com/json-iterator/go"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 var (
@@ -183,3 +185,40 @@ func TestLockedCompressorProducesSamePayloads(t *testing.T) {
 
 	require.Equal(t, payloadToString(*payloads1[0]), payloadToString(*payloads2[0]))
 }
+
+func TestBuildWithCodec(t *testing.T) {
+	m := &dummyMarshaller{
+		header: "{[",
+		footer: "]}",
+	}
+	for i := 0; i < 100; i++ {
+		m.items = append(m.items, fmt.Sprintf("item_%02d", i))
+	}
+	config.Datadog.SetDefault("serializer_max_payload_size", 128)
+	defer resetDefaults()
+
+	// zlib is covered by the tests above
+	for _, kind := range []string{compression.ZstdKind, compression.NoneKind} {
+		t.Run(kind, func(t *testing.T) {
+			codec, err := compression.NewCodec(kind, 1)
+			require.NoError(t, err)
+
+			builder := NewJSONPayloadBuilder(true)
+			payloads, err := builder.BuildWithCodec(m, DropItemOnErrItemTooBig, codec)
+			require.NoError(t, err)
+			require.True(t, len(payloads) > 1)
+
+			var items []string
+			for _, payload := range payloads {
+				require.LessOrEqual(t, len(*payload), 128)
+
+				decompressed, err := codec.Decompress(nil, *payload)
+				require.NoError(t, err)
+				content := string(decompressed)
+				require.True(t, strings.HasPrefix(content, "{[") && strings.HasSuffix(content, "]}"), content)
+				items = append(items, strings.Split(content[2:len(content)-2], ",")...)
+			}
+			require.Equal(t, m.items, items)
+		})
+	}
+}
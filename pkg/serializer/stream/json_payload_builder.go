@@ -18,6 +18,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -105,6 +106,15 @@ func (b *JSONPayloadBuilder) Build(m marshaler.StreamJSONMarshaler) (forwarder.P
 func (b *JSONPayloadBuilder) BuildWithOnErrItemTooBigPolicy(
 	m marshaler.StreamJSONMarshaler,
 	policy OnErrItemTooBigPolicy) (forwarder.Payloads, error) {
+	return b.BuildWithCodec(m, policy, compression.ZlibCodec)
+}
+
+// BuildWithCodec serializes a metadata payload compressed with the given codec
+// and sends it to the forwarder
+func (b *JSONPayloadBuilder) BuildWithCodec(
+	m marshaler.StreamJSONMarshaler,
+	policy OnErrItemTooBigPolicy,
+	codec compression.Codec) (forwarder.Payloads, error) {
 
 	var input, output *bytes.Buffer
 	if b.shareAndLockBuffers {
@@ -151,7 +161,7 @@ func (b *JSONPayloadBuilder) BuildWithOnErrItemTooBigPolicy(
 		return nil, err
 	}
 
-	compressor, err := NewCompressor(input, output, header.Bytes(), footer.Bytes(), []byte(","))
+	compressor, err := NewCompressorWithCodec(input, output, header.Bytes(), footer.Bytes(), []byte(","), codec)
 	if err != nil {
 		return nil, err
 	}
@@ -181,7 +191,7 @@ func (b *JSONPayloadBuilder) BuildWithOnErrItemTooBigPolicy(
 			payloads = append(payloads, &payload)
 			input.Reset()
 			output.Reset()
-			compressor, err = NewCompressor(input, output, header.Bytes(), footer.Bytes(), []byte(","))
+			compressor, err = NewCompressorWithCodec(input, output, header.Bytes(), footer.Bytes(), []byte(","), codec)
 			if err != nil {
 				return nil, err
 			}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 // OnErrItemTooBigPolicy defines the behavior when OnErrItemTooBig occurs.
@@ -38,3 +39,8 @@ func NewJSONPayloadBuilder(shareAndLockBuffers bool) *JSONPayloadBuilder {
 func (b *JSONPayloadBuilder) BuildWithOnErrItemTooBigPolicy(marshaler.StreamJSONMarshaler, OnErrItemTooBigPolicy) (forwarder.Payloads, error) {
 	return nil, fmt.Errorf("not implemented")
 }
+
+// BuildWithCodec is not implemented when zlib is not available.
+func (b *JSONPayloadBuilder) BuildWithCodec(marshaler.StreamJSONMarshaler, OnErrItemTooBigPolicy, compression.Codec) (forwarder.Payloads, error) {
+	return nil, fmt.Errorf("not implemented")
+}
@@ -47,6 +47,8 @@ type Agent struct {
 	ErrorsSampler         *sampler.ErrorsSampler
 	RareSampler           *sampler.RareSampler
 	NoPrioritySampler     *sampler.NoPrioritySampler
+	RulesSampler          *sampler.RulesSampler // nil unless sampling rules are configured
+	TailSampler           *sampler.TailSampler  // nil unless the tail sampling is enabled
 	EventProcessor        *event.Processor
 	TraceWriter           *writer.TraceWriter
 	StatsWriter           *writer.StatsWriter
@@ -95,6 +97,16 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 		conf:                  conf,
 		ctx:                   ctx,
 	}
+	if ts := conf.TailSampling; ts != nil && ts.Enabled {
+		if conf.SynchronousFlushing {
+			log.Warn("Tail sampling is not supported with synchronous flushing, it is disabled.")
+		} else {
+			agnt.TailSampler = sampler.NewTailSampler(ts)
+		}
+	}
+	if len(conf.SamplingRules) > 0 {
+		agnt.RulesSampler = sampler.NewRulesSampler(conf.SamplingRules)
+	}
 	agnt.Receiver = api.NewHTTPReceiver(conf, dynConf, in, agnt)
 	agnt.OTLPReceiver = api.NewOTLPReceiver(in, conf.OTLPReceiver)
 	return agnt
@@ -114,6 +126,9 @@ func (a *Agent) Run() {
 	} {
 		starter.Start()
 	}
+	if a.TailSampler != nil {
+		a.TailSampler.Start()
+	}
 
 	go a.TraceWriter.Run()
 	go a.StatsWriter.Run()
@@ -164,6 +179,10 @@ func (a *Agent) loop() {
 			if err := a.Receiver.Stop(); err != nil {
 				log.Error(err)
 			}
+			if a.TailSampler != nil {
+				// flush the buffered traces while the trace writer is running
+				a.TailSampler.Stop()
+			}
 			for _, stopper := range []interface{ Stop() }{
 				a.Concentrator,
 				a.ClientStatsAggregator,
@@ -296,6 +315,22 @@ func (a *Agent) Process(p *api.Payload) {
 			})
 		}
 
+		if a.TailSampler != nil {
+			if sampled, ok := a.runHeadSamplers(ts, pt); ok {
+				// the events are extracted before the stats are computed on the spans
+				numEvents := a.extractEvents(ts, pt)
+				a.TailSampler.Add(&sampler.TailChunk{
+					Chunk:   chunk,
+					Sampled: sampled,
+					Size:    chunk.Msgsize(),
+					Flush:   a.tailSampledFlusher(p.TracerPayload, chunk, numEvents),
+				})
+			}
+			// the chunk is written once its trace is sampled
+			p.RemoveChunk(i)
+			continue
+		}
+
 		numEvents, keep := a.sample(ts, pt)
 		if !keep && numEvents == 0 {
 			// the trace was dropped and no analyzed span were kept
@@ -390,6 +425,18 @@ func (a *Agent) ProcessStats(in pb.ClientStatsPayload, lang, tracerVersion strin
 
 // sample reports the number of events found in pt and whether the chunk should be kept as a trace.
 func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (numEvents int64, keep bool) {
+	sampled, ok := a.runHeadSamplers(ts, pt)
+	if !ok {
+		return 0, false
+	}
+	pt.TraceChunk.DroppedTrace = !sampled
+	return a.extractEvents(ts, pt), sampled
+}
+
+// runHeadSamplers counts the sampling priority of pt and reports whether the samplers keep it.
+// The second value is false when the priority of pt rejects it. The sampling rules take
+// precedence over the other samplers, unless the priority of pt was set by the user.
+func (a *Agent) runHeadSamplers(ts *info.TagStats, pt ProcessedTrace) (sampled bool, ok bool) {
 	priority, hasPriority := sampler.GetSamplingPriority(pt.TraceChunk)
 
 	if hasPriority {
@@ -399,17 +446,59 @@ func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (numEvents int64, k
 	}
 
 	if priority < 0 {
-		return 0, false
+		return false, false
 	}
+	if a.RulesSampler != nil && priority != sampler.PriorityUserKeep {
+		if rule, sampled := a.RulesSampler.Sample(pt.Root); rule != "" {
+			ts.TracesPerSamplingRule.CountSamplingRule(rule, sampled)
+			return sampled, true
+		}
+	}
+	return a.runSamplers(pt, hasPriority), true
+}
 
-	sampled := a.runSamplers(pt, hasPriority)
-	pt.TraceChunk.DroppedTrace = !sampled
+// extractEvents reports the number of events found in pt. Only the events are left
+// in the chunk of pt when it is dropped.
+func (a *Agent) extractEvents(ts *info.TagStats, pt ProcessedTrace) int64 {
 	numEvents, numExtracted := a.EventProcessor.Process(pt.Root, pt.TraceChunk)
 
 	atomic.AddInt64(&ts.EventsExtracted, int64(numExtracted))
 	atomic.AddInt64(&ts.EventsSampled, numEvents)
 
-	return numEvents, sampled
+	return numEvents
+}
+
+// tailSampledFlusher returns the function writing chunk, received in payload, once its trace
+// is sampled by the tail sampler. Only the events are left in the chunk when it is dropped.
+func (a *Agent) tailSampledFlusher(payload *pb.TracerPayload, chunk *pb.TraceChunk, numEvents int64) func(keep bool) {
+	// the payload is cut and reused by Process, only its metadata is kept
+	tp := *payload
+	return func(keep bool) {
+		if !keep {
+			if numEvents == 0 {
+				return
+			}
+			events := make([]*pb.Span, 0, numEvents)
+			for _, span := range chunk.Spans {
+				if sampler.IsAnalyzedSpan(span) {
+					events = append(events, span)
+				}
+			}
+			chunk.Spans = events
+			chunk.DroppedTrace = true
+		}
+		chunkPayload := tp
+		chunkPayload.Chunks = []*pb.TraceChunk{chunk}
+		ss := &writer.SampledChunks{
+			TracerPayload: &chunkPayload,
+			Size:          chunk.Msgsize(),
+			EventCount:    numEvents,
+		}
+		if !chunk.DroppedTrace {
+			ss.SpanCount = int64(len(chunk.Spans))
+		}
+		a.TraceWriter.In <- ss
+	}
 }
 
 // runSamplers runs all the agent's samplers on pt and returns the sampling decision
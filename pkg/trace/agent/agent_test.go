@@ -1181,6 +1181,97 @@ func TestMergeDuplicates(t *testing.T) {
 	assert.Equal(t, expected, in)
 }
 
+func TestTailSampling(t *testing.T) {
+	assert := assert.New(t)
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.DisableRareSampler = true
+	cfg.TailSampling = &config.TailSamplingConfig{
+		Enabled: true,
+		Window:  50 * time.Millisecond,
+		Policies: []*config.TailSamplingPolicy{
+			{Name: "slow", Type: config.TailSamplingLatency, LatencyThresholdMs: 500},
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	agnt := NewAgent(ctx, cfg)
+	defer cancel()
+	agnt.TailSampler.Start()
+	defer agnt.TailSampler.Stop()
+
+	now := time.Now()
+	process := func(span *pb.Span) {
+		chunk := testutil.TraceChunkWithSpan(span)
+		chunk.Priority = int32(sampler.PriorityAutoDrop)
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(chunk),
+			Source:        info.NewReceiverStats().GetTagStats(info.Tags{}),
+		})
+	}
+	// the slow span of the second chunk keeps the first one dropped by the head samplers
+	process(&pb.Span{TraceID: 1, SpanID: 1, Service: "web", Name: "http.request", Resource: "GET /", Start: now.UnixNano(), Duration: int64(time.Millisecond)})
+	process(&pb.Span{TraceID: 1, SpanID: 2, ParentID: 1, Service: "db", Name: "query", Resource: "SELECT", Start: now.UnixNano(), Duration: int64(time.Second)})
+	process(&pb.Span{TraceID: 2, SpanID: 3, Service: "web", Name: "http.request", Resource: "GET /", Start: now.UnixNano(), Duration: int64(time.Millisecond)})
+
+	spanIDs := make(map[uint64]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case ss := <-agnt.TraceWriter.In:
+			assert.EqualValues(1, ss.SpanCount)
+			assert.Len(ss.TracerPayload.Chunks, 1)
+			assert.False(ss.TracerPayload.Chunks[0].DroppedTrace)
+			spanIDs[ss.TracerPayload.Chunks[0].Spans[0].SpanID] = true
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the tail sampled chunks")
+		}
+	}
+	assert.Equal(map[uint64]bool{1: true, 2: true}, spanIDs)
+
+	select {
+	case ss := <-agnt.TraceWriter.In:
+		t.Fatalf("unexpected chunk written: %v", ss.TracerPayload)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestSampleWithRules(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.SamplingRules = []*config.SamplingRule{
+		{Name: "health", ResourceRe: regexp.MustCompile("^GET /health$"), Decision: config.SamplingRuleDrop},
+		{Name: "server-errors", StatusCodes: []config.StatusCodeRange{{Min: 500, Max: 599}}, Decision: config.SamplingRuleKeep},
+	}
+	agnt := NewAgent(ctx, cfg)
+	defer cancel()
+
+	ts := info.NewReceiverStats().GetTagStats(info.Tags{})
+	sample := func(priority sampler.SamplingPriority, span *pb.Span) bool {
+		chunk := testutil.TraceChunkWithSpan(span)
+		chunk.Priority = int32(priority)
+		_, keep := agnt.sample(ts, ProcessedTrace{TraceChunk: chunk, Root: span})
+		return keep
+	}
+	health := func() *pb.Span { return &pb.Span{TraceID: 1, SpanID: 1, Service: "web", Resource: "GET /health"} }
+	serverError := func() *pb.Span {
+		return &pb.Span{TraceID: 2, SpanID: 2, Service: "web", Resource: "GET /", Meta: map[string]string{"http.status_code": "503"}}
+	}
+
+	// the rules take precedence over the priority set by the tracers
+	assert.False(sample(sampler.PriorityAutoKeep, health()))
+	assert.True(sample(sampler.PriorityAutoDrop, serverError()))
+	// but not over the priority set by the users
+	assert.True(sample(sampler.PriorityUserKeep, health()))
+	assert.False(sample(sampler.PriorityUserDrop, serverError()))
+	// the other samplers apply when no rule matches
+	assert.True(sample(sampler.PriorityAutoKeep, &pb.Span{TraceID: 3, SpanID: 3, Service: "web", Resource: "GET /"}))
+
+	kept, dropped := ts.TracesPerSamplingRule.TagValues()
+	assert.Equal(map[string]int64{"server-errors": 1}, kept)
+	assert.Equal(map[string]int64{"health": 1}, dropped)
+}
+
 func TestSampleWithPriorityNone(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cfg := config.New()
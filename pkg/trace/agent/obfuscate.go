@@ -20,7 +20,11 @@ const (
 	tagMemcachedCommand = "memcached.command"
 	tagMongoDBQuery     = "mongodb.query"
 	tagElasticBody      = "elasticsearch.body"
+	tagOpenSearchBody   = "opensearch.body"
+	tagDynamoDBQuery    = "dynamodb.query"
+	tagGraphQLQuery     = "graphql.query"
 	tagSQLQuery         = "sql.query"
+	tagDBSystem         = "db.system"
 	tagHTTPURL          = "http.url"
 )
 
@@ -35,7 +39,16 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 		if span.Resource == "" {
 			return
 		}
-		oq, err := o.ObfuscateSQLString(span.Resource)
+		var (
+			oq  *obfuscate.ObfuscatedQuery
+			err error
+		)
+		if span.Type == "cassandra" {
+			oq, err = o.ObfuscateCQLString(span.Resource)
+		} else {
+			// the dialect of the query depends on the database management system
+			oq, err = o.ObfuscateSQLStringForDBMS(span.Resource, span.Meta[tagDBSystem])
+		}
 		if err != nil {
 			// we have an error, discard the SQL to avoid polluting user resources.
 			log.Debugf("Error parsing SQL query: %v. Resource: %q", err, span.Resource)
@@ -54,6 +67,12 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 		if len(oq.TablesCSV) > 0 {
 			traceutil.SetMeta(span, "sql.tables", oq.TablesCSV)
 		}
+		if len(oq.Operations) > 0 {
+			traceutil.SetMeta(span, "sql.operations", strings.Join(oq.Operations, ","))
+		}
+		if len(oq.Procedures) > 0 {
+			traceutil.SetMeta(span, "sql.procedures", strings.Join(oq.Procedures, ","))
+		}
 		if span.Meta != nil && span.Meta[tagSQLQuery] != "" {
 			// "sql.query" tag already set by user, do not change it.
 			return
@@ -97,6 +116,26 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 			return
 		}
 		span.Meta[tagElasticBody] = o.ObfuscateElasticSearchString(v)
+	case "opensearch":
+		v, ok := span.Meta[tagOpenSearchBody]
+		if span.Meta == nil || !ok {
+			return
+		}
+		span.Meta[tagOpenSearchBody] = o.ObfuscateOpenSearchString(v)
+	case "dynamodb":
+		v, ok := span.Meta[tagDynamoDBQuery]
+		if span.Meta == nil || !ok {
+			return
+		}
+		span.Meta[tagDynamoDBQuery] = o.ObfuscateDynamoDBString(v)
+	case "graphql":
+		if !a.conf.Obfuscation.GraphQL.Enabled {
+			return
+		}
+		span.Resource = o.ObfuscateGraphQLString(span.Resource)
+		if v, ok := span.Meta[tagGraphQLQuery]; ok {
+			span.Meta[tagGraphQLQuery] = o.ObfuscateGraphQLString(v)
+		}
 	}
 }
 
@@ -104,7 +143,11 @@ func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 	o := a.obfuscator
 	switch b.Type {
 	case "sql", "cassandra":
-		oq, err := o.ObfuscateSQLString(b.Resource)
+		obfuscateSQL := o.ObfuscateSQLString
+		if b.Type == "cassandra" {
+			obfuscateSQL = o.ObfuscateCQLString
+		}
+		oq, err := obfuscateSQL(b.Resource)
 		if err != nil {
 			log.Errorf("Error obfuscating stats group resource %q: %v", b.Resource, err)
 			b.Resource = textNonParsable
@@ -113,6 +156,10 @@ func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 		}
 	case "redis":
 		b.Resource = o.QuantizeRedisString(b.Resource)
+	case "graphql":
+		if a.conf.Obfuscation.GraphQL.Enabled {
+			b.Resource = o.ObfuscateGraphQLString(b.Resource)
+		}
 	}
 }
 
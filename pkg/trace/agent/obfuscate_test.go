@@ -63,7 +63,9 @@ func TestObfuscateStatsGroup(t *testing.T) {
 	}{
 		{statsGroup("sql", "SELECT 1 FROM db"), "SELECT ? FROM db"},
 		{statsGroup("sql", "SELECT 1\nFROM Blogs AS [b\nORDER BY [b]"), textNonParsable},
+		{statsGroup("cassandra", "SELECT * FROM users WHERE id = 123e4567-e89b-12d3-a456-426655440000"), "SELECT * FROM users WHERE id = ?"},
 		{statsGroup("redis", "ADD 1, 2"), "ADD"},
+		{statsGroup("graphql", `{ user(id: 1) { name } }`), `{ user(id: 1) { name } }`},
 		{statsGroup("other", "ADD 1, 2"), "ADD 1, 2"},
 	} {
 		agnt, stop := agentWithDefaults()
@@ -103,6 +105,19 @@ func TestObfuscateDefaults(t *testing.T) {
 		assert.Equal(t, query, span.Meta["sql.query"])
 		assert.Equal(t, "UPDATE users ( name ) SET ( ? )", span.Resource)
 	})
+
+	t.Run("cassandra", func(t *testing.T) {
+		query := "SELECT * FROM users WHERE id = 123e4567-e89b-12d3-a456-426655440000"
+		span := &pb.Span{
+			Type:     "cassandra",
+			Resource: query,
+		}
+		agnt, stop := agentWithDefaults()
+		defer stop()
+		agnt.obfuscateSpan(span)
+		assert.Equal(t, "SELECT * FROM users WHERE id = ?", span.Meta["sql.query"])
+		assert.Equal(t, "SELECT * FROM users WHERE id = ?", span.Resource)
+	})
 }
 
 func agentWithDefaults() (agnt *Agent, stop func()) {
@@ -205,6 +220,58 @@ func TestObfuscateConfig(t *testing.T) {
 		&config.ObfuscationConfig{},
 	))
 
+	t.Run("opensearch/enabled", testConfig(
+		"opensearch",
+		"opensearch.body",
+		`{"role": "database"}`,
+		`{"role":"?"}`,
+		&config.ObfuscationConfig{
+			OpenSearch: config.JSONObfuscationConfig{Enabled: true},
+		},
+	))
+
+	t.Run("opensearch/disabled", testConfig(
+		"opensearch",
+		"opensearch.body",
+		`{"role": "database"}`,
+		`{"role": "database"}`,
+		&config.ObfuscationConfig{},
+	))
+
+	t.Run("dynamodb/enabled", testConfig(
+		"dynamodb",
+		"dynamodb.query",
+		`{"TableName": "users", "Key": {"id": {"S": "42"}}}`,
+		`{"TableName":"users","Key":{"id":{"S":"?"}}}`,
+		&config.ObfuscationConfig{
+			DynamoDB: config.JSONObfuscationConfig{Enabled: true},
+		},
+	))
+
+	t.Run("dynamodb/disabled", testConfig(
+		"dynamodb",
+		"dynamodb.query",
+		`{"TableName": "users", "Key": {"id": {"S": "42"}}}`,
+		`{"TableName": "users", "Key": {"id": {"S": "42"}}}`,
+		&config.ObfuscationConfig{},
+	))
+
+	t.Run("graphql/enabled", testConfig(
+		"graphql",
+		"graphql.query",
+		`query GetUser { user(id: "42") { name } }`,
+		`query GetUser { user(id: ?) { name } }`,
+		&config.ObfuscationConfig{GraphQL: config.Enablable{Enabled: true}},
+	))
+
+	t.Run("graphql/disabled", testConfig(
+		"graphql",
+		"graphql.query",
+		`query GetUser { user(id: "42") { name } }`,
+		`query GetUser { user(id: "42") { name } }`,
+		&config.ObfuscationConfig{},
+	))
+
 	t.Run("memcached/enabled", testConfig(
 		"memcached",
 		"memcached.command",
@@ -331,3 +398,46 @@ func TestSQLTableNames(t *testing.T) {
 		assert.Empty(t, span.Meta["sql.tables"])
 	})
 }
+
+func TestSQLDBSystem(t *testing.T) {
+	span := &pb.Span{
+		Resource: `SELECT "name" FROM users WHERE name = "admin"`,
+		Type:     "sql",
+		Meta:     map[string]string{"db.system": "postgresql"},
+	}
+	agnt, stop := agentWithDefaults()
+	defer stop()
+	agnt.obfuscateSpan(span)
+	assert.Equal(t, "SELECT name FROM users WHERE name = admin", span.Resource)
+
+	span = &pb.Span{
+		Resource: `SELECT "name" FROM users WHERE name = "admin"`,
+		Type:     "sql",
+		Meta:     map[string]string{"db.system": "mysql"},
+	}
+	agnt.obfuscateSpan(span)
+	assert.Equal(t, "SELECT ? FROM users WHERE name = ?", span.Resource)
+}
+
+func TestSQLObfuscationConfig(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.Obfuscation = &config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{
+		ObfuscationMode:   "normalize_only",
+		KeepComments:      true,
+		CollectOperations: true,
+		CollectProcedures: true,
+	}}
+	agnt := NewAgent(ctx, cfg)
+
+	span := &pb.Span{
+		Resource: "/* app='shop' */ EXEC dbo.refresh_orders 42; SELECT id  FROM orders -- recent\nWHERE id > 42",
+		Type:     "sql",
+	}
+	agnt.obfuscateSpan(span)
+	assert.Equal(t, "/* app='shop' */ EXEC dbo.refresh_orders 42 SELECT id FROM orders WHERE id > 42", span.Resource)
+	assert.Equal(t, "EXEC,SELECT", span.Meta["sql.operations"])
+	assert.Equal(t, "dbo.refresh_orders", span.Meta["sql.procedures"])
+}
@@ -586,6 +586,10 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 			runMetaHook(tp.Chunks)
 		}
 	}
+	if v == v04 || v == v05 {
+		// join the traces propagated by the W3C or B3 formats to the ones received through OTLP
+		applyPropagatedTraceIDs(tp.Chunks)
+	}
 	if n, ok := r.replyOK(v, w); ok {
 		tags := append(ts.AsTags(), "endpoint:traces_"+string(v))
 		metrics.Histogram("datadog.trace_agent.receiver.rate_response_bytes", float64(n), tags, 1)
@@ -220,11 +220,12 @@ func (o *OTLPReceiver) processRequest(protocol string, header http.Header, in *o
 				EndpointVersion: fmt.Sprintf("opentelemetry_%s_v1", protocol),
 			},
 		}
-		tracesByID := make(map[uint64]pb.Trace)
+		// the traces are grouped by their full 128-bit IDs
+		tracesByID := make(map[string]pb.Trace)
 		for _, libspans := range rspans.InstrumentationLibrarySpans {
 			lib := libspans.InstrumentationLibrary
 			for _, span := range libspans.Spans {
-				traceID := byteArrayToUint64(span.TraceId)
+				traceID := string(span.TraceId)
 				if tracesByID[traceID] == nil {
 					tracesByID[traceID] = pb.Trace{}
 				}
@@ -339,6 +340,9 @@ func convertSpan(rattr map[string]string, lib *otlppb.InstrumentationLibrary, in
 		Metrics:  map[string]float64{},
 	}
 	span.Meta["otel.trace_id"] = hex.EncodeToString(in.TraceId)
+	if high := traceIDHighBits(in.TraceId); high != 0 {
+		span.Meta[tagTraceIDHigh] = traceIDHigh(high)
+	}
 	if _, ok := span.Meta["version"]; !ok {
 		if ver := rattr[string(semconv.AttributeServiceVersion)]; ver != "" {
 			span.Meta["version"] = ver
@@ -363,7 +367,7 @@ func convertSpan(rattr map[string]string, lib *otlppb.InstrumentationLibrary, in
 		}
 	}
 	if in.TraceState != "" {
-		span.Meta["trace_state"] = in.TraceState
+		span.Meta[tagTraceState] = in.TraceState
 	}
 	if lib.Name != "" {
 		span.Meta["instrumentation_library.name"] = lib.Name
@@ -457,6 +461,15 @@ func byteArrayToUint64(b []byte) uint64 {
 	return binary.BigEndian.Uint64(b[len(b)-8:])
 }
 
+// traceIDHighBits returns the upper 64 bits of the 128-bit trace ID b, which byteArrayToUint64
+// truncates.
+func traceIDHighBits(b []byte) uint64 {
+	if len(b) < 16 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b[len(b)-16 : len(b)-8])
+}
+
 // anyValueString converts otlppb.AnyValue a to its string representation.
 func anyValueString(a *otlppb.AnyValue) string {
 	switch v := a.Value.(type) {
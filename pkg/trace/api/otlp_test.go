@@ -186,6 +186,34 @@ func TestOTLPReceiver(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("processRequest/128-bit", func(t *testing.T) {
+		out := make(chan *Payload, 1)
+		o := NewOTLPReceiver(out, nil)
+		span1, span2 := makeOTLPTestSpan(1), makeOTLPTestSpan(2)
+		// the trace IDs differ by their upper 64 bits only
+		span2.TraceId = append([]byte{0x73}, otlpTestID128[1:]...)
+		o.processRequest(otlpProtocolHTTP, http.Header{}, &otlppb.ExportTraceServiceRequest{
+			ResourceSpans: []*otlppb.ResourceSpans{{
+				Resource: &otlppb.Resource{},
+				InstrumentationLibrarySpans: []*otlppb.InstrumentationLibrarySpans{{
+					InstrumentationLibrary: &otlppb.InstrumentationLibrary{},
+					Spans:                  []*otlppb.Span{span1, span2},
+				}},
+			}},
+		})
+		p := <-out
+		assert.Len(t, p.TracerPayload.Chunks, 2)
+		tids := make(map[string]uint64)
+		for _, c := range p.TracerPayload.Chunks {
+			assert.Len(t, c.Spans, 1)
+			tids[c.Spans[0].Meta["_dd.p.tid"]] = c.Spans[0].TraceID
+		}
+		assert.Equal(t, map[string]uint64{
+			"72df520af2bde7a5": 2594128270069917171,
+			"73df520af2bde7a5": 2594128270069917171,
+		}, tids)
+	})
 }
 
 func TestOTLPHelpers(t *testing.T) {
@@ -475,6 +503,7 @@ func TestOTLPConvertSpan(t *testing.T) {
 				Meta: map[string]string{
 					"name":                            "john",
 					"otel.trace_id":                   "72df520af2bde7a5240031ead750e5f3",
+					"_dd.p.tid":                       "72df520af2bde7a5",
 					"env":                             "staging",
 					"instrumentation_library.name":    "ddtracer",
 					"instrumentation_library.version": "v2",
@@ -565,6 +594,7 @@ func TestOTLPConvertSpan(t *testing.T) {
 					"deployment.environment":          "prod",
 					"instrumentation_library.name":    "ddtracer",
 					"otel.trace_id":                   "72df520af2bde7a5240031ead750e5f3",
+					"_dd.p.tid":                       "72df520af2bde7a5",
 					"instrumentation_library.version": "v2",
 					"service.version":                 "v1.2.3",
 					"trace_state":                     "state",
@@ -659,6 +689,7 @@ func TestOTLPConvertSpan(t *testing.T) {
 					"trace_state":                     "state",
 					"version":                         "v1.2.3",
 					"otel.trace_id":                   "72df520af2bde7a5240031ead750e5f3",
+					"_dd.p.tid":                       "72df520af2bde7a5",
 					"events":                          "[{\"time_unix_nano\":123,\"name\":\"boom\",\"attributes\":{\"message\":\"Out of memory\",\"accuracy\":\"2.40\"},\"dropped_attributes_count\":2},{\"time_unix_nano\":456,\"name\":\"exception\",\"attributes\":{\"exception.message\":\"Out of memory\",\"exception.type\":\"mem\",\"exception.stacktrace\":\"1/2/3\"},\"dropped_attributes_count\":2}]",
 					"error.msg":                       "Out of memory",
 					"error.type":                      "mem",
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+const (
+	// tagTraceIDHigh holds the upper 64 bits of a 128-bit trace ID, as 16 lower-case hex digits.
+	// The lower 64 bits are the Datadog trace ID.
+	tagTraceIDHigh = "_dd.p.tid"
+	// tagTraceState holds the W3C tracestate of a span.
+	tagTraceState = "trace_state"
+
+	// tagW3CTraceParent and tagW3CTraceState hold the W3C Trace Context a span was extracted from.
+	// See https://www.w3.org/TR/trace-context/
+	tagW3CTraceParent = "traceparent"
+	tagW3CTraceState  = "tracestate"
+	// tagB3 and tagB3TraceID hold the B3 context a span was extracted from, in the single header
+	// or the multiple headers format.
+	// See https://github.com/openzipkin/b3-propagation
+	tagB3        = "b3"
+	tagB3TraceID = "x-b3-traceid"
+)
+
+// traceIDHigh returns the tagTraceIDHigh value of the upper 64 bits of a trace ID.
+func traceIDHigh(high uint64) string {
+	return fmt.Sprintf("%016x", high)
+}
+
+// parseTraceID128 parses a 128-bit or 64-bit hex encoded trace ID. It returns false if the ID
+// is invalid or zero.
+func parseTraceID128(s string) (high, low uint64, ok bool) {
+	if len(s) != 32 && len(s) != 16 {
+		return 0, 0, false
+	}
+	var b [16]byte
+	if _, err := hex.Decode(b[16-len(s)/2:], []byte(s)); err != nil {
+		return 0, 0, false
+	}
+	high, low = binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	return high, low, high != 0 || low != 0
+}
+
+// propagatedTraceID returns the 128-bit trace ID which a span was extracted from, carried in its
+// W3C or B3 tags. It returns false if the span has none.
+func propagatedTraceID(span *pb.Span) (high, low uint64, ok bool) {
+	if v, found := span.Meta[tagW3CTraceParent]; found {
+		// version-traceid-parentid-flags
+		if parts := strings.Split(v, "-"); len(parts) >= 4 && len(parts[1]) == 32 {
+			return parseTraceID128(parts[1])
+		}
+	}
+	if v, found := span.Meta[tagB3]; found {
+		// traceid-spanid[-sampled[-parentspanid]]
+		if i := strings.IndexByte(v, '-'); i > 0 {
+			return parseTraceID128(v[:i])
+		}
+	}
+	if v, found := span.Meta[tagB3TraceID]; found {
+		return parseTraceID128(v)
+	}
+	return 0, 0, false
+}
+
+// applyPropagatedTraceIDs joins the chunks originating from W3C or B3 contexts to their traces: when
+// a span of a chunk carries the propagated trace ID in its tags, the spans of the chunk take its lower
+// 64 bits as trace ID and its upper 64 bits in the tagTraceIDHigh tag, as the spans received through
+// OTLP do. The W3C tracestate is kept in the tagTraceState tag.
+func applyPropagatedTraceIDs(chunks []*pb.TraceChunk) {
+	for _, chunk := range chunks {
+		var (
+			high, low uint64
+			ok        bool
+		)
+		for _, span := range chunk.Spans {
+			if span.Meta == nil {
+				continue
+			}
+			if ts, found := span.Meta[tagW3CTraceState]; found {
+				if _, found := span.Meta[tagTraceState]; !found {
+					span.Meta[tagTraceState] = ts
+				}
+			}
+			if !ok {
+				high, low, ok = propagatedTraceID(span)
+			}
+		}
+		if !ok {
+			continue
+		}
+		for _, span := range chunk.Spans {
+			span.TraceID = low
+			if high == 0 {
+				continue
+			}
+			if _, found := span.Meta[tagTraceIDHigh]; !found {
+				if span.Meta == nil {
+					span.Meta = make(map[string]string, 1)
+				}
+				span.Meta[tagTraceIDHigh] = traceIDHigh(high)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceID128(t *testing.T) {
+	for _, tt := range []struct {
+		in        string
+		high, low uint64
+		ok        bool
+	}{
+		{"72df520af2bde7a5240031ead750e5f3", 0x72df520af2bde7a5, 0x240031ead750e5f3, true},
+		{"240031ead750e5f3", 0, 0x240031ead750e5f3, true},
+		{"00000000000000000000000000000000", 0, 0, false},
+		{"72df520af2bde7a5240031ead750e5f", 0, 0, false},
+		{"72df520af2bde7a5240031ead750e5fz", 0, 0, false},
+		{"", 0, 0, false},
+	} {
+		high, low, ok := parseTraceID128(tt.in)
+		assert.Equal(t, tt.ok, ok, tt.in)
+		assert.Equal(t, tt.high, high, tt.in)
+		assert.Equal(t, tt.low, low, tt.in)
+	}
+}
+
+func TestApplyPropagatedTraceIDs(t *testing.T) {
+	chunk := func(spans ...*pb.Span) *pb.TraceChunk { return &pb.TraceChunk{Spans: spans} }
+
+	t.Run("w3c", func(t *testing.T) {
+		root := &pb.Span{TraceID: 1, SpanID: 10, Meta: map[string]string{
+			"traceparent": "00-72df520af2bde7a5240031ead750e5f3-00f067aa0ba902b7-01",
+			"tracestate":  "dd=s:1,congo=t61rcWkgMzE",
+		}}
+		child := &pb.Span{TraceID: 1, SpanID: 11, ParentID: 10}
+		applyPropagatedTraceIDs([]*pb.TraceChunk{chunk(child, root)})
+		for _, s := range []*pb.Span{root, child} {
+			assert.EqualValues(t, 0x240031ead750e5f3, s.TraceID)
+			assert.Equal(t, "72df520af2bde7a5", s.Meta["_dd.p.tid"])
+		}
+		assert.Equal(t, "dd=s:1,congo=t61rcWkgMzE", root.Meta["trace_state"])
+	})
+
+	t.Run("b3", func(t *testing.T) {
+		single := &pb.Span{TraceID: 1, Meta: map[string]string{"b3": "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1"}}
+		multi := &pb.Span{TraceID: 2, Meta: map[string]string{"x-b3-traceid": "a3ce929d0e0e4736"}}
+		applyPropagatedTraceIDs([]*pb.TraceChunk{chunk(single), chunk(multi)})
+		assert.EqualValues(t, 0x64fe8b2a57d3eff7, single.TraceID)
+		assert.Equal(t, "80f198ee56343ba8", single.Meta["_dd.p.tid"])
+		assert.Equal(t, uint64(0xa3ce929d0e0e4736), multi.TraceID)
+		assert.NotContains(t, multi.Meta, "_dd.p.tid")
+	})
+
+	t.Run("none", func(t *testing.T) {
+		span := &pb.Span{TraceID: 1, Meta: map[string]string{"traceparent": "invalid", "_dd.p.tid": "0000000000000001"}}
+		applyPropagatedTraceIDs([]*pb.TraceChunk{chunk(span), chunk(&pb.Span{TraceID: 2})})
+		assert.EqualValues(t, 1, span.TraceID)
+		assert.Equal(t, "0000000000000001", span.Meta["_dd.p.tid"])
+	})
+}
@@ -49,12 +49,21 @@ type OTLP struct {
 // ObfuscationConfig holds the configuration for obfuscating sensitive data
 // for various span types.
 type ObfuscationConfig struct {
+	// SQL holds the obfuscation configuration for the SQL queries of the spans of type "sql".
+	SQL SQLObfuscationConfig `mapstructure:"sql"`
+
 	// ES holds the obfuscation configuration for ElasticSearch bodies.
 	ES JSONObfuscationConfig `mapstructure:"elasticsearch"`
 
 	// Mongo holds the obfuscation configuration for MongoDB queries.
 	Mongo JSONObfuscationConfig `mapstructure:"mongodb"`
 
+	// OpenSearch holds the obfuscation configuration for OpenSearch bodies.
+	OpenSearch JSONObfuscationConfig `mapstructure:"opensearch"`
+
+	// DynamoDB holds the obfuscation configuration for DynamoDB requests.
+	DynamoDB JSONObfuscationConfig `mapstructure:"dynamodb"`
+
 	// SQLExecPlan holds the obfuscation configuration for SQL Exec Plans. This is strictly for safety related obfuscation,
 	// not normalization. Normalization of exec plans is configured in SQLExecPlanNormalize.
 	SQLExecPlan JSONObfuscationConfig `mapstructure:"sql_exec_plan"`
@@ -77,6 +86,10 @@ type ObfuscationConfig struct {
 	// for spans of type "memcached".
 	Memcached Enablable `mapstructure:"memcached"`
 
+	// GraphQL holds the configuration for obfuscating the "graphql.query" tag
+	// and the resource for spans of type "graphql".
+	GraphQL Enablable `mapstructure:"graphql"`
+
 	// CreditCards holds the configuration for obfuscating credit cards.
 	CreditCards CreditCardsConfig `mapstructure:"credit_cards"`
 }
@@ -90,6 +103,11 @@ func (o *ObfuscationConfig) Export() obfuscate.Config {
 			KeepSQLAlias:     features.Has("keep_sql_alias"),
 			DollarQuotedFunc: features.Has("dollar_quoted_func"),
 			Cache:            features.Has("sql_cache"),
+
+			ObfuscationMode:   obfuscate.ObfuscationMode(o.SQL.ObfuscationMode),
+			KeepComments:      o.SQL.KeepComments,
+			CollectOperations: o.SQL.CollectOperations,
+			CollectProcedures: o.SQL.CollectProcedures,
 		},
 		ES: obfuscate.JSONConfig{
 			Enabled:            o.ES.Enabled,
@@ -101,6 +119,16 @@ func (o *ObfuscationConfig) Export() obfuscate.Config {
 			KeepValues:         o.Mongo.KeepValues,
 			ObfuscateSQLValues: o.Mongo.ObfuscateSQLValues,
 		},
+		OpenSearch: obfuscate.JSONConfig{
+			Enabled:            o.OpenSearch.Enabled,
+			KeepValues:         o.OpenSearch.KeepValues,
+			ObfuscateSQLValues: o.OpenSearch.ObfuscateSQLValues,
+		},
+		DynamoDB: obfuscate.JSONConfig{
+			Enabled:            o.DynamoDB.Enabled,
+			KeepValues:         o.DynamoDB.KeepValues,
+			ObfuscateSQLValues: o.DynamoDB.ObfuscateSQLValues,
+		},
 		SQLExecPlan: obfuscate.JSONConfig{
 			Enabled:            o.SQLExecPlan.Enabled,
 			KeepValues:         o.SQLExecPlan.KeepValues,
@@ -137,6 +165,25 @@ type CreditCardsConfig struct {
 	Luhn bool `mapstructure:"luhn"`
 }
 
+// SQLObfuscationConfig holds the configuration settings for SQL obfuscation.
+type SQLObfuscationConfig struct {
+	// ObfuscationMode specifies how the queries are obfuscated. When set to "normalize_only",
+	// the queries are normalized without replacing their literals. By default, the literals
+	// are replaced too.
+	ObfuscationMode string `mapstructure:"obfuscation_mode"`
+
+	// KeepComments specifies whether the block comments of the queries should be kept.
+	KeepComments bool `mapstructure:"keep_comments"`
+
+	// CollectOperations specifies whether the operations that the queries execute (e.g. SELECT)
+	// should be extracted into the "sql.operations" tag.
+	CollectOperations bool `mapstructure:"collect_operations"`
+
+	// CollectProcedures specifies whether the stored procedures that the queries call should be
+	// extracted into the "sql.procedures" tag.
+	CollectProcedures bool `mapstructure:"collect_procedures"`
+}
+
 // HTTPObfuscationConfig holds the configuration settings for HTTP obfuscation.
 type HTTPObfuscationConfig struct {
 	// RemoveQueryStrings determines query strings to be removed from HTTP URLs.
@@ -184,6 +231,122 @@ type ReplaceRule struct {
 	Repl string `mapstructure:"repl"`
 }
 
+// TailSamplingConfig holds the configuration of the tail sampling, buffering the
+// trace chunks by trace ID in order to sample the complete local traces.
+type TailSamplingConfig struct {
+	// Enabled reports whether the trace chunks are buffered for tail sampling.
+	Enabled bool
+
+	// Window is the duration the chunks of a trace are buffered for, from the
+	// reception of its first chunk, before the trace is sampled.
+	Window time.Duration
+
+	// MaxBufferedBytes bounds the approximate size of the buffered chunks. When it is
+	// reached, the oldest traces are sampled before the end of their window.
+	MaxBufferedBytes int
+
+	// Policies keep the traces matching one of them, in addition to the traces
+	// having a chunk kept by the other samplers.
+	Policies []*TailSamplingPolicy
+}
+
+// Tail sampling policy types.
+const (
+	// TailSamplingLatency keeps the traces lasting at least LatencyThresholdMs.
+	TailSamplingLatency = "latency"
+	// TailSamplingError keeps the traces having an erroneous span.
+	TailSamplingError = "error"
+	// TailSamplingTag keeps the traces having a span with the Tag tag matching Pattern.
+	TailSamplingTag = "tag"
+	// TailSamplingResource keeps the traces having a span with a resource matching Pattern.
+	TailSamplingResource = "resource"
+	// TailSamplingRate keeps a Rate ratio of the traces.
+	TailSamplingRate = "rate"
+)
+
+// TailSamplingPolicy specifies the traces kept by the tail sampling.
+type TailSamplingPolicy struct {
+	// Name identifies the policy in the telemetry.
+	Name string `mapstructure:"name"`
+
+	// Type is the type of the policy: latency, error, tag, resource or rate.
+	Type string `mapstructure:"type"`
+
+	// Service restricts the policy to the traces having a span of this service.
+	Service string `mapstructure:"service"`
+
+	// LatencyThresholdMs is the minimum duration of the traces kept by a latency policy.
+	LatencyThresholdMs float64 `mapstructure:"latency_threshold_ms"`
+
+	// Tag is the tag matched by a tag policy.
+	Tag string `mapstructure:"tag"`
+
+	// Pattern is the regexp pattern matching the tag value or the resource. An empty
+	// pattern matches any value.
+	Pattern string `mapstructure:"pattern"`
+
+	// Re holds the compiled Pattern and is only used internally.
+	Re *regexp.Regexp `mapstructure:"-"`
+
+	// Rate is the ratio of the traces kept by a rate policy, between 0 and 1.
+	Rate float64 `mapstructure:"rate"`
+}
+
+// Sampling rule decisions.
+const (
+	// SamplingRuleKeep keeps all the traces matching a sampling rule.
+	SamplingRuleKeep = "keep"
+	// SamplingRuleDrop drops all the traces matching a sampling rule.
+	SamplingRuleDrop = "drop"
+)
+
+// SamplingRule specifies the sampling of the traces whose root span it matches. All the
+// properties of a rule must match the root span; the properties left empty match any span.
+type SamplingRule struct {
+	// Name identifies the rule in the telemetry.
+	Name string `mapstructure:"name"`
+
+	// Service, Operation and Resource are regexp patterns matching the service,
+	// the operation name and the resource of the root span.
+	Service   string `mapstructure:"service"`
+	Operation string `mapstructure:"operation_name"`
+	Resource  string `mapstructure:"resource"`
+
+	// Tags maps tag names to the regexp patterns their values must match. An empty
+	// pattern only requires the tag to be set.
+	Tags map[string]string `mapstructure:"tags"`
+
+	// Metrics maps metric names to the values they must have.
+	Metrics map[string]float64 `mapstructure:"metrics"`
+
+	// HTTPStatusCodes lists the HTTP status codes and ranges of codes matched by the rule,
+	// such as "404,500-599".
+	HTTPStatusCodes string `mapstructure:"http_status_codes"`
+
+	// MinDurationMs and MaxDurationMs bound the duration of the root span, in milliseconds.
+	MinDurationMs float64 `mapstructure:"min_duration_ms"`
+	MaxDurationMs float64 `mapstructure:"max_duration_ms"`
+
+	// SampleRate is the ratio of the matching traces kept, between 0 and 1.
+	SampleRate *float64 `mapstructure:"sample_rate"`
+
+	// Decision forces the matching traces to be kept or dropped, in place of SampleRate.
+	Decision string `mapstructure:"decision"`
+
+	// ServiceRe, OperationRe, ResourceRe, TagsRe and StatusCodes hold the compiled
+	// patterns and are only used internally.
+	ServiceRe   *regexp.Regexp            `mapstructure:"-"`
+	OperationRe *regexp.Regexp            `mapstructure:"-"`
+	ResourceRe  *regexp.Regexp            `mapstructure:"-"`
+	TagsRe      map[string]*regexp.Regexp `mapstructure:"-"`
+	StatusCodes []StatusCodeRange         `mapstructure:"-"`
+}
+
+// StatusCodeRange is an inclusive range of HTTP status codes.
+type StatusCodeRange struct {
+	Min, Max int
+}
+
 // WriterConfig specifies configuration for an API writer.
 type WriterConfig struct {
 	// ConnectionLimit specifies the maximum number of concurrent outgoing
@@ -367,6 +530,12 @@ func (c *AgentConfig) applyDatadogConfig() error {
 			if o.RemoveStackTraces {
 				c.addReplaceRule("error.stack", `(?s).*`, "?")
 			}
+			switch obfuscate.ObfuscationMode(o.SQL.ObfuscationMode) {
+			case obfuscate.ObfuscateAndNormalize, obfuscate.NormalizeOnly:
+			default:
+				log.Errorf("Unknown SQL obfuscation mode %q, the literals will be replaced.", o.SQL.ObfuscationMode)
+				o.SQL.ObfuscationMode = ""
+			}
 		}
 	}
 	{
@@ -411,6 +580,36 @@ func (c *AgentConfig) applyDatadogConfig() error {
 			log.Errorf("Error reading writer config %q: %v", key, err)
 		}
 	}
+	if config.Datadog.IsSet("apm_config.tail_sampling.enabled") {
+		c.TailSampling.Enabled = config.Datadog.GetBool("apm_config.tail_sampling.enabled")
+	}
+	if config.Datadog.IsSet("apm_config.tail_sampling.window") {
+		c.TailSampling.Window = getDuration(config.Datadog.GetInt("apm_config.tail_sampling.window"))
+	}
+	if config.Datadog.IsSet("apm_config.tail_sampling.max_buffered_bytes") {
+		c.TailSampling.MaxBufferedBytes = config.Datadog.GetInt("apm_config.tail_sampling.max_buffered_bytes")
+	}
+	if k := "apm_config.tail_sampling.policies"; config.Datadog.IsSet(k) {
+		var policies []*TailSamplingPolicy
+		if err := config.Datadog.UnmarshalKey(k, &policies); err != nil {
+			log.Errorf("Bad format for %q: %v", k, err)
+		} else if err := compileTailSamplingPolicies(policies); err != nil {
+			log.Errorf("Invalid %q, the tail sampling is disabled: %v", k, err)
+			c.TailSampling.Enabled = false
+		} else {
+			c.TailSampling.Policies = policies
+		}
+	}
+	if k := "apm_config.sampling_rules"; config.Datadog.IsSet(k) {
+		var rules []*SamplingRule
+		if err := config.Datadog.UnmarshalKey(k, &rules); err != nil {
+			log.Errorf("Bad format for %q: %v", k, err)
+		} else if err := compileSamplingRules(rules); err != nil {
+			log.Errorf("Invalid %q, the sampling rules are ignored: %v", k, err)
+		} else {
+			c.SamplingRules = rules
+		}
+	}
 	if config.Datadog.IsSet("apm_config.connection_reset_interval") {
 		c.ConnectionResetInterval = getDuration(config.Datadog.GetInt("apm_config.connection_reset_interval"))
 	}
@@ -550,6 +749,116 @@ func compileReplaceRules(rules []*ReplaceRule) error {
 	return nil
 }
 
+// compileTailSamplingPolicies validates the tail sampling policies and compiles their patterns.
+func compileTailSamplingPolicies(policies []*TailSamplingPolicy) error {
+	for _, p := range policies {
+		if p.Name == "" {
+			return errors.New(`all policies must have a "name" property`)
+		}
+		switch p.Type {
+		case TailSamplingLatency:
+			if p.LatencyThresholdMs <= 0 {
+				return fmt.Errorf("policy %q: latency_threshold_ms must be positive", p.Name)
+			}
+		case TailSamplingError:
+		case TailSamplingTag:
+			if p.Tag == "" {
+				return fmt.Errorf("policy %q: tag policies must have a \"tag\" property", p.Name)
+			}
+		case TailSamplingResource:
+		case TailSamplingRate:
+			if p.Rate < 0 || p.Rate > 1 {
+				return fmt.Errorf("policy %q: rate must be between 0 and 1", p.Name)
+			}
+		default:
+			return fmt.Errorf("policy %q: unknown type %q", p.Name, p.Type)
+		}
+		if p.Pattern != "" {
+			re, err := regexp.Compile(p.Pattern)
+			if err != nil {
+				return fmt.Errorf("policy %q: %s", p.Name, err)
+			}
+			p.Re = re
+		}
+	}
+	return nil
+}
+
+// compileSamplingRules validates the sampling rules and compiles their patterns.
+func compileSamplingRules(rules []*SamplingRule) error {
+	for _, r := range rules {
+		if r.Name == "" {
+			return errors.New(`all sampling rules must have a "name" property`)
+		}
+		switch {
+		case r.SampleRate != nil && r.Decision != "":
+			return fmt.Errorf("sampling rule %q: only one of sample_rate and decision can be set", r.Name)
+		case r.SampleRate != nil:
+			if *r.SampleRate < 0 || *r.SampleRate > 1 {
+				return fmt.Errorf("sampling rule %q: sample_rate must be between 0 and 1", r.Name)
+			}
+		case r.Decision != SamplingRuleKeep && r.Decision != SamplingRuleDrop:
+			return fmt.Errorf("sampling rule %q: decision must be %q or %q when sample_rate is not set", r.Name, SamplingRuleKeep, SamplingRuleDrop)
+		}
+		if r.MaxDurationMs > 0 && r.MaxDurationMs < r.MinDurationMs {
+			return fmt.Errorf("sampling rule %q: max_duration_ms is lower than min_duration_ms", r.Name)
+		}
+		var err error
+		if r.ServiceRe, err = compileRulePattern(r.Service); err != nil {
+			return fmt.Errorf("sampling rule %q: %s", r.Name, err)
+		}
+		if r.OperationRe, err = compileRulePattern(r.Operation); err != nil {
+			return fmt.Errorf("sampling rule %q: %s", r.Name, err)
+		}
+		if r.ResourceRe, err = compileRulePattern(r.Resource); err != nil {
+			return fmt.Errorf("sampling rule %q: %s", r.Name, err)
+		}
+		r.TagsRe = make(map[string]*regexp.Regexp, len(r.Tags))
+		for k, pattern := range r.Tags {
+			if r.TagsRe[k], err = compileRulePattern(pattern); err != nil {
+				return fmt.Errorf("sampling rule %q: tag %q: %s", r.Name, k, err)
+			}
+		}
+		if r.StatusCodes, err = parseStatusCodeRanges(r.HTTPStatusCodes); err != nil {
+			return fmt.Errorf("sampling rule %q: %s", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// compileRulePattern compiles the pattern of a sampling rule. An empty pattern matches
+// any value and compiles to nil.
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// parseStatusCodeRanges parses a comma separated list of HTTP status codes and ranges
+// of codes, such as "404,500-599".
+func parseStatusCodeRanges(s string) ([]StatusCodeRange, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var ranges []StatusCodeRange
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		min, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid HTTP status code %q", part)
+		}
+		max := min
+		if len(bounds) == 2 {
+			if max, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil || max < min {
+				return nil, fmt.Errorf("invalid HTTP status code range %q", part)
+			}
+		}
+		ranges = append(ranges, StatusCodeRange{Min: min, Max: max})
+	}
+	return ranges, nil
+}
+
 // getDuration returns the duration of the provided value in seconds
 func getDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
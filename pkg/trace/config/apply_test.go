@@ -28,6 +28,68 @@ func TestParseRepaceRules(t *testing.T) {
 	}
 }
 
+// TestCompileTailSamplingPolicies tests the compileTailSamplingPolicies helper function.
+func TestCompileTailSamplingPolicies(t *testing.T) {
+	assert := assert.New(t)
+	policies := []*TailSamplingPolicy{
+		{Name: "slow", Type: TailSamplingLatency, LatencyThresholdMs: 100},
+		{Name: "errors", Type: TailSamplingError},
+		{Name: "tenant", Type: TailSamplingTag, Tag: "tenant", Pattern: "^premium-"},
+		{Name: "checkout", Type: TailSamplingResource, Pattern: "checkout"},
+		{Name: "web", Type: TailSamplingRate, Service: "web", Rate: 0.1},
+	}
+	assert.NoError(compileTailSamplingPolicies(policies))
+	assert.Equal("^premium-", policies[2].Re.String())
+	assert.Nil(policies[1].Re)
+
+	for _, invalid := range []*TailSamplingPolicy{
+		{Type: TailSamplingError},
+		{Name: "unknown", Type: "size"},
+		{Name: "slow", Type: TailSamplingLatency},
+		{Name: "tenant", Type: TailSamplingTag},
+		{Name: "rate", Type: TailSamplingRate, Rate: 2},
+		{Name: "checkout", Type: TailSamplingResource, Pattern: "(checkout"},
+	} {
+		assert.Error(compileTailSamplingPolicies([]*TailSamplingPolicy{invalid}), "%+v", invalid)
+	}
+}
+
+// TestCompileSamplingRules tests the compileSamplingRules helper function.
+func TestCompileSamplingRules(t *testing.T) {
+	assert := assert.New(t)
+	rate := 0.1
+	rules := []*SamplingRule{
+		{Name: "health", Service: "^web$", Resource: "^GET /health$", Decision: SamplingRuleDrop},
+		{Name: "errors", HTTPStatusCodes: "404, 500-599", Tags: map[string]string{"tenant": "^premium-", "region": ""}, Decision: SamplingRuleKeep},
+		{Name: "slow", Operation: "^http\\.", MinDurationMs: 500, SampleRate: &rate},
+	}
+	assert.NoError(compileSamplingRules(rules))
+	assert.Equal("^web$", rules[0].ServiceRe.String())
+	assert.Nil(rules[0].OperationRe)
+	assert.Equal("^GET /health$", rules[0].ResourceRe.String())
+	assert.Equal([]StatusCodeRange{{404, 404}, {500, 599}}, rules[1].StatusCodes)
+	assert.Equal("^premium-", rules[1].TagsRe["tenant"].String())
+	assert.Contains(rules[1].TagsRe, "region")
+	assert.Nil(rules[1].TagsRe["region"])
+	assert.Equal("^http\\.", rules[2].OperationRe.String())
+
+	invalidRate := 1.5
+	for _, invalid := range []*SamplingRule{
+		{Decision: SamplingRuleKeep},
+		{Name: "none"},
+		{Name: "both", Decision: SamplingRuleKeep, SampleRate: &rate},
+		{Name: "decision", Decision: "maybe"},
+		{Name: "rate", SampleRate: &invalidRate},
+		{Name: "service", Service: "(web", Decision: SamplingRuleKeep},
+		{Name: "tag", Tags: map[string]string{"tenant": "(premium"}, Decision: SamplingRuleKeep},
+		{Name: "codes", HTTPStatusCodes: "5xx", Decision: SamplingRuleKeep},
+		{Name: "range", HTTPStatusCodes: "599-500", Decision: SamplingRuleKeep},
+		{Name: "durations", MinDurationMs: 500, MaxDurationMs: 100, Decision: SamplingRuleKeep},
+	} {
+		assert.Error(compileSamplingRules([]*SamplingRule{invalid}), "%+v", invalid)
+	}
+}
+
 func TestSplitTag(t *testing.T) {
 	for _, tt := range []struct {
 		tag string
@@ -74,6 +74,12 @@ type AgentConfig struct {
 	DisableRareSampler bool
 	MaxEPS             float64
 
+	// TailSampling holds the configuration of the tail sampling of the traces.
+	TailSampling *TailSamplingConfig
+
+	// SamplingRules sample the traces whose root span they match, by order of precedence.
+	SamplingRules []*SamplingRule
+
 	// Receiver
 	ReceiverHost    string
 	ReceiverPort    int
@@ -164,6 +170,10 @@ func New() *AgentConfig {
 		TargetTPS:       10,
 		ErrorTPS:        10,
 		MaxEPS:          200,
+		TailSampling: &TailSamplingConfig{
+			Window:           10 * time.Second,
+			MaxBufferedBytes: 50 * 1024 * 1024, // 50MB
+		},
 
 		ReceiverHost:    "localhost",
 		ReceiverPort:    8126,
@@ -291,21 +291,55 @@ func TestFullYamlConfig(t *testing.T) {
 
 	assert.EqualValues([]string{"/health", "/500"}, c.Ignore["resource"])
 
+	assert.True(c.TailSampling.Enabled)
+	assert.Equal(30*time.Second, c.TailSampling.Window)
+	assert.Equal(1000000, c.TailSampling.MaxBufferedBytes)
+	assert.ElementsMatch([]*TailSamplingPolicy{
+		{Name: "slow", Type: TailSamplingLatency, LatencyThresholdMs: 500},
+		{Name: "checkout", Type: TailSamplingResource, Service: "web", Pattern: "^POST /checkout", Re: regexp.MustCompile("^POST /checkout")},
+	}, c.TailSampling.Policies)
+
+	if assert.Len(c.SamplingRules, 2) {
+		health, checkout := c.SamplingRules[0], c.SamplingRules[1]
+		assert.Equal("health", health.Name)
+		assert.Equal(SamplingRuleDrop, health.Decision)
+		assert.Equal("^GET /health$", health.ResourceRe.String())
+		assert.Nil(health.SampleRate)
+		assert.Equal("checkout-errors", checkout.Name)
+		assert.Equal("web", checkout.ServiceRe.String())
+		assert.Equal("^premium-", checkout.TagsRe["tenant"].String())
+		assert.Equal([]StatusCodeRange{{500, 599}}, checkout.StatusCodes)
+		if assert.NotNil(checkout.SampleRate) {
+			assert.Equal(0.5, *checkout.SampleRate)
+		}
+	}
+
 	assert.Equal("0.0.0.0", c.OTLPReceiver.BindHost)
 	assert.Equal(0, c.OTLPReceiver.HTTPPort)
 	assert.Equal(50053, c.OTLPReceiver.GRPCPort)
 
 	o := c.Obfuscation
 	assert.NotNil(o)
+	assert.Equal(SQLObfuscationConfig{
+		ObfuscationMode:   "normalize_only",
+		KeepComments:      true,
+		CollectOperations: true,
+		CollectProcedures: true,
+	}, o.SQL)
 	assert.True(o.ES.Enabled)
 	assert.EqualValues([]string{"user_id", "category_id"}, o.ES.KeepValues)
 	assert.True(o.Mongo.Enabled)
 	assert.EqualValues([]string{"uid", "cat_id"}, o.Mongo.KeepValues)
+	assert.True(o.OpenSearch.Enabled)
+	assert.EqualValues([]string{"size"}, o.OpenSearch.KeepValues)
+	assert.True(o.DynamoDB.Enabled)
+	assert.EqualValues([]string{"Region"}, o.DynamoDB.KeepValues)
 	assert.True(o.HTTP.RemoveQueryString)
 	assert.True(o.HTTP.RemovePathDigits)
 	assert.True(o.RemoveStackTraces)
 	assert.True(c.Obfuscation.Redis.Enabled)
 	assert.True(c.Obfuscation.Memcached.Enabled)
+	assert.True(c.Obfuscation.GraphQL.Enabled)
 	assert.True(c.Obfuscation.CreditCards.Enabled)
 	assert.True(c.Obfuscation.CreditCards.Luhn)
 }
@@ -124,7 +124,7 @@ type TagStats struct {
 }
 
 func newTagStats(tags Tags) *TagStats {
-	return &TagStats{tags, Stats{TracesDropped: &TracesDropped{}, SpansMalformed: &SpansMalformed{}}}
+	return &TagStats{tags, Stats{TracesDropped: &TracesDropped{}, SpansMalformed: &SpansMalformed{}, TracesPerSamplingRule: &samplingRuleStats{}}}
 }
 
 // AsTags returns all the tags contained in the TagStats.
@@ -175,6 +175,13 @@ func (ts *TagStats) publish() {
 	for priority, count := range ts.TracesPerSamplingPriority.TagValues() {
 		metrics.Count("datadog.trace_agent.receiver.traces_priority", count, append(tags, "priority:"+priority), 1)
 	}
+	kept, dropped := ts.TracesPerSamplingRule.TagValues()
+	for rule, count := range kept {
+		metrics.Count("datadog.trace_agent.sampler.rules.kept", count, append(tags, "rule:"+rule), 1)
+	}
+	for rule, count := range dropped {
+		metrics.Count("datadog.trace_agent.sampler.rules.dropped", count, append(tags, "rule:"+rule), 1)
+	}
 }
 
 // mapToString serializes the entries in this map into format "key1: value1, key2: value2, ...", sorted by
@@ -330,6 +337,69 @@ func (s *samplingPriorityStats) TagValues() map[string]int64 {
 	return stats
 }
 
+// samplingRuleStats holds the counts of traces kept and dropped by each agent sampling rule
+// that will be reported every 10s by the agent.
+type samplingRuleStats struct {
+	mu      sync.Mutex
+	kept    map[string]int64
+	dropped map[string]int64
+}
+
+// CountSamplingRule increments the counter of traces kept or dropped by the given sampling rule by 1
+func (s *samplingRuleStats) CountSamplingRule(rule string, kept bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kept {
+		s.kept = incr(s.kept, rule, 1)
+	} else {
+		s.dropped = incr(s.dropped, rule, 1)
+	}
+}
+
+// reset sets stats to 0
+func (s *samplingRuleStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kept, s.dropped = nil, nil
+}
+
+// update absorbs recent stats on top of existing ones.
+func (s *samplingRuleStats) update(recent *samplingRuleStats) {
+	kept, dropped := recent.TagValues()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for rule, count := range kept {
+		s.kept = incr(s.kept, rule, count)
+	}
+	for rule, count := range dropped {
+		s.dropped = incr(s.dropped, rule, count)
+	}
+}
+
+// TagValues returns maps with the number of traces that have been kept and dropped by each rule
+func (s *samplingRuleStats) TagValues() (kept, dropped map[string]int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept = make(map[string]int64, len(s.kept))
+	for rule, count := range s.kept {
+		kept[rule] = count
+	}
+	dropped = make(map[string]int64, len(s.dropped))
+	for rule, count := range s.dropped {
+		dropped[rule] = count
+	}
+	return kept, dropped
+}
+
+// incr adds n to the counter of key in m, allocating m if needed.
+func incr(m map[string]int64, key string, n int64) map[string]int64 {
+	if m == nil {
+		m = make(map[string]int64)
+	}
+	m[key] += n
+	return m
+}
+
 // Stats holds the metrics that will be reported every 10s by the agent.
 // Its fields require to be accessed in an atomic way.
 type Stats struct {
@@ -345,6 +415,8 @@ type Stats struct {
 	TracesPriorityNone int64
 	// TracesPerPriority holds counters for each priority in position MaxAbsPriorityValue + priority.
 	TracesPerSamplingPriority samplingPriorityStats
+	// TracesPerSamplingRule holds the counts of traces kept and dropped by each agent sampling rule.
+	TracesPerSamplingRule *samplingRuleStats
 	// ClientDroppedP0Traces number of P0 traces dropped by client.
 	ClientDroppedP0Traces int64
 	// ClientDroppedP0Spans number of P0 spans dropped by client.
@@ -402,6 +474,7 @@ func (s *Stats) update(recent *Stats) {
 	atomic.AddInt64(&s.PayloadAccepted, atomic.LoadInt64(&recent.PayloadAccepted))
 	atomic.AddInt64(&s.PayloadRefused, atomic.LoadInt64(&recent.PayloadRefused))
 	s.TracesPerSamplingPriority.update(&recent.TracesPerSamplingPriority)
+	s.TracesPerSamplingRule.update(recent.TracesPerSamplingRule)
 }
 
 func (s *Stats) reset() {
@@ -439,6 +512,7 @@ func (s *Stats) reset() {
 	atomic.StoreInt64(&s.PayloadAccepted, 0)
 	atomic.StoreInt64(&s.PayloadRefused, 0)
 	s.TracesPerSamplingPriority.reset()
+	s.TracesPerSamplingRule.reset()
 }
 
 func (s *Stats) isEmpty() bool {
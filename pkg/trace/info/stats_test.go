@@ -161,6 +161,30 @@ func TestSamplingPriorityStats(t *testing.T) {
 	})
 }
 
+func TestSamplingRuleStats(t *testing.T) {
+	s := &samplingRuleStats{}
+	s.CountSamplingRule("health", false)
+	s.CountSamplingRule("health", false)
+	s.CountSamplingRule("errors", true)
+
+	kept, dropped := s.TagValues()
+	assert.Equal(t, map[string]int64{"errors": 1}, kept)
+	assert.Equal(t, map[string]int64{"health": 2}, dropped)
+
+	recent := &samplingRuleStats{}
+	recent.CountSamplingRule("health", true)
+	recent.CountSamplingRule("health", false)
+	s.update(recent)
+	kept, dropped = s.TagValues()
+	assert.Equal(t, map[string]int64{"errors": 1, "health": 1}, kept)
+	assert.Equal(t, map[string]int64{"health": 3}, dropped)
+
+	s.reset()
+	kept, dropped = s.TagValues()
+	assert.Empty(t, kept)
+	assert.Empty(t, dropped)
+}
+
 var _ metrics.StatsClient = (*testStatsClient)(nil)
 
 type testStatsClient struct {
@@ -219,6 +243,10 @@ func TestReceiverStats(t *testing.T) {
 								maxAbsPriority + 4: 5,
 							},
 						},
+						TracesPerSamplingRule: &samplingRuleStats{
+							kept:    map[string]int64{"health": 6},
+							dropped: map[string]int64{"health": 7, "errors": 8},
+						},
 						ClientDroppedP0Traces: 7,
 						ClientDroppedP0Spans:  8,
 						TracesBytes:           9,
@@ -237,7 +265,7 @@ func TestReceiverStats(t *testing.T) {
 
 	t.Run("Publish", func(t *testing.T) {
 		testStats().Publish()
-		assert.EqualValues(t, atomic.LoadInt64(&statsclient.counts), 39)
+		assert.EqualValues(t, atomic.LoadInt64(&statsclient.counts), 42)
 	})
 
 	t.Run("reset", func(t *testing.T) {
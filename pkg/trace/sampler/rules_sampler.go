@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// agentRuleRateKey is the metric key holding the rate of the agent sampling rule applied on a root span.
+const agentRuleRateKey = "_dd.agent_rule_sr"
+
+// RulesSampler samples the traces whose root span matches one of the sampling rules
+// of the agent configuration, in place of the other samplers.
+type RulesSampler struct {
+	rules []*config.SamplingRule
+}
+
+// NewRulesSampler returns a RulesSampler applying rules, by order of precedence.
+func NewRulesSampler(rules []*config.SamplingRule) *RulesSampler {
+	return &RulesSampler{rules: rules}
+}
+
+// Sample returns the name of the first rule matching root and whether this rule keeps
+// the trace. The name is empty when no rule matches root.
+func (s *RulesSampler) Sample(root *pb.Span) (rule string, sampled bool) {
+	for _, r := range s.rules {
+		if !ruleMatches(r, root) {
+			continue
+		}
+		rate := ruleRate(r)
+		sampled = SampleByRate(root.TraceID, rate)
+		if sampled {
+			setMetric(root, agentRuleRateKey, rate)
+		}
+		return r.Name, sampled
+	}
+	return "", false
+}
+
+// ruleRate returns the ratio of the traces kept by a rule.
+func ruleRate(r *config.SamplingRule) float64 {
+	switch r.Decision {
+	case config.SamplingRuleKeep:
+		return 1
+	case config.SamplingRuleDrop:
+		return 0
+	}
+	return *r.SampleRate
+}
+
+// ruleMatches returns whether a rule matches a root span.
+func ruleMatches(r *config.SamplingRule, root *pb.Span) bool {
+	if r.ServiceRe != nil && !r.ServiceRe.MatchString(root.Service) {
+		return false
+	}
+	if r.OperationRe != nil && !r.OperationRe.MatchString(root.Name) {
+		return false
+	}
+	if r.ResourceRe != nil && !r.ResourceRe.MatchString(root.Resource) {
+		return false
+	}
+	for k, re := range r.TagsRe {
+		v, ok := root.Meta[k]
+		if !ok || (re != nil && !re.MatchString(v)) {
+			return false
+		}
+	}
+	for k, expected := range r.Metrics {
+		if v, ok := getMetric(root, k); !ok || v != expected {
+			return false
+		}
+	}
+	if len(r.StatusCodes) > 0 && !statusCodeMatches(r.StatusCodes, root) {
+		return false
+	}
+	if r.MinDurationMs > 0 && root.Duration < int64(r.MinDurationMs*float64(time.Millisecond)) {
+		return false
+	}
+	if r.MaxDurationMs > 0 && root.Duration > int64(r.MaxDurationMs*float64(time.Millisecond)) {
+		return false
+	}
+	return true
+}
+
+// statusCodeMatches returns whether the HTTP status code of root is in one of ranges.
+func statusCodeMatches(ranges []config.StatusCodeRange, root *pb.Span) bool {
+	var code int
+	if v, ok := root.Meta[KeyHTTPStatusCode]; ok {
+		c, err := strconv.Atoi(v)
+		if err != nil {
+			return false
+		}
+		code = c
+	} else if v, ok := getMetric(root, KeyHTTPStatusCode); ok {
+		code = int(v)
+	} else {
+		return false
+	}
+	for _, r := range ranges {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRulesSampler(t *testing.T) {
+	assert := assert.New(t)
+	half := 0.5
+	s := NewRulesSampler([]*config.SamplingRule{
+		{Name: "health", ResourceRe: regexp.MustCompile("^GET /health$"), Decision: config.SamplingRuleDrop},
+		{Name: "errors", StatusCodes: []config.StatusCodeRange{{Min: 500, Max: 599}}, Decision: config.SamplingRuleKeep},
+		{Name: "half", ServiceRe: regexp.MustCompile("^web$"), SampleRate: &half},
+	})
+
+	rule, sampled := s.Sample(&pb.Span{TraceID: 1, Service: "web", Resource: "GET /health"})
+	assert.Equal("health", rule)
+	assert.False(sampled)
+
+	root := &pb.Span{TraceID: 1, Service: "web", Resource: "GET /", Metrics: map[string]float64{"http.status_code": 502}}
+	rule, sampled = s.Sample(root)
+	assert.Equal("errors", rule)
+	assert.True(sampled)
+	assert.Equal(1.0, root.Metrics[agentRuleRateKey])
+
+	var kept int
+	for i := uint64(0); i < 1000; i++ {
+		rule, sampled = s.Sample(&pb.Span{TraceID: i * 7919, Service: "web"})
+		assert.Equal("half", rule)
+		if sampled {
+			kept++
+		}
+	}
+	assert.InDelta(500, kept, 100)
+
+	rule, sampled = s.Sample(&pb.Span{TraceID: 1, Service: "db"})
+	assert.Equal("", rule)
+	assert.False(sampled)
+}
+
+func TestRuleMatches(t *testing.T) {
+	root := &pb.Span{
+		Service:  "web",
+		Name:     "http.request",
+		Resource: "GET /checkout",
+		Duration: int64(200 * time.Millisecond),
+		Meta:     map[string]string{"http.status_code": "404", "tenant": "premium-1"},
+		Metrics:  map[string]float64{"retries": 2},
+	}
+	for _, tt := range []struct {
+		rule  config.SamplingRule
+		match bool
+	}{
+		{config.SamplingRule{}, true},
+		{config.SamplingRule{ServiceRe: regexp.MustCompile("^web$")}, true},
+		{config.SamplingRule{ServiceRe: regexp.MustCompile("^db$")}, false},
+		{config.SamplingRule{OperationRe: regexp.MustCompile("^http\\.")}, true},
+		{config.SamplingRule{ResourceRe: regexp.MustCompile("health")}, false},
+		{config.SamplingRule{TagsRe: map[string]*regexp.Regexp{"tenant": regexp.MustCompile("^premium-")}}, true},
+		{config.SamplingRule{TagsRe: map[string]*regexp.Regexp{"tenant": nil}}, true},
+		{config.SamplingRule{TagsRe: map[string]*regexp.Regexp{"region": nil}}, false},
+		{config.SamplingRule{Metrics: map[string]float64{"retries": 2}}, true},
+		{config.SamplingRule{Metrics: map[string]float64{"retries": 3}}, false},
+		{config.SamplingRule{StatusCodes: []config.StatusCodeRange{{Min: 404, Max: 404}}}, true},
+		{config.SamplingRule{StatusCodes: []config.StatusCodeRange{{Min: 500, Max: 599}}}, false},
+		{config.SamplingRule{MinDurationMs: 100}, true},
+		{config.SamplingRule{MinDurationMs: 300}, false},
+		{config.SamplingRule{MaxDurationMs: 100}, false},
+		{config.SamplingRule{MinDurationMs: 100, MaxDurationMs: 300}, true},
+	} {
+		assert.Equal(t, tt.match, ruleMatches(&tt.rule, root), "%+v", tt.rule)
+	}
+	assert.False(t, ruleMatches(&config.SamplingRule{StatusCodes: []config.StatusCodeRange{{Min: 200, Max: 599}}}, &pb.Span{}))
+}
@@ -0,0 +1,362 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// headReason is the telemetry reason of the traces kept because a chunk was kept by the head samplers.
+const headReason = "head"
+
+// TailChunk is a trace chunk buffered by the TailSampler.
+type TailChunk struct {
+	// Chunk is the buffered chunk.
+	Chunk *pb.TraceChunk
+	// Sampled reports whether the chunk was kept by the head samplers.
+	Sampled bool
+	// Size is the approximate size of the chunk, in bytes.
+	Size int
+	// Flush is called with the sampling decision of the trace of the chunk.
+	Flush func(keep bool)
+}
+
+// tailTrace holds the chunks of a trace buffered by the TailSampler.
+type tailTrace struct {
+	traceID  uint64
+	deadline time.Time
+	chunks   []*TailChunk
+	size     int
+}
+
+// TailSampler buffers the trace chunks by trace ID for a window, then samples the
+// complete local traces: a trace is kept if one of its chunks was kept by the head
+// samplers or if it matches one of the policies. The chunks received after the
+// decision on their trace follow that decision.
+type TailSampler struct {
+	window   time.Duration
+	maxBytes int
+	policies []*tailPolicy
+
+	mu     sync.Mutex
+	traces map[uint64]*tailTrace
+	// queue holds the buffered traces by order of arrival, hence of deadline.
+	queue []*tailTrace
+	size  int
+	// decided and previouslyDecided hold the decisions of the last two windows.
+	decided           map[uint64]bool
+	previouslyDecided map[uint64]bool
+	stopped           bool
+
+	// telemetry, reset on report
+	kept       map[string]int64
+	dropped    int64
+	evicted    int64
+	lateChunks int64
+
+	tick *time.Ticker
+	exit chan struct{}
+	done chan struct{}
+}
+
+// NewTailSampler returns a TailSampler configured by conf.
+func NewTailSampler(conf *config.TailSamplingConfig) *TailSampler {
+	policies := make([]*tailPolicy, 0, len(conf.Policies))
+	for _, p := range conf.Policies {
+		policies = append(policies, newTailPolicy(p))
+	}
+	return &TailSampler{
+		window:            conf.Window,
+		maxBytes:          conf.MaxBufferedBytes,
+		policies:          policies,
+		traces:            make(map[uint64]*tailTrace),
+		decided:           make(map[uint64]bool),
+		previouslyDecided: make(map[uint64]bool),
+		kept:              make(map[string]int64),
+		exit:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+}
+
+// Start starts sampling the traces at the end of their window.
+func (s *TailSampler) Start() {
+	s.tick = time.NewTicker(s.checkPeriod())
+	go func() {
+		defer close(s.done)
+		statsTicker := time.NewTicker(10 * time.Second)
+		defer statsTicker.Stop()
+		rotated := time.Now()
+		for {
+			select {
+			case now := <-s.tick.C:
+				s.flushExpired(now)
+				if now.Sub(rotated) >= s.window {
+					s.rotateDecisions()
+					rotated = now
+				}
+			case <-statsTicker.C:
+				s.report()
+			case <-s.exit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop samples all the buffered traces and stops the sampler. The chunks added
+// afterwards are flushed right away with the decision of the head samplers.
+func (s *TailSampler) Stop() {
+	close(s.exit)
+	<-s.done
+	s.tick.Stop()
+
+	s.mu.Lock()
+	s.stopped = true
+	traces := s.queue
+	s.queue = nil
+	s.traces = make(map[uint64]*tailTrace)
+	s.size = 0
+	s.mu.Unlock()
+
+	for _, t := range traces {
+		s.flush(t, s.decide(t))
+	}
+	s.report()
+}
+
+// Add buffers a chunk until its trace is sampled.
+func (s *TailSampler) Add(c *TailChunk) {
+	if len(c.Chunk.Spans) == 0 {
+		return
+	}
+	traceID := c.Chunk.Spans[0].TraceID
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		c.Flush(c.Sampled)
+		return
+	}
+	if keep, ok := s.decision(traceID); ok {
+		s.lateChunks++
+		s.mu.Unlock()
+		c.Flush(keep || c.Sampled)
+		return
+	}
+	t, ok := s.traces[traceID]
+	if !ok {
+		t = &tailTrace{traceID: traceID, deadline: time.Now().Add(s.window)}
+		s.traces[traceID] = t
+		s.queue = append(s.queue, t)
+	}
+	t.chunks = append(t.chunks, c)
+	t.size += c.Size
+	s.size += c.Size
+
+	// the oldest traces are sampled early to bound the memory
+	var evicted []*tailTrace
+	for s.maxBytes > 0 && s.size > s.maxBytes && len(s.queue) > 0 {
+		evicted = append(evicted, s.pop())
+	}
+	s.evicted += int64(len(evicted))
+	s.mu.Unlock()
+
+	s.flushAll(evicted)
+}
+
+// flushExpired samples the traces whose window ended before now.
+func (s *TailSampler) flushExpired(now time.Time) {
+	var expired []*tailTrace
+	s.mu.Lock()
+	for len(s.queue) > 0 && !s.queue[0].deadline.After(now) {
+		expired = append(expired, s.pop())
+	}
+	s.mu.Unlock()
+
+	s.flushAll(expired)
+}
+
+// pop removes the oldest trace from the buffer. It must be called with the lock held.
+func (s *TailSampler) pop() *tailTrace {
+	t := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	delete(s.traces, t.traceID)
+	s.size -= t.size
+	return t
+}
+
+// flushAll samples the traces and records the decisions for their late chunks.
+func (s *TailSampler) flushAll(traces []*tailTrace) {
+	for _, t := range traces {
+		keep := s.decide(t)
+		s.mu.Lock()
+		s.decided[t.traceID] = keep
+		s.mu.Unlock()
+		s.flush(t, keep)
+	}
+}
+
+// decide returns whether a trace is kept, and counts the decision.
+func (s *TailSampler) decide(t *tailTrace) bool {
+	reason := s.reason(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reason == "" {
+		s.dropped++
+		return false
+	}
+	s.kept[reason]++
+	return true
+}
+
+// reason returns the reason a trace is kept for, or an empty string if it is dropped.
+func (s *TailSampler) reason(t *tailTrace) string {
+	for _, c := range t.chunks {
+		if c.Sampled {
+			return headReason
+		}
+	}
+	for _, p := range s.policies {
+		if p.matches(t) {
+			return p.name
+		}
+	}
+	return ""
+}
+
+func (s *TailSampler) flush(t *tailTrace, keep bool) {
+	for _, c := range t.chunks {
+		c.Flush(keep)
+	}
+}
+
+// decision returns the decision made on a trace during the last two windows.
+// It must be called with the lock held.
+func (s *TailSampler) decision(traceID uint64) (keep bool, ok bool) {
+	if keep, ok = s.decided[traceID]; ok {
+		return keep, ok
+	}
+	keep, ok = s.previouslyDecided[traceID]
+	return keep, ok
+}
+
+// rotateDecisions forgets the decisions made before the last window.
+func (s *TailSampler) rotateDecisions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.previouslyDecided = s.decided
+	s.decided = make(map[uint64]bool)
+}
+
+// checkPeriod returns the period at which the end of the windows is checked.
+func (s *TailSampler) checkPeriod() time.Duration {
+	period := s.window / 10
+	if period < 100*time.Millisecond {
+		period = 100 * time.Millisecond
+	}
+	return period
+}
+
+func (s *TailSampler) report() {
+	s.mu.Lock()
+	kept, dropped, evicted, lateChunks := s.kept, s.dropped, s.evicted, s.lateChunks
+	s.kept, s.dropped, s.evicted, s.lateChunks = make(map[string]int64), 0, 0, 0
+	buffered, size := len(s.queue), s.size
+	s.mu.Unlock()
+
+	for reason, n := range kept {
+		metrics.Count("datadog.trace_agent.sampler.tail.kept", n, []string{"reason:" + reason}, 1)
+	}
+	metrics.Count("datadog.trace_agent.sampler.tail.dropped", dropped, nil, 1)
+	metrics.Count("datadog.trace_agent.sampler.tail.evicted", evicted, nil, 1)
+	metrics.Count("datadog.trace_agent.sampler.tail.late_chunks", lateChunks, nil, 1)
+	metrics.Gauge("datadog.trace_agent.sampler.tail.buffered_traces", float64(buffered), nil, 1)
+	metrics.Gauge("datadog.trace_agent.sampler.tail.buffered_bytes", float64(size), nil, 1)
+}
+
+// tailPolicy keeps the traces it matches.
+type tailPolicy struct {
+	name    string
+	service string
+	match   func(t *tailTrace) bool
+}
+
+func newTailPolicy(conf *config.TailSamplingPolicy) *tailPolicy {
+	p := &tailPolicy{name: conf.Name, service: conf.Service}
+	switch conf.Type {
+	case config.TailSamplingLatency:
+		threshold := int64(conf.LatencyThresholdMs * float64(time.Millisecond))
+		p.match = func(t *tailTrace) bool {
+			return traceDuration(t) >= threshold
+		}
+	case config.TailSamplingError:
+		p.match = func(t *tailTrace) bool {
+			return anySpan(t, func(s *pb.Span) bool { return s.Error != 0 })
+		}
+	case config.TailSamplingTag:
+		p.match = func(t *tailTrace) bool {
+			return anySpan(t, func(s *pb.Span) bool {
+				v, ok := s.Meta[conf.Tag]
+				return ok && (conf.Re == nil || conf.Re.MatchString(v))
+			})
+		}
+	case config.TailSamplingResource:
+		p.match = func(t *tailTrace) bool {
+			return anySpan(t, func(s *pb.Span) bool {
+				return conf.Re == nil || conf.Re.MatchString(s.Resource)
+			})
+		}
+	case config.TailSamplingRate:
+		p.match = func(t *tailTrace) bool {
+			return SampleByRate(t.traceID, conf.Rate)
+		}
+	default:
+		p.match = func(t *tailTrace) bool { return false }
+	}
+	return p
+}
+
+// matches returns whether the policy keeps a trace.
+func (p *tailPolicy) matches(t *tailTrace) bool {
+	if p.service != "" && !anySpan(t, func(s *pb.Span) bool { return s.Service == p.service }) {
+		return false
+	}
+	return p.match(t)
+}
+
+// anySpan returns whether one of the spans of a trace satisfies f.
+func anySpan(t *tailTrace, f func(*pb.Span) bool) bool {
+	for _, c := range t.chunks {
+		for _, s := range c.Chunk.Spans {
+			if f(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// traceDuration returns the duration of the buffered part of a trace, in nanoseconds.
+func traceDuration(t *tailTrace) int64 {
+	var start, end int64
+	for _, c := range t.chunks {
+		for _, s := range c.Chunk.Spans {
+			if start == 0 || s.Start < start {
+				start = s.Start
+			}
+			if s.Start+s.Duration > end {
+				end = s.Start + s.Duration
+			}
+		}
+	}
+	return end - start
+}
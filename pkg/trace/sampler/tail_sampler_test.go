@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+// tailDecisions records the decisions flushed by a TailSampler by span ID.
+type tailDecisions map[uint64]bool
+
+func (d tailDecisions) chunk(sampled bool, spans ...*pb.Span) *TailChunk {
+	return &TailChunk{
+		Chunk:   &pb.TraceChunk{Spans: spans},
+		Sampled: sampled,
+		Size:    10,
+		Flush: func(keep bool) {
+			for _, s := range spans {
+				d[s.SpanID] = keep
+			}
+		},
+	}
+}
+
+func newTestTailSampler(maxBytes int, policies ...*config.TailSamplingPolicy) *TailSampler {
+	return NewTailSampler(&config.TailSamplingConfig{
+		Enabled:          true,
+		Window:           time.Minute,
+		MaxBufferedBytes: maxBytes,
+		Policies:         policies,
+	})
+}
+
+func TestTailSamplerSamplesCompleteTraces(t *testing.T) {
+	assert := assert.New(t)
+	s := newTestTailSampler(0, &config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingError})
+	d := tailDecisions{}
+
+	// the error of the second chunk keeps the first one
+	s.Add(d.chunk(false, &pb.Span{TraceID: 1, SpanID: 1}))
+	s.Add(d.chunk(false, &pb.Span{TraceID: 1, SpanID: 2, Error: 1}))
+	s.Add(d.chunk(false, &pb.Span{TraceID: 2, SpanID: 3}))
+	// the trace is kept as a chunk was kept by the head samplers
+	s.Add(d.chunk(false, &pb.Span{TraceID: 3, SpanID: 4}))
+	s.Add(d.chunk(true, &pb.Span{TraceID: 3, SpanID: 5}))
+	assert.Empty(d)
+
+	s.flushExpired(time.Now())
+	assert.Empty(d)
+	s.flushExpired(time.Now().Add(time.Minute))
+	assert.Equal(tailDecisions{1: true, 2: true, 3: false, 4: true, 5: true}, d)
+	assert.Equal(map[string]int64{"errors": 1, headReason: 1}, s.kept)
+	assert.EqualValues(1, s.dropped)
+	assert.Empty(s.traces)
+	assert.Zero(s.size)
+
+	// the late chunks follow the decision made on their trace
+	s.Add(d.chunk(false, &pb.Span{TraceID: 1, SpanID: 6}))
+	s.Add(d.chunk(false, &pb.Span{TraceID: 2, SpanID: 7}))
+	assert.True(d[6])
+	assert.False(d[7])
+	assert.EqualValues(2, s.lateChunks)
+
+	// the decisions are forgotten after two windows
+	s.rotateDecisions()
+	s.rotateDecisions()
+	s.Add(d.chunk(false, &pb.Span{TraceID: 1, SpanID: 8}))
+	assert.NotContains(d, uint64(8))
+}
+
+func TestTailSamplerBoundsMemory(t *testing.T) {
+	assert := assert.New(t)
+	s := newTestTailSampler(25)
+	d := tailDecisions{}
+
+	s.Add(d.chunk(true, &pb.Span{TraceID: 1, SpanID: 1}))
+	s.Add(d.chunk(false, &pb.Span{TraceID: 2, SpanID: 2}))
+	assert.Empty(d)
+	s.Add(d.chunk(false, &pb.Span{TraceID: 2, SpanID: 3}))
+	assert.Equal(tailDecisions{1: true}, d)
+	assert.EqualValues(1, s.evicted)
+	assert.Equal(20, s.size)
+}
+
+func TestTailSamplerStop(t *testing.T) {
+	s := newTestTailSampler(0)
+	d := tailDecisions{}
+	s.Start()
+
+	s.Add(d.chunk(true, &pb.Span{TraceID: 1, SpanID: 1}))
+	s.Stop()
+	assert.Equal(t, tailDecisions{1: true}, d)
+
+	s.Add(d.chunk(false, &pb.Span{TraceID: 2, SpanID: 2}))
+	assert.Equal(t, tailDecisions{1: true, 2: false}, d)
+}
+
+func TestTailPolicies(t *testing.T) {
+	trace := func(spans ...*pb.Span) *tailTrace {
+		return &tailTrace{traceID: spans[0].TraceID, chunks: []*TailChunk{{Chunk: &pb.TraceChunk{Spans: spans}}}}
+	}
+	slow := trace(
+		&pb.Span{TraceID: 1, Service: "web", Resource: "GET /checkout", Start: 100, Duration: 50},
+		&pb.Span{TraceID: 1, Service: "db", Start: 120, Duration: 500e6, Meta: map[string]string{"db.type": "postgres"}},
+	)
+	fast := trace(&pb.Span{TraceID: 2, Service: "web", Resource: "GET /health", Start: 100, Duration: 50, Error: 1})
+
+	for _, tt := range []struct {
+		policy config.TailSamplingPolicy
+		slow   bool
+		fast   bool
+	}{
+		{config.TailSamplingPolicy{Type: config.TailSamplingLatency, LatencyThresholdMs: 500}, true, false},
+		{config.TailSamplingPolicy{Type: config.TailSamplingLatency, LatencyThresholdMs: 500, Service: "api"}, false, false},
+		{config.TailSamplingPolicy{Type: config.TailSamplingError}, false, true},
+		{config.TailSamplingPolicy{Type: config.TailSamplingTag, Tag: "db.type"}, true, false},
+		{config.TailSamplingPolicy{Type: config.TailSamplingTag, Tag: "db.type", Re: regexp.MustCompile("^mysql$")}, false, false},
+		{config.TailSamplingPolicy{Type: config.TailSamplingResource, Re: regexp.MustCompile("checkout")}, true, false},
+		{config.TailSamplingPolicy{Type: config.TailSamplingRate, Rate: 1}, true, true},
+		{config.TailSamplingPolicy{Type: config.TailSamplingRate, Rate: 1, Service: "db"}, true, false},
+		{config.TailSamplingPolicy{Type: config.TailSamplingRate, Rate: 0}, false, false},
+	} {
+		p := newTailPolicy(&tt.policy)
+		assert.Equal(t, tt.slow, p.matches(slow), "%+v", tt.policy)
+		assert.Equal(t, tt.fast, p.matches(fast), "%+v", tt.policy)
+	}
+}
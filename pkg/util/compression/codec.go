@@ -0,0 +1,189 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compression
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/DataDog/zstd"
+)
+
+// Codec kinds that can be selected at runtime
+const (
+	// ZlibKind compresses with zlib, sent with the `deflate` content encoding
+	ZlibKind = "zlib"
+	// ZstdKind compresses with zstd
+	ZstdKind = "zstd"
+	// NoneKind does not compress
+	NoneKind = "none"
+)
+
+// StreamWriter compresses the data written to it into an underlying writer.
+// Flush writes the data compressed so far, Close writes the end of the
+// compressed stream.
+type StreamWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// Codec is a compression algorithm selectable at runtime, unlike the
+// package-level functions whose algorithm is chosen at build time.
+type Codec interface {
+	// ContentEncoding returns the value of the `Content-Encoding` HTTP header
+	// of the payloads compressed by the codec, empty if they are not compressed.
+	ContentEncoding() string
+	// Compress compresses src
+	Compress(dst []byte, src []byte) ([]byte, error)
+	// Decompress decompresses src
+	Decompress(dst []byte, src []byte) ([]byte, error)
+	// CompressBound returns the worst case size of sourceLen compressed bytes
+	CompressBound(sourceLen int) int
+	// NewStreamWriter returns a writer compressing into w
+	NewStreamWriter(w io.Writer) (StreamWriter, error)
+}
+
+// NewCodec returns the codec of the given kind. level is only used by zstd.
+func NewCodec(kind string, level int) (Codec, error) {
+	switch kind {
+	case ZlibKind:
+		return ZlibCodec, nil
+	case ZstdKind:
+		return NewZstdCodec(level), nil
+	case NoneKind:
+		return NoneCodec, nil
+	}
+	return nil, fmt.Errorf("unknown compression kind %q, must be one of %q, %q or %q", kind, ZlibKind, ZstdKind, NoneKind)
+}
+
+// CodecForContentEncoding returns the codec able to decompress payloads with
+// the given `Content-Encoding` HTTP header.
+func CodecForContentEncoding(contentEncoding string) (Codec, error) {
+	switch contentEncoding {
+	case ZlibCodec.ContentEncoding():
+		return ZlibCodec, nil
+	case ZstdKind:
+		return NewZstdCodec(zstd.DefaultCompression), nil
+	case NoneCodec.ContentEncoding():
+		return NoneCodec, nil
+	}
+	return nil, fmt.Errorf("unknown content encoding %q", contentEncoding)
+}
+
+// ZlibCodec compresses payloads with zlib
+var ZlibCodec Codec = zlibCodec{}
+
+type zlibCodec struct{}
+
+func (zlibCodec) ContentEncoding() string {
+	return "deflate"
+}
+
+func (zlibCodec) Compress(dst []byte, src []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (zlibCodec) Decompress(dst []byte, src []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func (zlibCodec) CompressBound(sourceLen int) int {
+	// From https://code.woboq.org/gcc/zlib/compress.c.html#compressBound
+	return sourceLen + (sourceLen >> 12) + (sourceLen >> 14) + (sourceLen >> 25) + 13
+}
+
+func (zlibCodec) NewStreamWriter(w io.Writer) (StreamWriter, error) {
+	return zlib.NewWriter(w), nil
+}
+
+// ZstdCodec compresses payloads with zstd
+type ZstdCodec struct {
+	level int
+}
+
+// NewZstdCodec returns a zstd codec compressing at the given level
+func NewZstdCodec(level int) *ZstdCodec {
+	if level < zstd.BestSpeed {
+		level = zstd.BestSpeed
+	} else if level > zstd.BestCompression {
+		level = zstd.BestCompression
+	}
+	return &ZstdCodec{level: level}
+}
+
+// ContentEncoding returns the value of the `Content-Encoding` HTTP header
+func (c *ZstdCodec) ContentEncoding() string {
+	return ZstdKind
+}
+
+// Compress compresses src
+func (c *ZstdCodec) Compress(dst []byte, src []byte) ([]byte, error) {
+	return zstd.CompressLevel(dst, src, c.level)
+}
+
+// Decompress decompresses src
+func (c *ZstdCodec) Decompress(dst []byte, src []byte) ([]byte, error) {
+	return zstd.Decompress(dst, src)
+}
+
+// CompressBound returns the worst case size of sourceLen compressed bytes
+func (c *ZstdCodec) CompressBound(sourceLen int) int {
+	return zstd.CompressBound(sourceLen)
+}
+
+// NewStreamWriter returns a writer compressing into w
+func (c *ZstdCodec) NewStreamWriter(w io.Writer) (StreamWriter, error) {
+	return zstd.NewWriterLevel(w, c.level), nil
+}
+
+// NoneCodec does not compress payloads
+var NoneCodec Codec = noneCodec{}
+
+type noneCodec struct{}
+
+func (noneCodec) ContentEncoding() string {
+	return ""
+}
+
+func (noneCodec) Compress(dst []byte, src []byte) ([]byte, error) {
+	return src, nil
+}
+
+func (noneCodec) Decompress(dst []byte, src []byte) ([]byte, error) {
+	return src, nil
+}
+
+func (noneCodec) CompressBound(sourceLen int) int {
+	return sourceLen
+}
+
+func (noneCodec) NewStreamWriter(w io.Writer) (StreamWriter, error) {
+	return nopStreamWriter{w}, nil
+}
+
+// nopStreamWriter writes the data as is
+type nopStreamWriter struct {
+	io.Writer
+}
+
+func (nopStreamWriter) Flush() error { return nil }
+func (nopStreamWriter) Close() error { return nil }
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compression
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecs(t *testing.T) {
+	payload := []byte(strings.Repeat("some payload to compress,", 100))
+
+	for _, kind := range []string{ZlibKind, ZstdKind, NoneKind} {
+		t.Run(kind, func(t *testing.T) {
+			codec, err := NewCodec(kind, 5)
+			require.NoError(t, err)
+
+			compressed, err := codec.Compress(nil, payload)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(compressed), codec.CompressBound(len(payload)))
+
+			decompressed, err := codec.Decompress(nil, compressed)
+			require.NoError(t, err)
+			assert.Equal(t, payload, decompressed)
+
+			// the payloads written in several chunks can be decompressed as a whole
+			var buf bytes.Buffer
+			w, err := codec.NewStreamWriter(&buf)
+			require.NoError(t, err)
+			_, err = w.Write(payload[:100])
+			require.NoError(t, err)
+			require.NoError(t, w.Flush())
+			_, err = w.Write(payload[100:])
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			decompressed, err = codec.Decompress(nil, buf.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, payload, decompressed)
+
+			// the content encoding sent with the payloads allows to decompress them
+			decoder, err := CodecForContentEncoding(codec.ContentEncoding())
+			require.NoError(t, err)
+			decompressed, err = decoder.Decompress(nil, compressed)
+			require.NoError(t, err)
+			assert.Equal(t, payload, decompressed)
+		})
+	}
+}
+
+func TestNewCodecUnknownKind(t *testing.T) {
+	_, err := NewCodec("lz4", 0)
+	assert.Error(t, err)
+}
+
+func TestNewZstdCodecLevel(t *testing.T) {
+	assert.Equal(t, 1, NewZstdCodec(-3).level)
+	assert.Equal(t, 20, NewZstdCodec(42).level)
+	assert.Equal(t, 7, NewZstdCodec(7).level)
+}
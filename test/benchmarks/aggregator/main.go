@@ -91,6 +91,9 @@ func (f *forwarderBenchStub) SubmitSeries(payload forwarder.Payloads, extraHeade
 func (f *forwarderBenchStub) SubmitSketchSeries(payload forwarder.Payloads, extraHeaders http.Header) error {
 	return nil
 }
+func (f *forwarderBenchStub) SubmitPrometheusRemoteWrite(payload forwarder.Payloads, extraHeaders http.Header) error {
+	return nil
+}
 func (f *forwarderBenchStub) SubmitHostMetadata(payload forwarder.Payloads, extraHeaders http.Header) error {
 	return nil
 }
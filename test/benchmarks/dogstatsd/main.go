@@ -95,6 +95,10 @@ func (f *forwarderBenchStub) SubmitSketchSeries(payloads forwarder.Payloads, ext
 	f.computeStats(payloads)
 	return nil
 }
+func (f *forwarderBenchStub) SubmitPrometheusRemoteWrite(payloads forwarder.Payloads, extraHeaders http.Header) error {
+	f.computeStats(payloads)
+	return nil
+}
 func (f *forwarderBenchStub) SubmitHostMetadata(payloads forwarder.Payloads, extraHeaders http.Header) error {
 	f.computeStats(payloads)
 	return nil